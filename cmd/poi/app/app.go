@@ -20,7 +20,9 @@ import (
 
 	"apify-poi-data/config"
 	sqlcdb "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/services"
 	"apify-poi-data/pkg/health"
+	"apify-poi-data/pkg/taxonomy"
 )
 
 var (
@@ -34,7 +36,10 @@ var (
 )
 
 var (
-	db *sqlcdb.Database
+	db             *sqlcdb.Database
+	imageArchive   *services.ImageArchive // nil when images are not archived
+	mapsService    *services.MapsService
+	outboxListener *services.OutboxListener
 )
 
 func init() {
@@ -74,6 +79,18 @@ func Run() {
 	}
 	defer db.Close()
 
+	if err := services.SyncTaxonomy(ctx, db, taxonomy.Current); err != nil {
+		panic(fmt.Errorf("unable to store the category taxonomy; err=%v", err))
+	}
+
+	imageArchive, err = services.NewImageArchive(cfg.Images, db)
+	if err != nil {
+		panic(fmt.Errorf("unable to set up the image archive; err=%v", err))
+	}
+
+	mapsService = newMapsService()
+	outboxListener = services.NewOutboxListener(db)
+
 	// Start gRPC server
 	g.Add(func() error {
 		log.Println("Starting GRPC server...")
@@ -88,6 +105,53 @@ func Run() {
 		cancel()
 	})
 
+	// Start the saved search scheduler
+	if cfg.Scheduler.Enabled {
+		scheduler := services.NewScheduler(cfg.Scheduler, mapsService)
+		g.Add(func() error {
+			log.Println("Starting saved search scheduler...")
+			return scheduler.Run(ctx)
+		}, func(err error) {
+			log.Println("Shutting down saved search scheduler...")
+			cancel()
+		})
+	}
+
+	// Start the outbox relay
+	publisher, err := services.NewPublisher(cfg.Outbox)
+	if err != nil {
+		panic(fmt.Errorf("unable to connect to the outbox publisher; err=%v", err))
+	}
+	relay := services.NewOutboxRelay(cfg.Outbox, db, publisher)
+	g.Add(func() error {
+		log.Printf("Starting outbox relay to %s...", cfg.Outbox.Publisher)
+		return relay.Run(ctx)
+	}, func(err error) {
+		log.Println("Shutting down outbox relay...")
+		cancel()
+	})
+
+	// Start the webhook dispatcher
+	if cfg.Webhooks.Enabled {
+		dispatcher := services.NewWebhookDispatcher(cfg.Webhooks, db, outboxListener)
+		g.Add(func() error {
+			log.Println("Starting webhook dispatcher...")
+			return dispatcher.Run(ctx)
+		}, func(err error) {
+			log.Println("Shutting down webhook dispatcher...")
+			cancel()
+		})
+	}
+
+	// Start the outbox listener that wakes up the watch streams and the webhook dispatcher
+	g.Add(func() error {
+		log.Println("Starting outbox listener...")
+		return outboxListener.Run(ctx)
+	}, func(err error) {
+		log.Println("Shutting down outbox listener...")
+		cancel()
+	})
+
 	// Start HTTP server
 	g.Add(func() error {
 		log.Println("Starting HTTP server...")
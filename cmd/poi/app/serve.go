@@ -16,11 +16,14 @@ import (
 	"google.golang.org/grpc/grpclog"
 	"google.golang.org/grpc/reflection"
 
+	"apify-poi-data/internal/models"
 	"apify-poi-data/internal/services"
 	"apify-poi-data/pkg/apify"
+	admin_v1 "apify-poi-data/proto/apify/admin/v1"
 	maps_v1 "apify-poi-data/proto/apify/maps/v1"
 	poi_v1 "apify-poi-data/proto/apify/poi/v1"
 	tripsadvisor_v1 "apify-poi-data/proto/apify/tripsadvisor/v1"
+	webhook_v1 "apify-poi-data/proto/apify/webhook/v1"
 )
 
 func setupGRPCServer(port int, tlsConfig *tls.Config) (*grpc.Server, net.Listener, error) {
@@ -30,21 +33,27 @@ func setupGRPCServer(port int, tlsConfig *tls.Config) (*grpc.Server, net.Listene
 	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)))
 
 	// Register services
-	maps_v1.RegisterMapsServiceServer(server, &services.MapsService{
-		ApifyClient: apify.NewClient(
-			cfg.Apify.Key,
-			cfg.Apify.ActorExtractorID,
-			cfg.Apify.ActorScraperID,
-		),
+	maps_v1.RegisterMapsServiceServer(server, mapsService)
+	admin_v1.RegisterAdminServiceServer(server, &services.AdminService{
 		Database: db,
+		Maps:     mapsService,
+	})
+	tripsadvisor_v1.RegisterTripadvisorServiceServer(server, &services.TripadvisorService{
+		Database:    db,
+		ApifyClient: mapsService.ApifyClient,
+		Maps:        mapsService,
 	})
-	tripsadvisor_v1.RegisterTripadvisorServiceServer(server, &services.TripadvisorService{})
 	poi_v1.RegisterPoiServiceServer(
 		server,
 		&services.PoiService{
 			Database: db,
+			Outbox:   outboxListener,
 		},
 	)
+	webhook_v1.RegisterWebhookServiceServer(server, &services.WebhookService{
+		Database: db,
+		Config:   cfg.Webhooks,
+	})
 
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
@@ -56,6 +65,34 @@ func setupGRPCServer(port int, tlsConfig *tls.Config) (*grpc.Server, net.Listene
 	return server, listener, nil
 }
 
+// newMapsService creates the maps service shared by the gRPC server and the scheduler.
+func newMapsService() *services.MapsService {
+	// Datasets of the configured actors are decoded by their matching parser.
+	// An unset actor gets no alias, so that an empty actor id resolves to nothing.
+	if cfg.Apify.ActorExtractorID != "" {
+		models.DefaultRegistry.Alias(cfg.Apify.ActorExtractorID, models.ParserGoogleMapsExtractor)
+	}
+	if cfg.Apify.ActorScraperID != "" {
+		models.DefaultRegistry.Alias(cfg.Apify.ActorScraperID, models.ParserGoogleMapsScraper)
+	}
+	if cfg.Apify.ActorTripadvisorID != "" {
+		models.DefaultRegistry.Alias(cfg.Apify.ActorTripadvisorID, models.ParserTripadvisor)
+	}
+
+	return &services.MapsService{
+		ApifyClient: apify.NewClient(
+			cfg.Apify.Key,
+			cfg.Apify.ActorExtractorID,
+			cfg.Apify.ActorScraperID,
+			cfg.Apify.ActorTripadvisorID,
+		),
+		Database: db,
+		Ingest:   cfg.Ingest,
+		Images:   imageArchive,
+		Changes:  cfg.Changes,
+	}
+}
+
 func ShutdownGRPCServer(server *grpc.Server) {
 	server.GracefulStop()
 }
@@ -87,6 +124,26 @@ func SetupHTTPMux(ctx context.Context, grpcPort, httpPort int, tlsConfig *tls.Co
 		return nil, err
 	}
 
+	err = admin_v1.RegisterAdminServiceHandlerFromEndpoint(ctx, mux, fmt.Sprintf("localhost:%d", grpcPort), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = webhook_v1.RegisterWebhookServiceHandlerFromEndpoint(ctx, mux, fmt.Sprintf("localhost:%d", grpcPort), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Archived images are plain HTTP, not part of a gRPC service
+	if imageArchive != nil {
+		if err := mux.HandlePath("GET", "/v1/images/{id}", imageArchive.ServeImage); err != nil {
+			return nil, err
+		}
+		if err := mux.HandlePath("GET", "/v1/images/{id}/thumbnail", imageArchive.ServeThumbnail); err != nil {
+			return nil, err
+		}
+	}
+
 	// Register gRPC gateway handlers
 	server := &http.Server{
 		Addr:              fmt.Sprintf(":%d", httpPort),
@@ -3,9 +3,10 @@ package config
 import "errors"
 
 type Apify struct {
-	Key              string `mapstructure:"key"`
-	ActorExtractorID string `mapstructure:"actor_extractor_id"`
-	ActorScraperID   string `mapstructure:"actor_scraper_id"`
+	Key                string `mapstructure:"key"`
+	ActorExtractorID   string `mapstructure:"actor_extractor_id"`
+	ActorScraperID     string `mapstructure:"actor_scraper_id"`
+	ActorTripadvisorID string `mapstructure:"actor_tripadvisor_id"` // Optional, SearchTripadvisor fails without it
 }
 
 func (a *Apify) Validate() error {
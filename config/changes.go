@@ -0,0 +1,23 @@
+package config
+
+import "errors"
+
+// Changes configures which differences between scrapes are recorded as events.
+type Changes struct {
+	RatingJump         float64 `mapstructure:"rating_jump"`          // Smallest rating difference reported, in stars
+	MoveMeters         float64 `mapstructure:"move_meters"`          // Smallest location difference reported
+	DisappearAfterRuns int     `mapstructure:"disappear_after_runs"` // Consecutive saved search runs without a place before it disappeared
+}
+
+func (c *Changes) Validate() error {
+	if c.RatingJump <= 0 {
+		return errors.New("changes rating jump must be positive")
+	}
+	if c.MoveMeters <= 0 {
+		return errors.New("changes move meters must be positive")
+	}
+	if c.DisappearAfterRuns < 1 {
+		return errors.New("changes disappear after runs must be at least 1")
+	}
+	return nil
+}
@@ -19,6 +19,66 @@ const (
 	apifyKey            = "APIFY.KEY"
 	apifyExtractorActor = "APIFY.ACTOR.EXTRACTOR.ID"
 	apifyScraperActor   = "APIFY.ACTOR.SCRAPER.ID"
+	apifyTripadvisor    = "APIFY.ACTOR.TRIPADVISOR.ID"
+)
+
+const (
+	ingestDecodeWorkers    = "INGEST.Workers.Decode"
+	ingestNormalizeWorkers = "INGEST.Workers.Normalize"
+	ingestH3Workers        = "INGEST.Workers.H3"
+	ingestWriteWorkers     = "INGEST.Workers.Write"
+	ingestBuffer           = "INGEST.Buffer"
+	ingestAreaToleranceKm  = "INGEST.SearchArea.ToleranceKm"
+)
+
+const (
+	imagesEnabled       = "IMAGES.Enabled"
+	imagesStore         = "IMAGES.Store"
+	imagesDir           = "IMAGES.Dir"
+	imagesWorkers       = "IMAGES.Workers"
+	imagesMaxPerPOI     = "IMAGES.MaxPerPOI"
+	imagesMaxBytes      = "IMAGES.MaxBytes"
+	imagesThumbnailSize = "IMAGES.ThumbnailSize"
+	imagesS3Endpoint    = "IMAGES.S3.Endpoint"
+	imagesS3Region      = "IMAGES.S3.Region"
+	imagesS3Bucket      = "IMAGES.S3.Bucket"
+	imagesS3AccessKey   = "IMAGES.S3.AccessKey"
+	imagesS3SecretKey   = "IMAGES.S3.SecretKey"
+	imagesS3PathStyle   = "IMAGES.S3.PathStyle"
+)
+
+const (
+	schedulerEnabled      = "SCHEDULER.Enabled"
+	schedulerPollInterval = "SCHEDULER.PollInterval"
+	schedulerWorkers      = "SCHEDULER.Workers"
+)
+
+const (
+	changesRatingJump         = "CHANGES.RatingJump"
+	changesMoveMeters         = "CHANGES.MoveMeters"
+	changesDisappearAfterRuns = "CHANGES.DisappearAfterRuns"
+)
+
+const (
+	outboxPublisher    = "OUTBOX.Publisher"
+	outboxPrefix       = "OUTBOX.Prefix"
+	outboxPollInterval = "OUTBOX.PollInterval"
+	outboxBatchSize    = "OUTBOX.BatchSize"
+	outboxRetention    = "OUTBOX.Retention"
+	outboxNATSURL      = "OUTBOX.NATS.URL"
+	outboxKafkaRESTURL = "OUTBOX.Kafka.RESTURL"
+)
+
+const (
+	webhooksEnabled      = "WEBHOOKS.Enabled"
+	webhooksPollInterval = "WEBHOOKS.PollInterval"
+	webhooksBatchSize    = "WEBHOOKS.BatchSize"
+	webhooksTimeout      = "WEBHOOKS.Timeout"
+	webhooksMaxAttempts  = "WEBHOOKS.MaxAttempts"
+	webhooksBackoffBase  = "WEBHOOKS.BackoffBase"
+	webhooksBackoffMax   = "WEBHOOKS.BackoffMax"
+	webhooksSecretGrace  = "WEBHOOKS.SecretGrace"
+	webhooksRetention    = "WEBHOOKS.Retention"
 )
 
 const (
@@ -36,9 +96,15 @@ type Conf interface {
 }
 
 type Config struct {
-	Database Postgres `mapstructure:"database"`
-	Ports    Ports    `mapstructure:"ports"`
-	Apify    Apify    `mapstructure:"apify"`
+	Database  Postgres  `mapstructure:"database"`
+	Ports     Ports     `mapstructure:"ports"`
+	Apify     Apify     `mapstructure:"apify"`
+	Ingest    Ingest    `mapstructure:"ingest"`
+	Images    Images    `mapstructure:"images"`
+	Scheduler Scheduler `mapstructure:"scheduler"`
+	Changes   Changes   `mapstructure:"changes"`
+	Outbox    Outbox    `mapstructure:"outbox"`
+	Webhooks  Webhooks  `mapstructure:"webhooks"`
 }
 
 func NewConfig() *Config {
@@ -55,6 +121,24 @@ func (c *Config) Validate() error {
 	if err := c.Apify.Validate(); err != nil {
 		return err
 	}
+	if err := c.Ingest.Validate(); err != nil {
+		return err
+	}
+	if err := c.Images.Validate(); err != nil {
+		return err
+	}
+	if err := c.Scheduler.Validate(); err != nil {
+		return err
+	}
+	if err := c.Changes.Validate(); err != nil {
+		return err
+	}
+	if err := c.Outbox.Validate(); err != nil {
+		return err
+	}
+	if err := c.Webhooks.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -114,13 +198,63 @@ func viperSetup() (*viper.Viper, error) {
 	root.SetDefault(apifyKey, "apify_api_XmchhEKoin5t3ienQ2yDLxAG0ZdmGy2WFxIm")
 	root.SetDefault(apifyExtractorActor, "hGfcPZSlUoZsx2E9q")
 	root.SetDefault(apifyScraperActor, "n83ynZgGnAlyfHr38")
+	root.SetDefault(apifyTripadvisor, "") // Tripadvisor searches fail until a task is configured
+
+	// Writes wait on the database, so they get more workers than the CPU bound stages
+	root.SetDefault(ingestDecodeWorkers, 4)
+	root.SetDefault(ingestNormalizeWorkers, 4)
+	root.SetDefault(ingestH3Workers, 2)
+	root.SetDefault(ingestWriteWorkers, 8)
+	root.SetDefault(ingestBuffer, 64)
+	root.SetDefault(ingestAreaToleranceKm, 10)
+
+	// Images are not archived unless enabled
+	root.SetDefault(imagesEnabled, false)
+	root.SetDefault(imagesStore, "fs")
+	root.SetDefault(imagesDir, "data/images")
+	root.SetDefault(imagesWorkers, 4)
+	root.SetDefault(imagesMaxPerPOI, 10)
+	root.SetDefault(imagesMaxBytes, 10<<20)
+	root.SetDefault(imagesThumbnailSize, 320)
+	root.SetDefault(imagesS3Region, "us-east-1")
+	root.SetDefault(imagesS3PathStyle, true)
+
+	// Saved searches are checked every minute, the finest cron resolution
+	root.SetDefault(schedulerEnabled, true)
+	root.SetDefault(schedulerPollInterval, "1m")
+	root.SetDefault(schedulerWorkers, 2)
+
+	// Rating and location noise between scrapes stays below these
+	root.SetDefault(changesRatingJump, 0.5)
+	root.SetDefault(changesMoveMeters, 100)
+	root.SetDefault(changesDisappearAfterRuns, 3)
+
+	// Messages are logged until a broker is configured
+	root.SetDefault(outboxPublisher, "log")
+	root.SetDefault(outboxPrefix, "poi")
+	root.SetDefault(outboxPollInterval, "1s")
+	root.SetDefault(outboxBatchSize, 100)
+	root.SetDefault(outboxRetention, "168h")
+	root.SetDefault(outboxNATSURL, "nats://localhost:4222")
+	root.SetDefault(outboxKafkaRESTURL, "")
+
+	// Failed deliveries are retried for about an hour before they are dead
+	root.SetDefault(webhooksEnabled, true)
+	root.SetDefault(webhooksPollInterval, "1s")
+	root.SetDefault(webhooksBatchSize, 20)
+	root.SetDefault(webhooksTimeout, "10s")
+	root.SetDefault(webhooksMaxAttempts, 8)
+	root.SetDefault(webhooksBackoffBase, "30s")
+	root.SetDefault(webhooksBackoffMax, "1h")
+	root.SetDefault(webhooksSecretGrace, "24h")
+	root.SetDefault(webhooksRetention, "720h")
 
 	root.SetDefault(dbUser, "postgres")
 	root.SetDefault(dbPassword, "postgres")
 	root.SetDefault(dbHost, "localhost")
 	root.SetDefault(dbName, "POIRawData")
 	root.SetDefault(dbMigration, "db/migrations")
-	root.SetDefault(dbVersion, 1)
+	root.SetDefault(dbVersion, 19)
 	root.SetDefault(dbURL, "")
 
 	return root, nil
@@ -143,6 +277,54 @@ func getValues(root *viper.Viper, cfg *Config) {
 	cfg.Apify.Key = root.GetString(apifyKey)
 	cfg.Apify.ActorExtractorID = root.GetString(apifyExtractorActor)
 	cfg.Apify.ActorScraperID = root.GetString(apifyScraperActor)
+	cfg.Apify.ActorTripadvisorID = root.GetString(apifyTripadvisor)
+
+	cfg.Ingest.DecodeWorkers = root.GetInt(ingestDecodeWorkers)
+	cfg.Ingest.NormalizeWorkers = root.GetInt(ingestNormalizeWorkers)
+	cfg.Ingest.H3Workers = root.GetInt(ingestH3Workers)
+	cfg.Ingest.WriteWorkers = root.GetInt(ingestWriteWorkers)
+	cfg.Ingest.Buffer = root.GetInt(ingestBuffer)
+	cfg.Ingest.SearchAreaToleranceKm = root.GetFloat64(ingestAreaToleranceKm)
+
+	cfg.Images.Enabled = root.GetBool(imagesEnabled)
+	cfg.Images.Store = root.GetString(imagesStore)
+	cfg.Images.Dir = root.GetString(imagesDir)
+	cfg.Images.Workers = root.GetInt(imagesWorkers)
+	cfg.Images.MaxPerPOI = root.GetInt(imagesMaxPerPOI)
+	cfg.Images.MaxBytes = root.GetInt64(imagesMaxBytes)
+	cfg.Images.ThumbnailSize = root.GetInt(imagesThumbnailSize)
+	cfg.Images.S3Endpoint = root.GetString(imagesS3Endpoint)
+	cfg.Images.S3Region = root.GetString(imagesS3Region)
+	cfg.Images.S3Bucket = root.GetString(imagesS3Bucket)
+	cfg.Images.S3AccessKey = root.GetString(imagesS3AccessKey)
+	cfg.Images.S3SecretKey = root.GetString(imagesS3SecretKey)
+	cfg.Images.S3PathStyle = root.GetBool(imagesS3PathStyle)
+
+	cfg.Scheduler.Enabled = root.GetBool(schedulerEnabled)
+	cfg.Scheduler.PollInterval = root.GetDuration(schedulerPollInterval)
+	cfg.Scheduler.Workers = root.GetInt(schedulerWorkers)
+
+	cfg.Changes.RatingJump = root.GetFloat64(changesRatingJump)
+	cfg.Changes.MoveMeters = root.GetFloat64(changesMoveMeters)
+	cfg.Changes.DisappearAfterRuns = root.GetInt(changesDisappearAfterRuns)
+
+	cfg.Outbox.Publisher = root.GetString(outboxPublisher)
+	cfg.Outbox.Prefix = root.GetString(outboxPrefix)
+	cfg.Outbox.PollInterval = root.GetDuration(outboxPollInterval)
+	cfg.Outbox.BatchSize = root.GetInt(outboxBatchSize)
+	cfg.Outbox.Retention = root.GetDuration(outboxRetention)
+	cfg.Outbox.NATSURL = root.GetString(outboxNATSURL)
+	cfg.Outbox.KafkaRESTURL = root.GetString(outboxKafkaRESTURL)
+
+	cfg.Webhooks.Enabled = root.GetBool(webhooksEnabled)
+	cfg.Webhooks.PollInterval = root.GetDuration(webhooksPollInterval)
+	cfg.Webhooks.BatchSize = root.GetInt(webhooksBatchSize)
+	cfg.Webhooks.Timeout = root.GetDuration(webhooksTimeout)
+	cfg.Webhooks.MaxAttempts = root.GetInt(webhooksMaxAttempts)
+	cfg.Webhooks.BackoffBase = root.GetDuration(webhooksBackoffBase)
+	cfg.Webhooks.BackoffMax = root.GetDuration(webhooksBackoffMax)
+	cfg.Webhooks.SecretGrace = root.GetDuration(webhooksSecretGrace)
+	cfg.Webhooks.Retention = root.GetDuration(webhooksRetention)
 
 	cfg.Database.URL = fmt.Sprintf(
 		"postgres://%s:%s@%s:%d/%s?sslmode=disable",
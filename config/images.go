@@ -0,0 +1,52 @@
+package config
+
+import "errors"
+
+// Images configures the optional archive of scraped images.
+type Images struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	Store         string `mapstructure:"store"` // fs or s3
+	Dir           string `mapstructure:"dir"`   // Root of the fs store
+	Workers       int    `mapstructure:"workers"`
+	MaxPerPOI     int    `mapstructure:"max_per_poi"`
+	MaxBytes      int64  `mapstructure:"max_bytes"`      // Larger images are not archived
+	ThumbnailSize int    `mapstructure:"thumbnail_size"` // Longer side of thumbnails, in pixels
+
+	S3Endpoint  string `mapstructure:"s3_endpoint"`
+	S3Region    string `mapstructure:"s3_region"`
+	S3Bucket    string `mapstructure:"s3_bucket"`
+	S3AccessKey string `mapstructure:"s3_access_key"`
+	S3SecretKey string `mapstructure:"s3_secret_key"`
+	S3PathStyle bool   `mapstructure:"s3_path_style"`
+}
+
+func (i *Images) Validate() error {
+	if !i.Enabled {
+		return nil
+	}
+	switch i.Store {
+	case "fs":
+		if i.Dir == "" {
+			return errors.New("images dir is required for the fs store")
+		}
+	case "s3":
+		if i.S3Endpoint == "" || i.S3Bucket == "" {
+			return errors.New("images S3 endpoint and bucket are required for the s3 store")
+		}
+	default:
+		return errors.New("images store must be fs or s3")
+	}
+	if i.Workers < 1 {
+		return errors.New("images workers must be at least 1")
+	}
+	if i.MaxPerPOI < 1 {
+		return errors.New("images max per POI must be at least 1")
+	}
+	if i.MaxBytes < 1 {
+		return errors.New("images max bytes must be at least 1")
+	}
+	if i.ThumbnailSize < 16 {
+		return errors.New("images thumbnail size must be at least 16")
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package config
+
+import "errors"
+
+// Ingest sizes the stages of the ingestion pipeline.
+type Ingest struct {
+	DecodeWorkers    int `mapstructure:"decode_workers"`
+	NormalizeWorkers int `mapstructure:"normalize_workers"`
+	H3Workers        int `mapstructure:"h3_workers"`
+	WriteWorkers     int `mapstructure:"write_workers"`
+	Buffer           int `mapstructure:"buffer"` // Items queued between two stages
+
+	// POIs further than this outside the searched polygons are quarantined
+	SearchAreaToleranceKm float64 `mapstructure:"search_area_tolerance_km"`
+}
+
+func (i *Ingest) Validate() error {
+	if i.DecodeWorkers < 1 {
+		return errors.New("ingest decode workers must be at least 1")
+	}
+	if i.NormalizeWorkers < 1 {
+		return errors.New("ingest normalize workers must be at least 1")
+	}
+	if i.H3Workers < 1 {
+		return errors.New("ingest h3 workers must be at least 1")
+	}
+	if i.WriteWorkers < 1 {
+		return errors.New("ingest write workers must be at least 1")
+	}
+	if i.Buffer < 0 {
+		return errors.New("ingest buffer must not be negative")
+	}
+	if i.SearchAreaToleranceKm < 0 {
+		return errors.New("ingest search area tolerance must not be negative")
+	}
+	return nil
+}
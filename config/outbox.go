@@ -0,0 +1,53 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	PublisherLog   = "log"
+	PublisherNATS  = "nats"
+	PublisherKafka = "kafka"
+)
+
+// Outbox configures the relay that publishes the outbox to a broker.
+type Outbox struct {
+	Publisher    string        `mapstructure:"publisher"`     // log, nats or kafka
+	Prefix       string        `mapstructure:"prefix"`        // NATS subject and Kafka topic prefix
+	PollInterval time.Duration `mapstructure:"poll_interval"` // How often new messages are looked for
+	BatchSize    int           `mapstructure:"batch_size"`    // Messages published at once
+	Retention    time.Duration `mapstructure:"retention"`     // How long published messages can be resumed from
+	NATSURL      string        `mapstructure:"nats_url"`
+	KafkaRESTURL string        `mapstructure:"kafka_rest_url"` // Kafka REST Proxy
+}
+
+func (o *Outbox) Validate() error {
+	switch o.Publisher {
+	case PublisherLog:
+	case PublisherNATS:
+		if o.NATSURL == "" {
+			return errors.New("outbox NATS URL is required")
+		}
+	case PublisherKafka:
+		if o.KafkaRESTURL == "" {
+			return errors.New("outbox Kafka REST URL is required")
+		}
+	default:
+		return fmt.Errorf("unknown outbox publisher: %q", o.Publisher)
+	}
+	if o.Prefix == "" {
+		return errors.New("outbox prefix is required")
+	}
+	if o.PollInterval < 100*time.Millisecond {
+		return errors.New("outbox poll interval must be at least 100ms")
+	}
+	if o.BatchSize < 1 {
+		return errors.New("outbox batch size must be at least 1")
+	}
+	if o.Retention < time.Hour {
+		return errors.New("outbox retention must be at least 1h")
+	}
+	return nil
+}
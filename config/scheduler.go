@@ -0,0 +1,26 @@
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+// Scheduler configures the in-process scheduler that runs saved searches.
+type Scheduler struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	PollInterval time.Duration `mapstructure:"poll_interval"` // How often due searches are looked for
+	Workers      int           `mapstructure:"workers"`       // Saved searches scraped at the same time
+}
+
+func (s *Scheduler) Validate() error {
+	if !s.Enabled {
+		return nil
+	}
+	if s.PollInterval < time.Second {
+		return errors.New("scheduler poll interval must be at least 1s")
+	}
+	if s.Workers < 1 {
+		return errors.New("scheduler workers must be at least 1")
+	}
+	return nil
+}
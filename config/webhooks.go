@@ -0,0 +1,47 @@
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+// Webhooks configures the dispatcher that calls webhook subscribers.
+type Webhooks struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	PollInterval time.Duration `mapstructure:"poll_interval"` // How often due deliveries are looked for
+	BatchSize    int           `mapstructure:"batch_size"`    // Deliveries sent at the same time
+	Timeout      time.Duration `mapstructure:"timeout"`       // Per request
+	MaxAttempts  int           `mapstructure:"max_attempts"`  // Before a delivery is dead
+	BackoffBase  time.Duration `mapstructure:"backoff_base"`  // Wait after the first failure, doubled after each next one
+	BackoffMax   time.Duration `mapstructure:"backoff_max"`
+	SecretGrace  time.Duration `mapstructure:"secret_grace"` // How long a rotated secret keeps signing requests
+	Retention    time.Duration `mapstructure:"retention"`    // How long delivered deliveries are logged
+}
+
+func (w *Webhooks) Validate() error {
+	if !w.Enabled {
+		return nil
+	}
+	if w.PollInterval < 100*time.Millisecond {
+		return errors.New("webhooks poll interval must be at least 100ms")
+	}
+	if w.BatchSize < 1 {
+		return errors.New("webhooks batch size must be at least 1")
+	}
+	if w.Timeout <= 0 {
+		return errors.New("webhooks timeout must be positive")
+	}
+	if w.MaxAttempts < 1 {
+		return errors.New("webhooks max attempts must be at least 1")
+	}
+	if w.BackoffBase <= 0 || w.BackoffMax < w.BackoffBase {
+		return errors.New("webhooks backoff base must be positive and at most the backoff max")
+	}
+	if w.SecretGrace < 0 {
+		return errors.New("webhooks secret grace must not be negative")
+	}
+	if w.Retention < time.Hour {
+		return errors.New("webhooks retention must be at least 1h")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+-- 1) Original item JSON per POI and source run, so the mapping can be re-run without re-scraping
+CREATE TABLE IF NOT EXISTS poi_data_schema.raw_items (
+    id BIGSERIAL PRIMARY KEY,
+    item_id TEXT NOT NULL,        -- place_id for Google Maps, location id for Tripadvisor
+    parser TEXT NOT NULL,         -- name of the parser that decoded the item
+    source_run_id TEXT NOT NULL,  -- Apify run or dataset the item came from
+    content_hash TEXT NOT NULL,   -- sha256 of the item
+    item JSONB NOT NULL,
+    location_lat DOUBLE PRECISION,
+    location_lng DOUBLE PRECISION,
+    geom geometry(Point, 4326),
+    scraped_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (item_id, source_run_id)
+);
+
+-- 2) Items are large and rarely read; lz4 is cheaper than the default pglz
+ALTER TABLE poi_data_schema.raw_items
+  ALTER COLUMN item SET COMPRESSION lz4;
+
+-- 3) Indexes for selecting the items to reprocess
+CREATE INDEX IF NOT EXISTS idx_raw_items_geom
+  ON poi_data_schema.raw_items
+  USING GIST (geom);
+
+CREATE INDEX IF NOT EXISTS idx_raw_items_source_run_id
+  ON poi_data_schema.raw_items (source_run_id);
+
+CREATE INDEX IF NOT EXISTS idx_raw_items_scraped_at
+  ON poi_data_schema.raw_items (scraped_at);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS poi_data_schema.dataset_imports;
@@ -0,0 +1,18 @@
+-- 1) Ledger of dataset imports, so the same dataset content is only processed once
+CREATE TABLE IF NOT EXISTS poi_data_schema.dataset_imports (
+    id BIGSERIAL PRIMARY KEY,
+    dataset_id TEXT NOT NULL,
+    content_hash TEXT NOT NULL,    -- sha256 of the downloaded dataset
+    parser TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('running', 'succeeded', 'failed')),
+    item_count INTEGER NOT NULL DEFAULT 0,
+    inserted_count INTEGER NOT NULL DEFAULT 0,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ,
+    UNIQUE (dataset_id, content_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dataset_imports_dataset_id
+  ON poi_data_schema.dataset_imports (dataset_id);
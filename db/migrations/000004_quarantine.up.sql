@@ -0,0 +1,18 @@
+-- 1) POIs held back because their coordinates failed validation, pending review
+CREATE TABLE IF NOT EXISTS poi_data_schema.quarantine (
+    id BIGSERIAL PRIMARY KEY,
+    item_id TEXT NOT NULL,        -- place_id for Google Maps
+    parser TEXT NOT NULL,
+    source_run_id TEXT NOT NULL,  -- empty when the item was reprocessed
+    reason TEXT NOT NULL,         -- e.g. swapped_coordinates, outside_search_area
+    details TEXT,
+    location_lat DOUBLE PRECISION,
+    location_lng DOUBLE PRECISION,
+    item JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'released', 'discarded')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    reviewed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_quarantine_status_reason
+  ON poi_data_schema.quarantine (status, reason);
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS poi_data_schema.idx_google_maps_address_postal_code;
+DROP INDEX IF EXISTS poi_data_schema.idx_google_maps_address_key;
+DROP INDEX IF EXISTS poi_data_schema.idx_google_maps_website_host;
+DROP INDEX IF EXISTS poi_data_schema.idx_google_maps_phone_e164;
+
+ALTER TABLE poi_data_schema.google_maps
+  DROP COLUMN IF EXISTS phone_e164,
+  DROP COLUMN IF EXISTS website_url,
+  DROP COLUMN IF EXISTS website_host,
+  DROP COLUMN IF EXISTS address_street,
+  DROP COLUMN IF EXISTS address_house_number,
+  DROP COLUMN IF EXISTS address_postal_code,
+  DROP COLUMN IF EXISTS address_city,
+  DROP COLUMN IF EXISTS address_country_code,
+  DROP COLUMN IF EXISTS address_key;
@@ -0,0 +1,25 @@
+-- 1) Normalized contact and address values, used as match keys and filters.
+--    Existing rows are filled in by reprocessing their raw items.
+ALTER TABLE poi_data_schema.google_maps
+  ADD COLUMN IF NOT EXISTS phone_e164 TEXT,
+  ADD COLUMN IF NOT EXISTS website_url TEXT,           -- canonical URL without tracking parameters
+  ADD COLUMN IF NOT EXISTS website_host TEXT,          -- lowercase hostname without www.
+  ADD COLUMN IF NOT EXISTS address_street TEXT,        -- street name without the house number
+  ADD COLUMN IF NOT EXISTS address_house_number TEXT,
+  ADD COLUMN IF NOT EXISTS address_postal_code TEXT,   -- uppercase, no spaces
+  ADD COLUMN IF NOT EXISTS address_city TEXT,
+  ADD COLUMN IF NOT EXISTS address_country_code TEXT,
+  ADD COLUMN IF NOT EXISTS address_key TEXT;           -- country|postal code|city|street|number, lowercase
+
+-- 2) Indexes for matching on the normalized values
+CREATE INDEX IF NOT EXISTS idx_google_maps_phone_e164
+  ON poi_data_schema.google_maps (phone_e164);
+
+CREATE INDEX IF NOT EXISTS idx_google_maps_website_host
+  ON poi_data_schema.google_maps (website_host);
+
+CREATE INDEX IF NOT EXISTS idx_google_maps_address_key
+  ON poi_data_schema.google_maps (address_key);
+
+CREATE INDEX IF NOT EXISTS idx_google_maps_address_postal_code
+  ON poi_data_schema.google_maps (address_country_code, address_postal_code);
@@ -0,0 +1,35 @@
+-- 1) Reviews of the scraped places, one row per review.
+--    review_id is stable across scrapes, so re-scraping a place updates its reviews in place.
+CREATE TABLE IF NOT EXISTS poi_data_schema.reviews (
+    id BIGSERIAL PRIMARY KEY,
+    review_id TEXT NOT NULL UNIQUE,
+    place_id TEXT NOT NULL,
+    rating SMALLINT,                -- 1 to 5 stars
+    text TEXT,
+    text_translated TEXT,
+    language TEXT,                  -- original language of the text, e.g. "en"
+    published_at TIMESTAMPTZ NOT NULL,
+    owner_response_text TEXT,
+    owner_response_at TIMESTAMPTZ,
+    likes_count INT,
+    review_url TEXT,
+    review_origin TEXT,             -- e.g. "Google"
+    image_urls TEXT[],
+    reviewer_id TEXT,
+    reviewer_name TEXT,
+    reviewer_url TEXT,
+    reviewer_photo_url TEXT,
+    reviewer_review_count INT,
+    reviewer_is_local_guide BOOLEAN,
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- 2) Listing the reviews of a place, newest first
+CREATE INDEX IF NOT EXISTS idx_reviews_place_published
+  ON poi_data_schema.reviews (place_id, published_at DESC, id DESC);
+
+-- 3) Text match over the original and the translated text
+CREATE INDEX IF NOT EXISTS idx_reviews_text
+  ON poi_data_schema.reviews
+  USING GIN (to_tsvector('simple', coalesce(text, '') || ' ' || coalesce(text_translated, '')));
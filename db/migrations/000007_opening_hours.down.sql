@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS poi_data_schema.opening_intervals;
+DROP TABLE IF EXISTS poi_data_schema.opening_hours;
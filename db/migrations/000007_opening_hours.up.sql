@@ -0,0 +1,24 @@
+-- 1) Opening hours of a POI in a common weekly model, per source.
+--    poi_id is the place_id for Google Maps and the location ID for Tripadvisor.
+CREATE TABLE IF NOT EXISTS poi_data_schema.opening_hours (
+    source TEXT NOT NULL,         -- google_maps or tripadvisor
+    poi_id TEXT NOT NULL,
+    timezone TEXT NOT NULL,       -- IANA name, e.g. Europe/Stockholm
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (source, poi_id)
+);
+
+-- 2) Weekly opening intervals in minutes since Monday 00:00 local time.
+--    Intervals past the end of the week are split at Sunday midnight.
+CREATE TABLE IF NOT EXISTS poi_data_schema.opening_intervals (
+    source TEXT NOT NULL,
+    poi_id TEXT NOT NULL,
+    start_minute INT NOT NULL CHECK (start_minute >= 0 AND start_minute < 10080),
+    end_minute INT NOT NULL CHECK (end_minute > start_minute AND end_minute <= 10080),
+    FOREIGN KEY (source, poi_id)
+      REFERENCES poi_data_schema.opening_hours (source, poi_id)
+      ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_opening_intervals_poi
+  ON poi_data_schema.opening_intervals (source, poi_id, start_minute);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS poi_data_schema.popular_time_hours;
+DROP TABLE IF EXISTS poi_data_schema.popular_times;
@@ -0,0 +1,18 @@
+-- 1) Places with a popular times histogram, with the time zone the hours are in
+CREATE TABLE IF NOT EXISTS poi_data_schema.popular_times (
+    place_id TEXT PRIMARY KEY,
+    timezone TEXT NOT NULL,       -- IANA name, e.g. Europe/Stockholm
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- 2) Typical occupancy per weekday and hour in local time
+CREATE TABLE IF NOT EXISTS poi_data_schema.popular_time_hours (
+    place_id TEXT NOT NULL REFERENCES poi_data_schema.popular_times (place_id) ON DELETE CASCADE,
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6), -- 0 is Monday
+    hour SMALLINT NOT NULL CHECK (hour BETWEEN 0 AND 23),
+    occupancy_percent SMALLINT NOT NULL,
+    PRIMARY KEY (place_id, weekday, hour)
+);
+
+CREATE INDEX IF NOT EXISTS idx_popular_time_hours_slot
+  ON poi_data_schema.popular_time_hours (weekday, hour, occupancy_percent);
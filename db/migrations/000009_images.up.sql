@@ -0,0 +1,24 @@
+-- 1) Archived copies of the images scraped places link to
+CREATE TABLE IF NOT EXISTS poi_data_schema.images (
+    id BIGSERIAL PRIMARY KEY,
+    place_id TEXT NOT NULL,
+    source_url TEXT NOT NULL,     -- Scraped CDN URL
+    position INT NOT NULL,        -- 0 is the main image of the place
+    status TEXT NOT NULL CHECK (status IN ('stored', 'failed')),
+    error TEXT,
+    blob_key TEXT,                -- Key of the original in the blob store
+    thumbnail_key TEXT,
+    content_type TEXT,
+    width INT,
+    height INT,
+    size_bytes BIGINT,
+    dhash BIGINT,                 -- Perceptual difference hash
+    fetched_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (place_id, source_url)
+);
+
+CREATE INDEX IF NOT EXISTS idx_images_place
+  ON poi_data_schema.images (place_id, position);
+
+CREATE INDEX IF NOT EXISTS idx_images_dhash
+  ON poi_data_schema.images (dhash);
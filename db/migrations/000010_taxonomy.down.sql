@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS poi_data_schema.poi_categories;
+DROP TABLE IF EXISTS poi_data_schema.category_mappings;
+DROP TABLE IF EXISTS poi_data_schema.taxonomy_nodes;
+DROP TABLE IF EXISTS poi_data_schema.taxonomy_versions;
@@ -0,0 +1,44 @@
+CREATE EXTENSION IF NOT EXISTS ltree;
+
+-- 1) Versions of the category taxonomy. A version is never changed once
+--    stored; changes to the hierarchy or mappings make a new version.
+CREATE TABLE IF NOT EXISTS poi_data_schema.taxonomy_versions (
+    version INT PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- 2) Nodes of the hierarchy, e.g. food.restaurant.pizza.
+CREATE TABLE IF NOT EXISTS poi_data_schema.taxonomy_nodes (
+    version INT NOT NULL REFERENCES poi_data_schema.taxonomy_versions (version) ON DELETE CASCADE,
+    path LTREE NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (version, path)
+);
+
+-- 3) Source categories, per language, and the node they map to.
+CREATE TABLE IF NOT EXISTS poi_data_schema.category_mappings (
+    version INT NOT NULL,
+    source TEXT NOT NULL,         -- google_maps or tripadvisor
+    language TEXT NOT NULL,
+    source_category TEXT NOT NULL,
+    node_path LTREE NOT NULL,
+    PRIMARY KEY (version, source, language, source_category),
+    FOREIGN KEY (version, node_path)
+      REFERENCES poi_data_schema.taxonomy_nodes (version, path)
+      ON DELETE CASCADE
+);
+
+-- 4) Canonical categories assigned to each POI at ingest.
+CREATE TABLE IF NOT EXISTS poi_data_schema.poi_categories (
+    source TEXT NOT NULL,
+    poi_id TEXT NOT NULL,
+    version INT NOT NULL,
+    node_path LTREE NOT NULL,
+    PRIMARY KEY (source, poi_id, version, node_path),
+    FOREIGN KEY (version, node_path)
+      REFERENCES poi_data_schema.taxonomy_nodes (version, path)
+      ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_poi_categories_node
+  ON poi_data_schema.poi_categories USING GIST (node_path);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS google_maps_categories_sync ON poi_data_schema.google_maps;
+DROP FUNCTION IF EXISTS poi_data_schema.sync_google_maps_categories();
+DROP TABLE IF EXISTS poi_data_schema.google_maps_categories;
@@ -0,0 +1,49 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- 1) One row per category of a place, lowercased with white space collapsed,
+--    so category filters can be served by an index instead of unnest().
+CREATE TABLE IF NOT EXISTS poi_data_schema.google_maps_categories (
+    poi_id INT NOT NULL REFERENCES poi_data_schema.google_maps (id) ON DELETE CASCADE,
+    category TEXT NOT NULL,       -- As scraped
+    category_norm TEXT NOT NULL,  -- Matched against
+    PRIMARY KEY (poi_id, category_norm)
+);
+
+-- Exact and prefix matches
+CREATE INDEX IF NOT EXISTS idx_google_maps_categories_norm
+  ON poi_data_schema.google_maps_categories (category_norm text_pattern_ops);
+
+-- Substring (LIKE '%pizza%') and fuzzy (word similarity) matches
+CREATE INDEX IF NOT EXISTS idx_google_maps_categories_trgm
+  ON poi_data_schema.google_maps_categories USING GIN (category_norm gin_trgm_ops);
+
+-- 2) Keep the rows in step with google_maps.categories on every write path.
+CREATE OR REPLACE FUNCTION poi_data_schema.sync_google_maps_categories()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.categories IS NOT DISTINCT FROM OLD.categories THEN
+        RETURN NEW;
+    END IF;
+
+    DELETE FROM poi_data_schema.google_maps_categories WHERE poi_id = NEW.id;
+    INSERT INTO poi_data_schema.google_maps_categories (poi_id, category, category_norm)
+    SELECT NEW.id, min(cat), lower(regexp_replace(btrim(cat), '\s+', ' ', 'g'))
+    FROM unnest(NEW.categories) AS cat
+    WHERE btrim(cat) <> ''
+    GROUP BY 3;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER google_maps_categories_sync
+AFTER INSERT OR UPDATE OF categories ON poi_data_schema.google_maps
+FOR EACH ROW EXECUTE FUNCTION poi_data_schema.sync_google_maps_categories();
+
+-- 3) Backfill the places stored before this migration.
+INSERT INTO poi_data_schema.google_maps_categories (poi_id, category, category_norm)
+SELECT gm.id, min(cat), lower(regexp_replace(btrim(cat), '\s+', ' ', 'g'))
+FROM poi_data_schema.google_maps gm
+CROSS JOIN LATERAL unnest(gm.categories) AS cat
+WHERE btrim(cat) <> ''
+GROUP BY gm.id, 3
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,24 @@
+-- 1) Attribute keys seen in google_maps.additional_info, per section, e.g.
+--    ("Accessibility", "Wheelchair accessible entrance"). The values stay in
+--    additional_info, where the list queries match them through its GIN index.
+CREATE TABLE IF NOT EXISTS poi_data_schema.attribute_catalog (
+    section TEXT NOT NULL,
+    attribute TEXT NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (section, attribute)
+);
+
+CREATE INDEX IF NOT EXISTS idx_attribute_catalog_attribute
+  ON poi_data_schema.attribute_catalog (attribute);
+
+-- 2) Backfill from the places stored before this migration.
+INSERT INTO poi_data_schema.attribute_catalog (section, attribute)
+SELECT DISTINCT s.key, a.key
+FROM poi_data_schema.google_maps gm
+CROSS JOIN LATERAL jsonb_each(gm.additional_info) AS s
+CROSS JOIN LATERAL jsonb_array_elements(s.value) AS e
+CROSS JOIN LATERAL jsonb_each(e) AS a
+WHERE jsonb_typeof(gm.additional_info) = 'object'
+  AND jsonb_typeof(s.value) = 'array'
+  AND jsonb_typeof(e) = 'object'
+ON CONFLICT DO NOTHING;
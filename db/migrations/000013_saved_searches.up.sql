@@ -0,0 +1,37 @@
+-- 1) Searches that are scraped again on a cron schedule. The request is the
+--    JSON form of a ScraperRequest or SearchRequest, depending on kind.
+CREATE TABLE IF NOT EXISTS poi_data_schema.saved_searches (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    kind TEXT NOT NULL CHECK (kind IN ('scraper', 'extractor')),
+    request JSONB NOT NULL,
+    schedule TEXT NOT NULL,           -- Cron expression, e.g. "0 4 * * 1"
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMPTZ,          -- NULL when the schedule never fires again
+    last_run_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_searches_due
+  ON poi_data_schema.saved_searches (next_run_at)
+  WHERE enabled;
+
+-- 2) Every execution of a saved search, scheduled or started by hand.
+CREATE TABLE IF NOT EXISTS poi_data_schema.saved_search_runs (
+    id BIGSERIAL PRIMARY KEY,
+    saved_search_id BIGINT NOT NULL REFERENCES poi_data_schema.saved_searches (id) ON DELETE CASCADE,
+    trigger TEXT NOT NULL,            -- schedule or manual
+    status TEXT NOT NULL,             -- running, succeeded or failed
+    apify_run_id TEXT,
+    item_count INT NOT NULL DEFAULT 0,
+    inserted_count INT NOT NULL DEFAULT 0,
+    failed_count INT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_search_runs_search
+  ON poi_data_schema.saved_search_runs (saved_search_id, started_at DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS poi_data_schema.saved_search_places;
+DROP TABLE IF EXISTS poi_data_schema.poi_events;
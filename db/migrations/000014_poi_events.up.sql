@@ -0,0 +1,32 @@
+-- 1) Changes detected when places are written: new, closed, reopened,
+--    renamed, rating changes, moves and disappearances from saved searches.
+CREATE TABLE IF NOT EXISTS poi_data_schema.poi_events (
+    id BIGSERIAL PRIMARY KEY,
+    source TEXT NOT NULL,
+    poi_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    details JSONB,                    -- {"from": ..., "to": ...} or search details
+    geom geometry(Point, 4326),       -- Location of the place when the event occurred
+    saved_search_id BIGINT REFERENCES poi_data_schema.saved_searches (id) ON DELETE SET NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_poi_events_type_occurred_at
+  ON poi_data_schema.poi_events (type, occurred_at);
+
+CREATE INDEX IF NOT EXISTS idx_poi_events_poi
+  ON poi_data_schema.poi_events (source, poi_id, occurred_at);
+
+CREATE INDEX IF NOT EXISTS idx_poi_events_geom
+  ON poi_data_schema.poi_events
+  USING GIST (geom);
+
+-- 2) The places each saved search returned, with the number of its
+--    consecutive successful runs that no longer did.
+CREATE TABLE IF NOT EXISTS poi_data_schema.saved_search_places (
+    saved_search_id BIGINT NOT NULL REFERENCES poi_data_schema.saved_searches (id) ON DELETE CASCADE,
+    place_id TEXT NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    missed_runs INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (saved_search_id, place_id)
+);
@@ -0,0 +1,24 @@
+-- 1) Messages about written places, stored in the transaction that wrote
+--    them and published to the configured broker by the outbox relay.
+CREATE TABLE IF NOT EXISTS poi_data_schema.outbox (
+    id BIGSERIAL PRIMARY KEY,
+    -- Transaction that wrote the message. Readers take messages in (txid, id)
+    -- order below the oldest running transaction, so none can be committed
+    -- behind a reader's position later.
+    txid BIGINT NOT NULL DEFAULT pg_current_xact_id()::text::bigint,
+    subject TEXT NOT NULL,            -- inserted or updated
+    key TEXT NOT NULL,                -- Place ID
+    data JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_position
+  ON poi_data_schema.outbox (txid, id);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished
+  ON poi_data_schema.outbox (txid, id)
+  WHERE published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_created_at
+  ON poi_data_schema.outbox (created_at);
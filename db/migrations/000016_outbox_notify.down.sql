@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS outbox_notify ON poi_data_schema.outbox;
+DROP FUNCTION IF EXISTS poi_data_schema.notify_outbox();
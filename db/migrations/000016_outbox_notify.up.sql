@@ -0,0 +1,16 @@
+-- 1) Wakes up the listeners on every replica when outbox messages are
+--    committed. The notification carries no payload: listeners read the
+--    outbox from their own position.
+CREATE OR REPLACE FUNCTION poi_data_schema.notify_outbox()
+RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('poi_outbox', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS outbox_notify ON poi_data_schema.outbox;
+CREATE TRIGGER outbox_notify
+  AFTER INSERT ON poi_data_schema.outbox
+  FOR EACH STATEMENT
+  EXECUTE FUNCTION poi_data_schema.notify_outbox();
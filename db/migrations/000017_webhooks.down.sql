@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS poi_data_schema.webhook_cursor;
+DROP TABLE IF EXISTS poi_data_schema.webhook_deliveries;
+DROP TABLE IF EXISTS poi_data_schema.webhook_subscriptions;
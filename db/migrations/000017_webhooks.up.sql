@@ -0,0 +1,56 @@
+-- 1) Subscribers called back over HTTP about written places.
+CREATE TABLE IF NOT EXISTS poi_data_schema.webhook_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    secret TEXT NOT NULL,
+    -- The secret replaced by the last rotation keeps signing requests
+    -- until previous_secret_expires_at, so subscribers can switch over
+    previous_secret TEXT,
+    previous_secret_expires_at TIMESTAMPTZ,
+    area GEOMETRY(Polygon, 4326),     -- NULL matches every place
+    event_types TEXT[] NOT NULL DEFAULT '{}', -- Empty matches every type
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_area
+  ON poi_data_schema.webhook_subscriptions USING GIST (area);
+
+-- 2) Every outbox message matched by a subscription. Deliveries that failed
+--    max_attempts times are dead: the dead-letter queue, redelivered by hand.
+CREATE TABLE IF NOT EXISTS poi_data_schema.webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES poi_data_schema.webhook_subscriptions (id) ON DELETE CASCADE,
+    outbox_id BIGINT NOT NULL,
+    subject TEXT NOT NULL,            -- inserted or updated
+    place_id TEXT NOT NULL,
+    payload JSONB NOT NULL,           -- Outbox envelope that is posted
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, delivered or dead
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_status_code INT,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at TIMESTAMPTZ,
+    UNIQUE (subscription_id, outbox_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due
+  ON poi_data_schema.webhook_deliveries (next_attempt_at)
+  WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_delivered_at
+  ON poi_data_schema.webhook_deliveries (delivered_at)
+  WHERE status = 'delivered';
+
+-- 3) Position in the outbox up to which messages were matched against the
+--    subscriptions. A single row, locked by the replica that matches them.
+CREATE TABLE IF NOT EXISTS poi_data_schema.webhook_cursor (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    txid BIGINT NOT NULL,
+    outbox_id BIGINT NOT NULL
+);
+
+INSERT INTO poi_data_schema.webhook_cursor (txid, outbox_id)
+VALUES (0, 0)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS poi_data_schema.tripadvisor;
+
+CREATE TABLE IF NOT EXISTS poi_data_schema.tripadvisor (
+    -- define columns here...
+);
@@ -0,0 +1,38 @@
+-- 1) Places scraped from Tripadvisor, replacing the empty placeholder table.
+--    Hotels, restaurants and attractions share the table; the whole item is
+--    kept in details.
+DROP TABLE IF EXISTS poi_data_schema.tripadvisor;
+
+CREATE TABLE poi_data_schema.tripadvisor (
+    location_id TEXT PRIMARY KEY,
+    type TEXT NOT NULL,               -- HOTEL, RESTAURANT or ATTRACTION
+    name TEXT NOT NULL,
+    category TEXT,
+    subcategories TEXT[],
+    description TEXT,
+    rating DOUBLE PRECISION,
+    ranking_position INT,
+    ranking_string TEXT,
+    phone TEXT,
+    email TEXT,
+    website TEXT,
+    web_url TEXT,
+    address TEXT,
+    street TEXT,
+    city TEXT,
+    postal_code TEXT,
+    country TEXT,
+    location_lat DOUBLE PRECISION,
+    location_lng DOUBLE PRECISION,
+    image_url TEXT,
+    photo_count INT,
+    is_closed BOOLEAN NOT NULL DEFAULT FALSE,
+    is_long_closed BOOLEAN NOT NULL DEFAULT FALSE,
+    details JSONB NOT NULL,           -- Source JSON of the item
+    source_run_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tripadvisor_type
+  ON poi_data_schema.tripadvisor (type);
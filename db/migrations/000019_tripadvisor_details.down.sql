@@ -0,0 +1,28 @@
+DROP TABLE IF EXISTS poi_data_schema.tripadvisor_metro_stations;
+DROP TABLE IF EXISTS poi_data_schema.tripadvisor_ancestor_locations;
+DROP TABLE IF EXISTS poi_data_schema.tripadvisor_offers;
+
+DROP INDEX IF EXISTS poi_data_schema.idx_tripadvisor_details_gin;
+DROP INDEX IF EXISTS poi_data_schema.idx_tripadvisor_h3_index;
+DROP INDEX IF EXISTS poi_data_schema.idx_tripadvisor_geom;
+
+ALTER TABLE poi_data_schema.tripadvisor
+  DROP COLUMN IF EXISTS geom,
+  DROP COLUMN IF EXISTS h3_index,
+  DROP COLUMN IF EXISTS price_level,
+  DROP COLUMN IF EXISTS price_range,
+  DROP COLUMN IF EXISTS hotel_class,
+  DROP COLUMN IF EXISTS number_of_rooms,
+  DROP COLUMN IF EXISTS amenities,
+  DROP COLUMN IF EXISTS cuisines,
+  DROP COLUMN IF EXISTS dietary_restrictions,
+  DROP COLUMN IF EXISTS meal_types,
+  DROP COLUMN IF EXISTS features,
+  DROP COLUMN IF EXISTS rating_count_1,
+  DROP COLUMN IF EXISTS rating_count_2,
+  DROP COLUMN IF EXISTS rating_count_3,
+  DROP COLUMN IF EXISTS rating_count_4,
+  DROP COLUMN IF EXISTS rating_count_5,
+  DROP COLUMN IF EXISTS lowest_price,
+  DROP COLUMN IF EXISTS booking_provider,
+  DROP COLUMN IF EXISTS booking_url;
@@ -0,0 +1,89 @@
+-- 1) Location columns, indexed like google_maps.
+ALTER TABLE poi_data_schema.tripadvisor
+  ADD COLUMN IF NOT EXISTS geom geometry(Point, 4326),
+  ADD COLUMN IF NOT EXISTS h3_index TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_tripadvisor_geom
+  ON poi_data_schema.tripadvisor
+  USING GIST (geom);
+
+CREATE INDEX IF NOT EXISTS idx_tripadvisor_h3_index
+  ON poi_data_schema.tripadvisor (h3_index);
+
+-- 2) Fields only some types have, the rating histogram, and the offer group
+--    and booking attractions show.
+ALTER TABLE poi_data_schema.tripadvisor
+  ADD COLUMN IF NOT EXISTS price_level TEXT,             -- Hotels and restaurants
+  ADD COLUMN IF NOT EXISTS price_range TEXT,             -- Hotels and restaurants
+  ADD COLUMN IF NOT EXISTS hotel_class TEXT,             -- Hotels
+  ADD COLUMN IF NOT EXISTS number_of_rooms INT,          -- Hotels
+  ADD COLUMN IF NOT EXISTS amenities TEXT[],             -- Hotels
+  ADD COLUMN IF NOT EXISTS cuisines TEXT[],              -- Restaurants
+  ADD COLUMN IF NOT EXISTS dietary_restrictions TEXT[],  -- Restaurants
+  ADD COLUMN IF NOT EXISTS meal_types TEXT[],            -- Restaurants
+  ADD COLUMN IF NOT EXISTS features TEXT[],              -- Restaurants
+  ADD COLUMN IF NOT EXISTS rating_count_1 INT,
+  ADD COLUMN IF NOT EXISTS rating_count_2 INT,
+  ADD COLUMN IF NOT EXISTS rating_count_3 INT,
+  ADD COLUMN IF NOT EXISTS rating_count_4 INT,
+  ADD COLUMN IF NOT EXISTS rating_count_5 INT,
+  ADD COLUMN IF NOT EXISTS lowest_price TEXT,            -- Attractions
+  ADD COLUMN IF NOT EXISTS booking_provider TEXT,
+  ADD COLUMN IF NOT EXISTS booking_url TEXT;
+
+-- 3) GIN index for faster JSONB queries on details, as on google_maps.additional_info
+CREATE INDEX IF NOT EXISTS idx_tripadvisor_details_gin
+  ON poi_data_schema.tripadvisor
+  USING GIN (details jsonb_path_ops);
+
+-- 4) Offers of the offer group, mostly tours and tickets of attractions.
+--    Rows of a place are replaced whenever it is written.
+CREATE TABLE IF NOT EXISTS poi_data_schema.tripadvisor_offers (
+    location_id TEXT NOT NULL REFERENCES poi_data_schema.tripadvisor (location_id) ON DELETE CASCADE,
+    position INT NOT NULL,            -- Order in the offer list
+    title TEXT,
+    url TEXT,
+    price TEXT,
+    rounded_up_price TEXT,
+    offer_type TEXT,
+    product_code TEXT,
+    partner TEXT,
+    image_url TEXT,
+    description TEXT,
+    primary_category TEXT,
+    PRIMARY KEY (location_id, position)
+);
+
+-- 5) Locations a place lies in, from the nearest (e.g. the city) outwards.
+CREATE TABLE IF NOT EXISTS poi_data_schema.tripadvisor_ancestor_locations (
+    location_id TEXT NOT NULL REFERENCES poi_data_schema.tripadvisor (location_id) ON DELETE CASCADE,
+    position INT NOT NULL,            -- 0 is the nearest ancestor
+    ancestor_id TEXT NOT NULL,
+    name TEXT,
+    abbreviation TEXT,
+    subcategory TEXT,                 -- e.g. City, Region, Country
+    PRIMARY KEY (location_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tripadvisor_ancestor_locations_ancestor
+  ON poi_data_schema.tripadvisor_ancestor_locations (ancestor_id);
+
+-- 6) Metro stations nearest to a place.
+CREATE TABLE IF NOT EXISTS poi_data_schema.tripadvisor_metro_stations (
+    location_id TEXT NOT NULL REFERENCES poi_data_schema.tripadvisor (location_id) ON DELETE CASCADE,
+    position INT NOT NULL,            -- Order by distance
+    name TEXT,
+    local_name TEXT,
+    address TEXT,
+    local_address TEXT,
+    lines TEXT[],
+    location_lat DOUBLE PRECISION,
+    location_lng DOUBLE PRECISION,
+    geom geometry(Point, 4326),
+    distance DOUBLE PRECISION,        -- As reported by Tripadvisor
+    PRIMARY KEY (location_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tripadvisor_metro_stations_geom
+  ON poi_data_schema.tripadvisor_metro_stations
+  USING GIST (geom);
@@ -0,0 +1,18 @@
+-- name: UpsertAttributes :exec
+INSERT INTO poi_data_schema.attribute_catalog (section, attribute)
+SELECT unnest($1::text[]), unnest($2::text[])
+ON CONFLICT (section, attribute) DO UPDATE
+SET last_seen_at = now();
+
+-- name: ListAttributes :many
+SELECT section, attribute
+FROM poi_data_schema.attribute_catalog
+WHERE $1::text = '' OR section = $1::text
+ORDER BY section, attribute;
+
+-- name: ListAttributeSections :many
+-- The sections an attribute given without one appears in
+SELECT section
+FROM poi_data_schema.attribute_catalog
+WHERE attribute = $1
+ORDER BY section;
@@ -0,0 +1,42 @@
+-- name: GetDatasetImport :one
+SELECT *
+FROM poi_data_schema.dataset_imports
+WHERE dataset_id = $1
+  AND content_hash = $2;
+
+-- Claims an import unless another run of it is still in progress.
+-- Runs that have not finished within an hour are assumed to have died.
+-- name: ClaimDatasetImport :one
+INSERT INTO poi_data_schema.dataset_imports (
+    dataset_id,
+    content_hash,
+    parser,
+    status
+) VALUES (
+    $1,
+    $2,
+    $3,
+    'running'
+)
+ON CONFLICT (dataset_id, content_hash) DO UPDATE SET
+    parser = EXCLUDED.parser,
+    status = 'running',
+    item_count = 0,
+    inserted_count = 0,
+    failed_count = 0,
+    error = NULL,
+    started_at = now(),
+    finished_at = NULL
+WHERE dataset_imports.status <> 'running'
+   OR dataset_imports.started_at < now() - INTERVAL '1 hour'
+RETURNING id;
+
+-- name: FinishDatasetImport :exec
+UPDATE poi_data_schema.dataset_imports
+SET status = $2,
+    item_count = $3,
+    inserted_count = $4,
+    failed_count = $5,
+    error = $6,
+    finished_at = now()
+WHERE id = $1;
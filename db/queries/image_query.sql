@@ -0,0 +1,59 @@
+-- name: GetImageBySource :one
+SELECT *
+FROM poi_data_schema.images
+WHERE place_id = $1
+  AND source_url = $2;
+
+-- name: UpsertImage :one
+INSERT INTO poi_data_schema.images (
+    place_id,
+    source_url,
+    position,
+    status,
+    error,
+    blob_key,
+    thumbnail_key,
+    content_type,
+    width,
+    height,
+    size_bytes,
+    dhash
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12
+)
+ON CONFLICT (place_id, source_url) DO UPDATE
+SET position      = EXCLUDED.position,
+    status        = EXCLUDED.status,
+    error         = EXCLUDED.error,
+    blob_key      = EXCLUDED.blob_key,
+    thumbnail_key = EXCLUDED.thumbnail_key,
+    content_type  = EXCLUDED.content_type,
+    width         = EXCLUDED.width,
+    height        = EXCLUDED.height,
+    size_bytes    = EXCLUDED.size_bytes,
+    dhash         = EXCLUDED.dhash,
+    fetched_at    = now()
+RETURNING id;
+
+-- name: GetImage :one
+SELECT *
+FROM poi_data_schema.images
+WHERE id = $1;
+
+-- name: ListImagesByPlace :many
+SELECT *
+FROM poi_data_schema.images
+WHERE place_id = $1
+  AND status = 'stored'
+ORDER BY position, id;
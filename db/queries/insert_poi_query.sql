@@ -72,7 +72,16 @@ INSERT INTO poi_data_schema.google_maps (
     reviews,
     user_place_note,
     restaurant_data,
-    owner_updates
+    owner_updates,
+    phone_e164,
+    website_url,
+    website_host,
+    address_street,
+    address_house_number,
+    address_postal_code,
+    address_city,
+    address_country_code,
+    address_key
 ) VALUES (
     $1,
     $2,
@@ -146,7 +155,16 @@ INSERT INTO poi_data_schema.google_maps (
     $69,
     $70,
     $71,
-    $72
+    $72,
+    $73,
+    $74,
+    $75,
+    $76,
+    $77,
+    $78,
+    $79,
+    $80,
+    $81
 )
 ON CONFLICT (place_id) DO NOTHING
 RETURNING id;
\ No newline at end of file
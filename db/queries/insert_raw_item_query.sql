@@ -0,0 +1,23 @@
+-- name: InsertRawItem :exec
+INSERT INTO poi_data_schema.raw_items (
+    item_id,
+    parser,
+    source_run_id,
+    content_hash,
+    item,
+    location_lat,
+    location_lng,
+    geom,
+    scraped_at
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6, -- location_lat
+    $7, -- location_lng
+    ST_SetSRID(ST_MakePoint($7, $6), 4326),
+    $8
+)
+ON CONFLICT (item_id, source_run_id) DO NOTHING;
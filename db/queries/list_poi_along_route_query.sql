@@ -1,4 +1,5 @@
 -- name: ListPOIAlongRouteH3 :many
+-- $6 is the additional_info fragment every place contains, or NULL; $7 the fragments none contains
 WITH route_line AS (
   SELECT ST_MakeLine(
     ST_SetSRID(ST_Point($1::float8, $2::float8), 4326),
@@ -16,4 +17,7 @@ cells AS (
 )
 SELECT gm.*
 FROM poi_data_schema.google_maps gm
-JOIN cells c ON gm.h3_index = c.h3_cell;
\ No newline at end of file
+JOIN cells c ON gm.h3_index = c.h3_cell
+WHERE TRUE
+  AND ($6::jsonb IS NULL OR gm.additional_info @> $6::jsonb)
+  AND (gm.additional_info IS NULL OR NOT (gm.additional_info @> ANY($7::jsonb[])));
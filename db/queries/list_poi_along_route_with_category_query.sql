@@ -1,4 +1,5 @@
 -- name: ListPOIAlongRouteWithCategoryH3 :many
+-- $7 is the additional_info fragment every place contains, or NULL; $8 the fragments none contains
 WITH route_line AS (
   SELECT ST_MakeLine(
     ST_SetSRID(ST_Point($1::float8, $2::float8), 4326),
@@ -18,8 +19,41 @@ cells AS (
 SELECT gm.*
 FROM poi_data_schema.google_maps gm
 JOIN cells c ON gm.h3_index = c.h3_cell
-WHERE EXISTS (
-  SELECT 1
-  FROM unnest(gm.categories) cat
-  WHERE cat ILIKE '%' || $6 || '%'
-);
\ No newline at end of file
+WHERE gm.id IN (
+  -- Served by the trigram index; the pattern is built from the match mode
+  SELECT gc.poi_id
+  FROM poi_data_schema.google_maps_categories gc
+  WHERE gc.category_norm LIKE $6::text
+)
+  AND ($7::jsonb IS NULL OR gm.additional_info @> $7::jsonb)
+  AND (gm.additional_info IS NULL OR NOT (gm.additional_info @> ANY($8::jsonb[])));
+
+-- name: ListPOIAlongRouteWithCategoryFuzzyH3 :many
+-- $7 is the additional_info fragment every place contains, or NULL; $8 the fragments none contains
+WITH route_line AS (
+  SELECT ST_MakeLine(
+    ST_SetSRID(ST_Point($1::float8, $2::float8), 4326),
+    ST_SetSRID(ST_Point($3::float8, $4::float8), 4326)
+  ) AS geom
+),
+corridor_poly AS (
+  -- Buffer in meters around the route, then convert back to geometry
+  SELECT ST_Buffer(geom::geography, $5::float8)::geometry AS poly
+  FROM route_line
+),
+cells AS (
+  -- Fill the buffered corridor polygon with H3 cells
+  SELECT unnest(h3_polyfill(poly, 9)) AS h3_cell
+  FROM corridor_poly
+)
+SELECT gm.*
+FROM poi_data_schema.google_maps gm
+JOIN cells c ON gm.h3_index = c.h3_cell
+WHERE gm.id IN (
+  -- Categories containing a word similar to $6, served by the trigram index
+  SELECT gc.poi_id
+  FROM poi_data_schema.google_maps_categories gc
+  WHERE $6::text <% gc.category_norm
+)
+  AND ($7::jsonb IS NULL OR gm.additional_info @> $7::jsonb)
+  AND (gm.additional_info IS NULL OR NOT (gm.additional_info @> ANY($8::jsonb[])));
\ No newline at end of file
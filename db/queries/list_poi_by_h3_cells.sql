@@ -1,4 +1,5 @@
 -- name: ListPOIsByH3Cells :many
+-- $3 is the additional_info fragment every place contains, or NULL; $4 the fragments none contains
 WITH parent_cells AS (
     SELECT unnest($2::text[])::h3index AS parent_cell
 )
@@ -8,4 +9,7 @@ JOIN LATERAL (
     SELECT h3_cell_to_children(pc.parent_cell, $1::int) AS child_index
     FROM parent_cells pc
 ) children
-ON g.h3_index = children.child_index;
\ No newline at end of file
+ON g.h3_index = children.child_index
+WHERE TRUE
+  AND ($3::jsonb IS NULL OR g.additional_info @> $3::jsonb)
+  AND (g.additional_info IS NULL OR NOT (g.additional_info @> ANY($4::jsonb[])));
@@ -0,0 +1,11 @@
+-- name: ListPOIByMatchKey :many
+-- Any of the normalized keys may be empty, the others still match
+-- $5 is the additional_info fragment every place contains, or NULL; $6 the fragments none contains
+SELECT *
+FROM poi_data_schema.google_maps
+WHERE (($1::text <> '' AND phone_e164 = $1::text)
+   OR ($2::text <> '' AND website_host = $2::text)
+   OR ($3::text <> '' AND address_key = $3::text))
+  AND ($5::jsonb IS NULL OR additional_info @> $5::jsonb)
+  AND (additional_info IS NULL OR NOT (additional_info @> ANY($6::jsonb[])))
+LIMIT $4::int;
@@ -1,7 +1,10 @@
 -- name: ListPOIInBox :many
+-- $5 is the additional_info fragment every place contains, or NULL; $6 the fragments none contains
 SELECT *
 FROM poi_data_schema.google_maps
 WHERE ST_Contains(
     ST_MakeEnvelope($1::float8, $2::float8, $3::float8, $4::float8, 4326),
     geom
-);
\ No newline at end of file
+)
+  AND ($5::jsonb IS NULL OR additional_info @> $5::jsonb)
+  AND (additional_info IS NULL OR NOT (additional_info @> ANY($6::jsonb[])));
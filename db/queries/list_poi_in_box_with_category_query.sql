@@ -1,4 +1,5 @@
 -- name: ListPOIInBoxWithCategoryH3 :many
+-- $6 is the additional_info fragment every place contains, or NULL; $7 the fragments none contains
 WITH envelope_poly AS (
   SELECT ST_MakeEnvelope(
     $1::float8,  -- minX (western longitude)
@@ -15,8 +16,38 @@ cells AS (
 SELECT gm.*
 FROM poi_data_schema.google_maps gm
 JOIN cells c ON gm.h3_index = c.h3_cell
-WHERE EXISTS (
-  SELECT 1
-  FROM unnest(gm.categories) cat
-  WHERE cat ILIKE '%' || $5 || '%'
-);
\ No newline at end of file
+WHERE gm.id IN (
+  -- Served by the trigram index; the pattern is built from the match mode
+  SELECT gc.poi_id
+  FROM poi_data_schema.google_maps_categories gc
+  WHERE gc.category_norm LIKE $5::text
+)
+  AND ($6::jsonb IS NULL OR gm.additional_info @> $6::jsonb)
+  AND (gm.additional_info IS NULL OR NOT (gm.additional_info @> ANY($7::jsonb[])));
+
+-- name: ListPOIInBoxWithCategoryFuzzyH3 :many
+-- $6 is the additional_info fragment every place contains, or NULL; $7 the fragments none contains
+WITH envelope_poly AS (
+  SELECT ST_MakeEnvelope(
+    $1::float8,  -- minX (western longitude)
+    $2::float8,  -- minY (southern latitude)
+    $3::float8,  -- maxX (eastern longitude)
+    $4::float8,  -- maxY (northern latitude)
+    4326
+  ) AS poly
+),
+cells AS (
+  SELECT unnest(h3_polyfill(poly, 9)) AS h3_cell  -- pick H3 res=9 for example
+  FROM envelope_poly
+)
+SELECT gm.*
+FROM poi_data_schema.google_maps gm
+JOIN cells c ON gm.h3_index = c.h3_cell
+WHERE gm.id IN (
+  -- Categories containing a word similar to $5, served by the trigram index
+  SELECT gc.poi_id
+  FROM poi_data_schema.google_maps_categories gc
+  WHERE $5::text <% gc.category_norm
+)
+  AND ($6::jsonb IS NULL OR gm.additional_info @> $6::jsonb)
+  AND (gm.additional_info IS NULL OR NOT (gm.additional_info @> ANY($7::jsonb[])));
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- name: ListRawItems :many
+-- Pages through the stored items by id. Empty strings and a false box flag disable a filter.
+-- Tripadvisor items are left out; they are stored in their own tables, not reprocessed.
+SELECT
+    ri.id,
+    ri.item_id,
+    ri.parser,
+    ri.source_run_id,
+    ri.item,
+    ri.scraped_at
+FROM poi_data_schema.raw_items ri
+WHERE ri.id > $1::bigint
+  AND ($2::text = '' OR ri.source_run_id = $2::text)
+  AND ($3::text = '' OR ri.parser = $3::text)
+  AND ri.parser <> 'tripadvisor'
+  AND (NOT $4::bool OR ST_Contains(
+    ST_MakeEnvelope($5::float8, $6::float8, $7::float8, $8::float8, 4326),
+    ri.geom
+  ))
+  AND ri.scraped_at >= $9::timestamptz
+  AND ri.scraped_at < $10::timestamptz
+ORDER BY ri.id
+LIMIT $11::int;
@@ -0,0 +1,41 @@
+-- name: UpsertOpeningHours :exec
+INSERT INTO poi_data_schema.opening_hours (
+    source,
+    poi_id,
+    timezone
+) VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (source, poi_id) DO UPDATE
+SET timezone   = EXCLUDED.timezone,
+    updated_at = now();
+
+-- name: DeleteOpeningIntervals :exec
+DELETE FROM poi_data_schema.opening_intervals
+WHERE source = $1
+  AND poi_id = $2;
+
+-- name: InsertOpeningIntervals :exec
+INSERT INTO poi_data_schema.opening_intervals (source, poi_id, start_minute, end_minute)
+SELECT $1::text, $2::text, unnest($3::int[]), unnest($4::int[]);
+
+-- name: ListOpenPOIIDs :many
+-- The POIs among $2 that are open at $3, in the local time of each POI
+SELECT DISTINCT oh.poi_id
+FROM poi_data_schema.opening_hours oh
+CROSS JOIN LATERAL (
+    SELECT $3::timestamptz AT TIME ZONE oh.timezone AS t
+) AS lt
+JOIN poi_data_schema.opening_intervals oi
+  ON oi.source = oh.source
+ AND oi.poi_id = oh.poi_id
+WHERE oh.source = $1::text
+  AND oh.poi_id = ANY($2::text[])
+  AND oi.start_minute <= (extract(isodow FROM lt.t)::int - 1) * 1440
+                         + extract(hour FROM lt.t)::int * 60
+                         + extract(minute FROM lt.t)::int
+  AND oi.end_minute > (extract(isodow FROM lt.t)::int - 1) * 1440
+                      + extract(hour FROM lt.t)::int * 60
+                      + extract(minute FROM lt.t)::int;
@@ -0,0 +1,45 @@
+-- name: InsertOutboxMessage :exec
+INSERT INTO poi_data_schema.outbox (subject, key, data)
+VALUES ($1::text, $2::text, $3::jsonb);
+
+-- name: ClaimOutboxMessages :many
+-- Locks the oldest unpublished messages that no running transaction can be
+-- committed before, skipping the ones another relay holds.
+SELECT o.id, o.txid, o.subject, o.key, o.data, o.created_at
+FROM poi_data_schema.outbox o
+WHERE o.published_at IS NULL
+  AND o.txid < pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+ORDER BY o.txid, o.id
+LIMIT $1::int
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkOutboxPublished :exec
+UPDATE poi_data_schema.outbox
+SET published_at = now()
+WHERE id = ANY($1::bigint[]);
+
+-- name: ListOutboxMessages :many
+-- The messages after position ($1, $2) in (txid, id) order, up to the oldest
+-- running transaction.
+SELECT o.id, o.txid, o.subject, o.key, o.data, o.created_at
+FROM poi_data_schema.outbox o
+WHERE (o.txid, o.id) > ($1::bigint, $2::bigint)
+  AND o.txid < pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+ORDER BY o.txid, o.id
+LIMIT $3::int;
+
+-- name: GetOutboxHead :one
+-- The position after every message that is visible now.
+SELECT COALESCE(MAX(o.txid), 0)::bigint AS txid, COALESCE(MAX(o.id), 0)::bigint AS id
+FROM poi_data_schema.outbox o
+WHERE o.txid = (
+    SELECT MAX(h.txid)
+    FROM poi_data_schema.outbox h
+    WHERE h.txid < pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+);
+
+-- name: DeleteOutboxMessages :execrows
+-- Deletes the published messages written before $1.
+DELETE FROM poi_data_schema.outbox
+WHERE created_at < $1::timestamptz
+  AND published_at IS NOT NULL;
@@ -0,0 +1,66 @@
+-- name: GetPOISnapshot :one
+-- The stored state of a place that changes are detected on, locked until the
+-- place is written.
+SELECT
+    gm.title,
+    gm.permanently_closed,
+    gm.temporarily_closed,
+    gm.total_score,
+    gm.location_lat,
+    gm.location_lng
+FROM poi_data_schema.google_maps gm
+WHERE gm.place_id = $1
+FOR UPDATE;
+
+-- name: InsertPOIEvent :exec
+-- Records an event at the stored location of the place.
+INSERT INTO poi_data_schema.poi_events (source, poi_id, type, details, geom, saved_search_id)
+SELECT
+    $1::text,
+    $2::text,
+    $3::text,
+    $4::jsonb,
+    (SELECT gm.geom FROM poi_data_schema.google_maps gm WHERE gm.place_id = $2::text),
+    NULLIF($5::bigint, 0);
+
+-- name: ListPOIEvents :many
+-- Pages through the events by id. An empty type list, an empty place id and a
+-- false box flag disable a filter.
+SELECT
+    e.id,
+    e.source,
+    e.poi_id,
+    e.type,
+    e.details,
+    (e.geom IS NOT NULL)::bool AS has_location,
+    COALESCE(ST_Y(e.geom), 0)::float8 AS lat,
+    COALESCE(ST_X(e.geom), 0)::float8 AS lng,
+    e.saved_search_id,
+    e.occurred_at
+FROM poi_data_schema.poi_events e
+WHERE e.id > $1::bigint
+  AND (cardinality($2::text[]) = 0 OR e.type = ANY($2::text[]))
+  AND ($3::text = '' OR e.poi_id = $3::text)
+  AND (NOT $4::bool OR ST_Contains(
+    ST_MakeEnvelope($5::float8, $6::float8, $7::float8, $8::float8, 4326),
+    e.geom
+  ))
+  AND e.occurred_at >= $9::timestamptz
+  AND e.occurred_at < $10::timestamptz
+ORDER BY e.id
+LIMIT $11::int;
+
+-- name: MarkSavedSearchPlacesSeen :exec
+INSERT INTO poi_data_schema.saved_search_places (saved_search_id, place_id)
+SELECT $1::bigint, unnest($2::text[])
+ON CONFLICT (saved_search_id, place_id) DO UPDATE SET
+    last_seen_at = now(),
+    missed_runs = 0;
+
+-- name: MissSavedSearchPlaces :many
+-- Counts a missed run for the places of a search that a run did not return.
+UPDATE poi_data_schema.saved_search_places
+SET missed_runs = missed_runs + 1
+WHERE saved_search_id = $1::bigint
+  AND NOT (place_id = ANY($2::text[]))
+RETURNING place_id, missed_runs;
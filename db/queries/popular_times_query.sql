@@ -0,0 +1,63 @@
+-- name: UpsertPopularTimes :exec
+INSERT INTO poi_data_schema.popular_times (
+    place_id,
+    timezone
+) VALUES (
+    $1,
+    $2
+)
+ON CONFLICT (place_id) DO UPDATE
+SET timezone   = EXCLUDED.timezone,
+    updated_at = now();
+
+-- name: DeletePopularTimeHours :exec
+DELETE FROM poi_data_schema.popular_time_hours
+WHERE place_id = $1;
+
+-- name: InsertPopularTimeHours :exec
+INSERT INTO poi_data_schema.popular_time_hours (place_id, weekday, hour, occupancy_percent)
+SELECT $1::text, unnest($2::smallint[]), unnest($3::smallint[]), unnest($4::smallint[]);
+
+-- name: ListQuietestHours :many
+-- Hours the place is open, least busy first. Without a stored schedule,
+-- hours at 0% are taken to be closed.
+SELECT pth.weekday, pth.hour, pth.occupancy_percent
+FROM poi_data_schema.popular_time_hours pth
+LEFT JOIN poi_data_schema.opening_hours oh
+  ON oh.source = 'google_maps'
+ AND oh.poi_id = pth.place_id
+WHERE pth.place_id = $1::text
+  AND ($2::int < 0 OR pth.weekday = $2::int)
+  AND CASE
+        WHEN oh.poi_id IS NULL THEN pth.occupancy_percent > 0
+        ELSE EXISTS (
+          SELECT 1
+          FROM poi_data_schema.opening_intervals oi
+          WHERE oi.source = oh.source
+            AND oi.poi_id = oh.poi_id
+            AND oi.start_minute <= pth.weekday * 1440 + pth.hour * 60
+            AND oi.end_minute > pth.weekday * 1440 + pth.hour * 60
+        )
+      END
+ORDER BY pth.occupancy_percent, pth.weekday, pth.hour
+LIMIT $3::int;
+
+-- name: ListPOIInBoxUnderOccupancy :many
+-- POIs in the box whose typical occupancy at $5, in their local time, is at most $6 percent
+SELECT sqlc.embed(gm), pth.occupancy_percent
+FROM poi_data_schema.google_maps gm
+JOIN poi_data_schema.popular_times pt
+  ON pt.place_id = gm.place_id
+CROSS JOIN LATERAL (
+    SELECT $5::timestamptz AT TIME ZONE pt.timezone AS t
+) AS lt
+JOIN poi_data_schema.popular_time_hours pth
+  ON pth.place_id = pt.place_id
+ AND pth.weekday = extract(isodow FROM lt.t)::int - 1
+ AND pth.hour = extract(hour FROM lt.t)::int
+WHERE ST_Contains(
+    ST_MakeEnvelope($1::float8, $2::float8, $3::float8, $4::float8, 4326),
+    gm.geom
+)
+  AND pth.occupancy_percent <= $6::int
+ORDER BY pth.occupancy_percent, gm.id;
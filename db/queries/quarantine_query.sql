@@ -0,0 +1,43 @@
+-- name: InsertQuarantinedPOI :exec
+INSERT INTO poi_data_schema.quarantine (
+    item_id,
+    parser,
+    source_run_id,
+    reason,
+    details,
+    location_lat,
+    location_lng,
+    item
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+);
+
+-- name: ListQuarantinedPOIs :many
+SELECT *
+FROM poi_data_schema.quarantine
+WHERE id > $1::bigint
+  AND ($2::text = '' OR status = $2::text)
+  AND ($3::text = '' OR reason = $3::text)
+ORDER BY id
+LIMIT $4::int;
+
+-- name: GetQuarantinedPOI :one
+SELECT *
+FROM poi_data_schema.quarantine
+WHERE id = $1;
+
+-- name: ClaimQuarantinedPOI :one
+-- Resolves a pending POI; no row is returned when it is resolved already
+UPDATE poi_data_schema.quarantine
+SET status = $2,
+    reviewed_at = now()
+WHERE id = $1
+  AND status = 'pending'
+RETURNING *;
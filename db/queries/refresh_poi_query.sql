@@ -0,0 +1,16 @@
+-- name: ListPOIRefreshTargets :many
+-- The places to refresh with their Google Maps URL, least recently scraped first.
+-- An empty id list, a false box flag and scrapedForever disable a filter.
+SELECT
+    gm.place_id::text AS place_id,
+    gm.url
+FROM poi_data_schema.google_maps gm
+WHERE gm.place_id IS NOT NULL
+  AND (cardinality($1::text[]) = 0 OR gm.place_id = ANY($1::text[]))
+  AND (NOT $2::bool OR ST_Contains(
+    ST_MakeEnvelope($3::float8, $4::float8, $5::float8, $6::float8, 4326),
+    gm.geom
+  ))
+  AND (gm.scraped_at IS NULL OR gm.scraped_at < $7::timestamptz)
+ORDER BY gm.scraped_at NULLS FIRST, gm.id
+LIMIT $8::int;
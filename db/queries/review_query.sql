@@ -0,0 +1,133 @@
+-- name: UpsertReview :exec
+INSERT INTO poi_data_schema.reviews (
+    review_id,
+    place_id,
+    rating,
+    text,
+    text_translated,
+    language,
+    published_at,
+    owner_response_text,
+    owner_response_at,
+    likes_count,
+    review_url,
+    review_origin,
+    image_urls,
+    reviewer_id,
+    reviewer_name,
+    reviewer_url,
+    reviewer_photo_url,
+    reviewer_review_count,
+    reviewer_is_local_guide
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14,
+    $15,
+    $16,
+    $17,
+    $18,
+    $19
+)
+ON CONFLICT (review_id) DO UPDATE
+SET place_id                = EXCLUDED.place_id,
+    rating                  = EXCLUDED.rating,
+    text                    = EXCLUDED.text,
+    text_translated         = EXCLUDED.text_translated,
+    language                = EXCLUDED.language,
+    published_at            = EXCLUDED.published_at,
+    owner_response_text     = EXCLUDED.owner_response_text,
+    owner_response_at       = EXCLUDED.owner_response_at,
+    likes_count             = EXCLUDED.likes_count,
+    review_url              = EXCLUDED.review_url,
+    review_origin           = EXCLUDED.review_origin,
+    image_urls              = EXCLUDED.image_urls,
+    reviewer_id             = EXCLUDED.reviewer_id,
+    reviewer_name           = EXCLUDED.reviewer_name,
+    reviewer_url            = EXCLUDED.reviewer_url,
+    reviewer_photo_url      = EXCLUDED.reviewer_photo_url,
+    reviewer_review_count   = EXCLUDED.reviewer_review_count,
+    reviewer_is_local_guide = EXCLUDED.reviewer_is_local_guide,
+    updated_at              = now();
+
+-- name: ListReviews :many
+-- Newest first. Pages continue after the (published_at, id) of the last review of the previous page.
+SELECT *
+FROM poi_data_schema.reviews
+WHERE ($1::text = '' OR place_id = $1::text)
+  AND published_at >= $2::timestamptz
+  AND published_at < $3::timestamptz
+  AND ($4::int = 0 OR rating >= $4::int)
+  AND ($5::int = 0 OR rating <= $5::int)
+  AND ($6::text = ''
+       OR to_tsvector('simple', coalesce(text, '') || ' ' || coalesce(text_translated, ''))
+          @@ plainto_tsquery('simple', $6::text))
+  AND (published_at, id) < ($7::timestamptz, $8::bigint)
+ORDER BY published_at DESC, id DESC
+LIMIT $9::int;
+
+-- name: InsertNewReview :execrows
+-- Used by review refreshes, which only merge reviews that are not stored yet
+INSERT INTO poi_data_schema.reviews (
+    review_id,
+    place_id,
+    rating,
+    text,
+    text_translated,
+    language,
+    published_at,
+    owner_response_text,
+    owner_response_at,
+    likes_count,
+    review_url,
+    review_origin,
+    image_urls,
+    reviewer_id,
+    reviewer_name,
+    reviewer_url,
+    reviewer_photo_url,
+    reviewer_review_count,
+    reviewer_is_local_guide
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14,
+    $15,
+    $16,
+    $17,
+    $18,
+    $19
+)
+ON CONFLICT (review_id) DO NOTHING;
+
+-- name: ListReviewRefreshTargets :many
+-- The Google Maps URL of each place and the publish date of its newest stored review, if any
+SELECT gm.place_id,
+       gm.url,
+       max(r.published_at) AS newest_review_at
+FROM poi_data_schema.google_maps gm
+LEFT JOIN poi_data_schema.reviews r ON r.place_id = gm.place_id
+WHERE gm.place_id = ANY($1::text[])
+GROUP BY gm.place_id, gm.url;
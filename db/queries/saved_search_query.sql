@@ -0,0 +1,96 @@
+-- name: CreateSavedSearch :one
+INSERT INTO poi_data_schema.saved_searches (
+    name,
+    kind,
+    request,
+    schedule,
+    timezone,
+    enabled,
+    next_run_at
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING *;
+
+-- name: GetSavedSearch :one
+SELECT *
+FROM poi_data_schema.saved_searches
+WHERE id = $1;
+
+-- name: ListSavedSearches :many
+SELECT *
+FROM poi_data_schema.saved_searches
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
+
+-- name: UpdateSavedSearch :one
+UPDATE poi_data_schema.saved_searches
+SET name = $2,
+    kind = $3,
+    request = $4,
+    schedule = $5,
+    timezone = $6,
+    enabled = $7,
+    next_run_at = $8,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteSavedSearch :execrows
+DELETE FROM poi_data_schema.saved_searches
+WHERE id = $1;
+
+-- name: ClaimDueSavedSearches :many
+-- Locks the enabled searches that are due, skipping those another scheduler
+-- has locked. The caller moves next_run_at forward before committing.
+SELECT *
+FROM poi_data_schema.saved_searches
+WHERE enabled
+  AND next_run_at <= now()
+ORDER BY next_run_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: SetSavedSearchNextRun :exec
+UPDATE poi_data_schema.saved_searches
+SET next_run_at = $2,
+    last_run_at = now()
+WHERE id = $1;
+
+-- name: StartSavedSearchRun :one
+INSERT INTO poi_data_schema.saved_search_runs (
+    saved_search_id,
+    trigger,
+    status
+) VALUES (
+    $1,
+    $2,
+    'running'
+)
+RETURNING id;
+
+-- name: FinishSavedSearchRun :exec
+UPDATE poi_data_schema.saved_search_runs
+SET status = $2,
+    apify_run_id = $3,
+    item_count = $4,
+    inserted_count = $5,
+    failed_count = $6,
+    error = $7,
+    finished_at = now()
+WHERE id = $1;
+
+-- name: ListSavedSearchRuns :many
+SELECT *
+FROM poi_data_schema.saved_search_runs
+WHERE saved_search_id = $1
+  AND ($2::bigint = 0 OR id < $2::bigint)
+ORDER BY id DESC
+LIMIT $3;
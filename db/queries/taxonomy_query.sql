@@ -0,0 +1,61 @@
+-- name: InsertTaxonomyVersion :execrows
+INSERT INTO poi_data_schema.taxonomy_versions (version)
+VALUES ($1)
+ON CONFLICT (version) DO NOTHING;
+
+-- name: InsertTaxonomyNodes :exec
+INSERT INTO poi_data_schema.taxonomy_nodes (version, path, name)
+SELECT $1::int, unnest($2::text[])::ltree, unnest($3::text[])
+ON CONFLICT (version, path) DO NOTHING;
+
+-- name: InsertCategoryMappings :exec
+INSERT INTO poi_data_schema.category_mappings (version, source, language, source_category, node_path)
+SELECT $1::int, unnest($2::text[]), unnest($3::text[]), unnest($4::text[]), unnest($5::text[])::ltree
+ON CONFLICT (version, source, language, source_category) DO NOTHING;
+
+-- name: DeletePOICategories :exec
+DELETE FROM poi_data_schema.poi_categories
+WHERE source = $1
+  AND poi_id = $2
+  AND version = $3;
+
+-- name: InsertPOICategories :exec
+INSERT INTO poi_data_schema.poi_categories (source, poi_id, version, node_path)
+SELECT $1::text, $2::text, $3::int, unnest($4::text[])::ltree
+ON CONFLICT DO NOTHING;
+
+-- name: ListPOIIDsInTaxonomyNode :many
+-- The POIs among $2 categorized in node $4 of version $3 or one of its descendants
+SELECT DISTINCT poi_id
+FROM poi_data_schema.poi_categories
+WHERE source = $1::text
+  AND poi_id = ANY($2::text[])
+  AND version = $3::int
+  AND node_path <@ $4::text::ltree;
+
+-- name: InsertPOICategoryRows :exec
+-- One row per ($3, $4) pair, for categorizing many POIs at once
+INSERT INTO poi_data_schema.poi_categories (source, poi_id, version, node_path)
+SELECT $1::text, unnest($3::text[]), $2::int, unnest($4::text[])::ltree
+ON CONFLICT DO NOTHING;
+
+-- name: ListGoogleMapsCategories :many
+-- Pages through the stored places by id with their scraped categories
+SELECT
+    id,
+    place_id::text AS place_id,
+    COALESCE(category_name, '')::text AS category_name,
+    COALESCE(categories, '{}')::text[] AS categories
+FROM poi_data_schema.google_maps
+WHERE id > $1::bigint
+  AND place_id IS NOT NULL
+ORDER BY id
+LIMIT $2::int;
+
+-- name: ListTripadvisorDetails :many
+-- Pages through the stored places by location id with their source items
+SELECT location_id, details
+FROM poi_data_schema.tripadvisor
+WHERE location_id > $1::text
+ORDER BY location_id
+LIMIT $2::int;
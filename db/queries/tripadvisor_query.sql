@@ -0,0 +1,261 @@
+-- name: UpsertTripadvisorPOI :one
+-- Inserts or replaces a Tripadvisor place and reports whether it was new.
+INSERT INTO poi_data_schema.tripadvisor (
+    location_id,
+    type,
+    name,
+    category,
+    subcategories,
+    description,
+    rating,
+    ranking_position,
+    ranking_string,
+    price_level,
+    price_range,
+    phone,
+    email,
+    website,
+    web_url,
+    address,
+    street,
+    city,
+    postal_code,
+    country,
+    location_lat,
+    location_lng,
+    geom,
+    h3_index,
+    image_url,
+    photo_count,
+    is_closed,
+    is_long_closed,
+    hotel_class,
+    number_of_rooms,
+    amenities,
+    cuisines,
+    dietary_restrictions,
+    meal_types,
+    features,
+    details,
+    source_run_id,
+    rating_count_1,
+    rating_count_2,
+    rating_count_3,
+    rating_count_4,
+    rating_count_5,
+    lowest_price,
+    booking_provider,
+    booking_url
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14,
+    $15,
+    $16,
+    $17,
+    $18,
+    $19,
+    $20,
+    $21,
+    $22,
+    ST_SetSRID(ST_MakePoint($22, $21), 4326),
+    $23,
+    $24,
+    $25,
+    $26,
+    $27,
+    $28,
+    $29,
+    $30,
+    $31,
+    $32,
+    $33,
+    $34,
+    $35,
+    $36,
+    $37,
+    $38,
+    $39,
+    $40,
+    $41,
+    $42,
+    $43,
+    $44
+)
+ON CONFLICT (location_id) DO UPDATE SET
+    type = EXCLUDED.type,
+    name = EXCLUDED.name,
+    category = EXCLUDED.category,
+    subcategories = EXCLUDED.subcategories,
+    description = EXCLUDED.description,
+    rating = EXCLUDED.rating,
+    ranking_position = EXCLUDED.ranking_position,
+    ranking_string = EXCLUDED.ranking_string,
+    price_level = EXCLUDED.price_level,
+    price_range = EXCLUDED.price_range,
+    phone = EXCLUDED.phone,
+    email = EXCLUDED.email,
+    website = EXCLUDED.website,
+    web_url = EXCLUDED.web_url,
+    address = EXCLUDED.address,
+    street = EXCLUDED.street,
+    city = EXCLUDED.city,
+    postal_code = EXCLUDED.postal_code,
+    country = EXCLUDED.country,
+    location_lat = EXCLUDED.location_lat,
+    location_lng = EXCLUDED.location_lng,
+    geom = EXCLUDED.geom,
+    h3_index = EXCLUDED.h3_index,
+    image_url = EXCLUDED.image_url,
+    photo_count = EXCLUDED.photo_count,
+    is_closed = EXCLUDED.is_closed,
+    is_long_closed = EXCLUDED.is_long_closed,
+    hotel_class = EXCLUDED.hotel_class,
+    number_of_rooms = EXCLUDED.number_of_rooms,
+    amenities = EXCLUDED.amenities,
+    cuisines = EXCLUDED.cuisines,
+    dietary_restrictions = EXCLUDED.dietary_restrictions,
+    meal_types = EXCLUDED.meal_types,
+    features = EXCLUDED.features,
+    details = EXCLUDED.details,
+    source_run_id = EXCLUDED.source_run_id,
+    rating_count_1 = EXCLUDED.rating_count_1,
+    rating_count_2 = EXCLUDED.rating_count_2,
+    rating_count_3 = EXCLUDED.rating_count_3,
+    rating_count_4 = EXCLUDED.rating_count_4,
+    rating_count_5 = EXCLUDED.rating_count_5,
+    lowest_price = EXCLUDED.lowest_price,
+    booking_provider = EXCLUDED.booking_provider,
+    booking_url = EXCLUDED.booking_url,
+    updated_at = now()
+RETURNING (xmax = 0)::bool AS inserted;
+
+-- name: DeleteTripadvisorOffers :exec
+DELETE FROM poi_data_schema.tripadvisor_offers
+WHERE location_id = $1;
+
+-- name: InsertTripadvisorOffer :exec
+INSERT INTO poi_data_schema.tripadvisor_offers (
+    location_id,
+    position,
+    title,
+    url,
+    price,
+    rounded_up_price,
+    offer_type,
+    product_code,
+    partner,
+    image_url,
+    description,
+    primary_category
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+);
+
+-- name: DeleteTripadvisorAncestorLocations :exec
+DELETE FROM poi_data_schema.tripadvisor_ancestor_locations
+WHERE location_id = $1;
+
+-- name: InsertTripadvisorAncestorLocation :exec
+INSERT INTO poi_data_schema.tripadvisor_ancestor_locations (
+    location_id,
+    position,
+    ancestor_id,
+    name,
+    abbreviation,
+    subcategory
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+);
+
+-- name: DeleteTripadvisorMetroStations :exec
+DELETE FROM poi_data_schema.tripadvisor_metro_stations
+WHERE location_id = $1;
+
+-- name: InsertTripadvisorMetroStation :exec
+INSERT INTO poi_data_schema.tripadvisor_metro_stations (
+    location_id,
+    position,
+    name,
+    local_name,
+    address,
+    local_address,
+    lines,
+    location_lat,
+    location_lng,
+    geom,
+    distance
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9,
+    ST_SetSRID(ST_MakePoint($9, $8), 4326),
+    $10
+);
+
+-- name: GetTripadvisorPOI :one
+SELECT *
+FROM poi_data_schema.tripadvisor
+WHERE location_id = $1;
+
+-- name: ListTripadvisorPOIInBox :many
+-- Places in the box, optionally of one type: $5 is HOTEL, RESTAURANT, ATTRACTION or empty for all.
+SELECT *
+FROM poi_data_schema.tripadvisor
+WHERE ST_Contains(
+    ST_MakeEnvelope($1::float8, $2::float8, $3::float8, $4::float8, 4326),
+    geom
+)
+  AND ($5::text = '' OR type = $5::text)
+ORDER BY location_id
+LIMIT $6::int;
+
+-- name: ListTripadvisorPOINearby :many
+-- Places within $3 meters of ($1 lng, $2 lat), nearest first.
+SELECT sqlc.embed(t),
+       ST_Distance(t.geom::geography, ST_SetSRID(ST_MakePoint($1::float8, $2::float8), 4326)::geography)::float8 AS distance_m
+FROM poi_data_schema.tripadvisor t
+WHERE ST_DWithin(
+    t.geom::geography,
+    ST_SetSRID(ST_MakePoint($1::float8, $2::float8), 4326)::geography,
+    $3::float8
+)
+  AND ($4::text = '' OR t.type = $4::text)
+ORDER BY distance_m
+LIMIT $5::int;
+
+-- name: ListTripadvisorPOIsByH3Cells :many
+-- Places in the children at resolution $1 of the cells $2.
+WITH parent_cells AS (
+    SELECT unnest($2::text[])::h3index AS parent_cell
+)
+SELECT t.*
+FROM poi_data_schema.tripadvisor t
+JOIN LATERAL (
+    SELECT h3_cell_to_children(pc.parent_cell, $1::int)::text AS child_index
+    FROM parent_cells pc
+) children
+ON t.h3_index = children.child_index;
+
+-- name: ListTripadvisorPOIsInAncestor :many
+-- Places that lie in the Tripadvisor location $1, e.g. a city.
+SELECT t.*
+FROM poi_data_schema.tripadvisor t
+WHERE EXISTS (
+    SELECT 1
+    FROM poi_data_schema.tripadvisor_ancestor_locations a
+    WHERE a.location_id = t.location_id
+      AND a.ancestor_id = $1::text
+)
+  AND ($2::text = '' OR t.type = $2::text)
+ORDER BY t.ranking_position NULLS LAST, t.location_id
+LIMIT $3::int;
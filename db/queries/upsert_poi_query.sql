@@ -0,0 +1,251 @@
+-- name: UpsertPOI :one
+INSERT INTO poi_data_schema.google_maps (
+    search_string,
+    rank,
+    search_page_url,
+    is_advertisement,
+    title,
+    sub_title,
+    price,
+    category_name,
+    address,
+    neighborhood,
+    street,
+    city,
+    postal_code,
+    state,
+    country_code,
+    website,
+    phone,
+    phone_unformatted,
+    claim_this_business,
+    location_lat,
+    location_lng,
+    total_score,
+    permanently_closed,
+    temporarily_closed,
+    place_id,
+    categories,
+    fid,
+    cid,
+    reviews_count,
+    images_count,
+    image_categories,
+    scraped_at,
+    google_food_url,
+    hotel_ads,
+    opening_hours,
+    people_also_search,
+    places_tags,
+    reviews_tags,
+    additional_info,
+    gas_prices,
+    url,
+    image_url,
+    kgmid,
+    h3_index,  -- new column for H3
+    geom,      -- geometry column
+    search_page_loaded_url,
+    description,
+    located_in,
+    plus_code,
+    menu,
+    reserve_table_url,
+    hotel_stars,
+    hotel_description,
+    check_in_date,
+    check_out_date,
+    similar_hotels_nearby,
+    hotel_review_summary,
+    popular_times_live_text,
+    popular_times_live_percent,
+    popular_times_histogram,
+    questions_and_answers,
+    updates_from_customers,
+    web_results,
+    parent_place_url,
+    table_reservation_links,
+    booking_links,
+    order_by,
+    images,
+    image_urls,
+    reviews,
+    user_place_note,
+    restaurant_data,
+    owner_updates,
+    phone_e164,
+    website_url,
+    website_host,
+    address_street,
+    address_house_number,
+    address_postal_code,
+    address_city,
+    address_country_code,
+    address_key
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13,
+    $14,
+    $15,
+    $16,
+    $17,
+    $18,
+    $19,
+    $20, -- location_lat
+    $21, -- location_lng
+    $22,
+    $23,
+    $24,
+    $25,
+    $26,
+    $27,
+    $28,
+    $29,
+    $30,
+    $31,
+    $32,
+    $33,
+    $34,
+    $35,
+    $36,
+    $37,
+    $38,
+    $39,
+    $40,
+    $41,
+    $42,
+    $43,                        -- kgmid
+    $44,           -- h3_index
+    ST_SetSRID(ST_MakePoint($21, $20), 4326),   -- geom
+    $45,
+    $46,
+    $47,
+    $48,
+    $49,
+    $50,
+    $51,
+    $52,
+    $53,
+    $54,
+    $55,
+    $56,
+    $57,
+    $58,
+    $59,
+    $60,
+    $61,
+    $62,
+    $63,
+    $64,
+    $65,
+    $66,
+    $67,
+    $68,
+    $69,
+    $70,
+    $71,
+    $72,
+    $73,
+    $74,
+    $75,
+    $76,
+    $77,
+    $78,
+    $79,
+    $80,
+    $81
+)
+ON CONFLICT (place_id) DO UPDATE SET
+    search_string = EXCLUDED.search_string,
+    rank = EXCLUDED.rank,
+    search_page_url = EXCLUDED.search_page_url,
+    is_advertisement = EXCLUDED.is_advertisement,
+    title = EXCLUDED.title,
+    sub_title = EXCLUDED.sub_title,
+    price = EXCLUDED.price,
+    category_name = EXCLUDED.category_name,
+    address = EXCLUDED.address,
+    neighborhood = EXCLUDED.neighborhood,
+    street = EXCLUDED.street,
+    city = EXCLUDED.city,
+    postal_code = EXCLUDED.postal_code,
+    state = EXCLUDED.state,
+    country_code = EXCLUDED.country_code,
+    website = EXCLUDED.website,
+    phone = EXCLUDED.phone,
+    phone_unformatted = EXCLUDED.phone_unformatted,
+    claim_this_business = EXCLUDED.claim_this_business,
+    location_lat = EXCLUDED.location_lat,
+    location_lng = EXCLUDED.location_lng,
+    total_score = EXCLUDED.total_score,
+    permanently_closed = EXCLUDED.permanently_closed,
+    temporarily_closed = EXCLUDED.temporarily_closed,
+    categories = EXCLUDED.categories,
+    fid = EXCLUDED.fid,
+    cid = EXCLUDED.cid,
+    reviews_count = EXCLUDED.reviews_count,
+    images_count = EXCLUDED.images_count,
+    image_categories = EXCLUDED.image_categories,
+    scraped_at = EXCLUDED.scraped_at,
+    google_food_url = EXCLUDED.google_food_url,
+    hotel_ads = EXCLUDED.hotel_ads,
+    opening_hours = EXCLUDED.opening_hours,
+    people_also_search = EXCLUDED.people_also_search,
+    places_tags = EXCLUDED.places_tags,
+    reviews_tags = EXCLUDED.reviews_tags,
+    additional_info = EXCLUDED.additional_info,
+    gas_prices = EXCLUDED.gas_prices,
+    url = EXCLUDED.url,
+    image_url = EXCLUDED.image_url,
+    kgmid = EXCLUDED.kgmid,
+    h3_index = EXCLUDED.h3_index,
+    geom = EXCLUDED.geom,
+    search_page_loaded_url = EXCLUDED.search_page_loaded_url,
+    description = EXCLUDED.description,
+    located_in = EXCLUDED.located_in,
+    plus_code = EXCLUDED.plus_code,
+    menu = EXCLUDED.menu,
+    reserve_table_url = EXCLUDED.reserve_table_url,
+    hotel_stars = EXCLUDED.hotel_stars,
+    hotel_description = EXCLUDED.hotel_description,
+    check_in_date = EXCLUDED.check_in_date,
+    check_out_date = EXCLUDED.check_out_date,
+    similar_hotels_nearby = EXCLUDED.similar_hotels_nearby,
+    hotel_review_summary = EXCLUDED.hotel_review_summary,
+    popular_times_live_text = EXCLUDED.popular_times_live_text,
+    popular_times_live_percent = EXCLUDED.popular_times_live_percent,
+    popular_times_histogram = EXCLUDED.popular_times_histogram,
+    questions_and_answers = EXCLUDED.questions_and_answers,
+    updates_from_customers = EXCLUDED.updates_from_customers,
+    web_results = EXCLUDED.web_results,
+    parent_place_url = EXCLUDED.parent_place_url,
+    table_reservation_links = EXCLUDED.table_reservation_links,
+    booking_links = EXCLUDED.booking_links,
+    order_by = EXCLUDED.order_by,
+    images = EXCLUDED.images,
+    image_urls = EXCLUDED.image_urls,
+    reviews = EXCLUDED.reviews,
+    user_place_note = EXCLUDED.user_place_note,
+    restaurant_data = EXCLUDED.restaurant_data,
+    owner_updates = EXCLUDED.owner_updates,
+    phone_e164 = EXCLUDED.phone_e164,
+    website_url = EXCLUDED.website_url,
+    website_host = EXCLUDED.website_host,
+    address_street = EXCLUDED.address_street,
+    address_house_number = EXCLUDED.address_house_number,
+    address_postal_code = EXCLUDED.address_postal_code,
+    address_city = EXCLUDED.address_city,
+    address_country_code = EXCLUDED.address_country_code,
+    address_key = EXCLUDED.address_key
+RETURNING id;
\ No newline at end of file
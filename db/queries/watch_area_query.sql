@@ -0,0 +1,10 @@
+-- name: ListPOIInPolygon :many
+-- $1 is a WKT polygon in WGS84 coordinates.
+SELECT *
+FROM poi_data_schema.google_maps
+WHERE ST_Contains(ST_GeomFromText($1::text, 4326), geom);
+
+-- name: GetPOIByPlaceID :one
+SELECT *
+FROM poi_data_schema.google_maps
+WHERE place_id = $1;
@@ -0,0 +1,124 @@
+-- name: CreateWebhookSubscription :one
+-- $4 is a WKT polygon in WGS84 coordinates, or empty for every place.
+INSERT INTO poi_data_schema.webhook_subscriptions (
+    url,
+    description,
+    secret,
+    area,
+    event_types
+) VALUES (
+    $1,
+    $2,
+    $3,
+    ST_GeomFromText(NULLIF($4::text, ''), 4326),
+    $5::text[]
+)
+RETURNING id;
+
+-- name: GetWebhookSubscription :one
+SELECT s.id, s.url, s.description, COALESCE(ST_AsText(s.area), '')::text AS area,
+       s.event_types, s.previous_secret_expires_at, s.created_at
+FROM poi_data_schema.webhook_subscriptions s
+WHERE s.id = $1;
+
+-- name: ListWebhookSubscriptions :many
+SELECT s.id, s.url, s.description, COALESCE(ST_AsText(s.area), '')::text AS area,
+       s.event_types, s.previous_secret_expires_at, s.created_at
+FROM poi_data_schema.webhook_subscriptions s
+WHERE s.id > $1
+ORDER BY s.id
+LIMIT $2;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM poi_data_schema.webhook_subscriptions
+WHERE id = $1;
+
+-- name: RotateWebhookSecret :execrows
+-- Replaces the secret, which keeps signing requests until $3.
+UPDATE poi_data_schema.webhook_subscriptions
+SET previous_secret = secret,
+    previous_secret_expires_at = $3::timestamptz,
+    secret = $2::text
+WHERE id = $1;
+
+-- name: GetWebhookCursor :one
+-- Locks the cursor, so one replica at a time matches messages. Returns no
+-- row while another replica holds it.
+SELECT txid, outbox_id
+FROM poi_data_schema.webhook_cursor
+FOR UPDATE SKIP LOCKED;
+
+-- name: UpdateWebhookCursor :exec
+UPDATE poi_data_schema.webhook_cursor
+SET txid = $1::bigint,
+    outbox_id = $2::bigint;
+
+-- name: EnqueueWebhookDeliveries :execrows
+-- Adds a delivery of outbox message $1 for every subscription created before
+-- it ($2) whose area holds the place ($7, $8, when $6 says it has a location)
+-- and whose event types include one of the message's ($9).
+INSERT INTO poi_data_schema.webhook_deliveries (subscription_id, outbox_id, subject, place_id, payload)
+SELECT s.id, $1::bigint, $3::text, $4::text, $5::jsonb
+FROM poi_data_schema.webhook_subscriptions s
+WHERE s.created_at <= $2::timestamptz
+  AND (s.area IS NULL OR ($6::bool AND ST_Intersects(s.area, ST_SetSRID(ST_MakePoint($7::float8, $8::float8), 4326))))
+  AND (cardinality(s.event_types) = 0 OR s.event_types && $9::text[])
+ON CONFLICT (subscription_id, outbox_id) DO NOTHING;
+
+-- name: ClaimWebhookDeliveries :many
+-- Locks the pending deliveries that are due, skipping the ones another
+-- replica holds. The previous secret is returned until it expires.
+SELECT d.id, d.payload, d.attempts, s.url, s.secret,
+       COALESCE(CASE WHEN s.previous_secret_expires_at > now() THEN s.previous_secret END, '')::text AS previous_secret
+FROM poi_data_schema.webhook_deliveries d
+JOIN poi_data_schema.webhook_subscriptions s ON s.id = d.subscription_id
+WHERE d.status = 'pending'
+  AND d.next_attempt_at <= now()
+ORDER BY d.next_attempt_at, d.id
+LIMIT $1::int
+FOR UPDATE OF d SKIP LOCKED;
+
+-- name: MarkWebhookDelivered :exec
+UPDATE poi_data_schema.webhook_deliveries
+SET status = 'delivered',
+    attempts = attempts + 1,
+    last_status_code = $2,
+    last_error = NULL,
+    delivered_at = now()
+WHERE id = $1;
+
+-- name: FailWebhookDelivery :exec
+-- Records a failed attempt. $5 moves the delivery to the dead-letter queue.
+UPDATE poi_data_schema.webhook_deliveries
+SET status = CASE WHEN $5::bool THEN 'dead' ELSE 'pending' END,
+    attempts = attempts + 1,
+    last_status_code = $2,
+    last_error = $3::text,
+    next_attempt_at = $4::timestamptz
+WHERE id = $1;
+
+-- name: ListWebhookDeliveries :many
+-- The deliveries of a subscription, newest first, optionally with status $2.
+SELECT *
+FROM poi_data_schema.webhook_deliveries
+WHERE subscription_id = $1
+  AND ($2::text = '' OR status = $2::text)
+  AND ($3::bigint = 0 OR id < $3::bigint)
+ORDER BY id DESC
+LIMIT $4;
+
+-- name: RedeliverWebhookDelivery :execrows
+-- Queues a dead or delivered delivery again, with a fresh set of attempts.
+UPDATE poi_data_schema.webhook_deliveries
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = now(),
+    delivered_at = NULL
+WHERE id = $1
+  AND status <> 'pending';
+
+-- name: DeleteWebhookDeliveries :execrows
+-- Deletes the deliveries that succeeded before $1.
+DELETE FROM poi_data_schema.webhook_deliveries
+WHERE status = 'delivered'
+  AND delivered_at < $1::timestamptz;
@@ -3,85 +3,58 @@ package models
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
 )
 
+// ParseMode decides what happens when a single item of a dataset fails to decode.
+type ParseMode int
+
 const (
-	AllPOIsSearch = "all_places_no_search"
+	// ParseStrict aborts the whole batch on the first item that fails to decode.
+	ParseStrict ParseMode = iota
+	// ParseLenient decodes item by item, repairs type drift in known-unstable
+	// fields and collects the errors of the items that still fail.
+	ParseLenient
 )
 
-// POI is a Point of Interest
-type shortType struct {
-	Type         string `json:"type,omitempty"`         // Unique for Tripadvisor
-	Kgmid        string `json:"kgmid,omitempty"`        // Unique for Google Maps
-	SearchString string `json:"searchString,omitempty"` // Unique for Google Maps
-
+// ItemError is a decode error for a single item, identified by its index in the dataset.
+type ItemError struct {
+	Index int
+	Err   error
 }
 
-// ParsePOIsFromJSON reads the array of POIs, then decides how to decode each object.
-func ParsePOIsFromJSON(data []byte) ([]POI, error) {
-	// First unmarshal into a slice of RawMessages
-	var rawItems []json.RawMessage
-	if err := json.Unmarshal(data, &rawItems); err != nil {
-		return nil, fmt.Errorf("error unmarshaling top-level array: %w", err)
-	}
-
-	results := make([]POI, 0, len(rawItems))
-
-	for _, raw := range rawItems {
-		// Quick parse just the "type"
-		var st shortType
-		if err := json.Unmarshal(raw, &st); err != nil {
-			return nil, fmt.Errorf("error reading type field: %w", err)
-		}
-
-		if strings.ToLower(st.SearchString) == AllPOIsSearch {
-			fmt.Printf("Parsing POI for PlaceScraper; all_places_no_search\n")
-			var g PlaceScraper
-			if err := json.Unmarshal(raw, &g); err != nil {
-				return nil, fmt.Errorf("error unmarshaling GoogleMaps: %w", err)
-			}
-			results = append(results, &g)
-		} else if st.Type != "" && st.Kgmid == "" {
-			fmt.Printf("Parsing POI for Tripadvisor; type=%s\n", st.Type)
-			switch st.Type {
-			case "HOTEL":
-				var h Hotel
-				if err := json.Unmarshal(raw, &h); err != nil {
-					return nil, fmt.Errorf("error unmarshaling HOTEL: %w", err)
-				}
-				results = append(results, &h)
+func (e ItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
 
-			case "RESTAURANT":
-				var r Restaurant
-				if err := json.Unmarshal(raw, &r); err != nil {
-					return nil, fmt.Errorf("error unmarshaling RESTAURANT: %w", err)
-				}
-				results = append(results, &r)
+func (e ItemError) Unwrap() error {
+	return e.Err
+}
 
-			case "ATTRACTION":
-				var a Attraction
-				if err := json.Unmarshal(raw, &a); err != nil {
-					return nil, fmt.Errorf("error unmarshaling ATTRACTION: %w", err)
-				}
-				results = append(results, &a)
+// ParseResult holds the POIs decoded from a dataset and the name of the parser that decoded them.
+// Raw[i] is the source item of POIs[i] and Indexes[i] its position in the dataset. Errors lists the items that were
+// skipped, Warnings the items that were only decoded after repairing one or more fields. Both are ordered by item index.
+type ParseResult struct {
+	Parser   string
+	POIs     []POI
+	Raw      []json.RawMessage
+	Indexes  []int
+	Errors   []ItemError
+	Warnings []ItemError
+}
 
-			default:
-				// either skip or store in a generic type
-				// for now, we'll just skip
-				fmt.Printf("Skipping unrecognized type: %s\n", st.Type)
-			}
-		} else if st.Type == "" && st.Kgmid != "" {
-			fmt.Printf("Parsing POI for Google Maps; kgmid=%s\n", st.Kgmid)
-			var g Place
-			if err := json.Unmarshal(raw, &g); err != nil {
-				return nil, fmt.Errorf("error unmarshaling GoogleMaps: %w", err)
-			}
-			results = append(results, &g)
-		}
+// ParsePOIsFromJSON reads the array of POIs in strict mode, failing on the first item that does not decode.
+func ParsePOIsFromJSON(data []byte) ([]POI, error) {
+	res, err := ParsePOIs(data, ParseStrict)
+	if err != nil {
+		return nil, err
 	}
+	return res.POIs, nil
+}
 
-	return results, nil
+// ParsePOIs reads the array of POIs with the parser that DefaultRegistry detects for it.
+// In lenient mode only a malformed top-level array is returned as an error.
+func ParsePOIs(data []byte, mode ParseMode) (ParseResult, error) {
+	return DefaultRegistry.Parse(data, ParserAuto, mode)
 }
 
 // Helper: safely dereference pointers
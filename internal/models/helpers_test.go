@@ -0,0 +1,91 @@
+package models
+
+import (
+	"testing"
+)
+
+const driftingDataset = `[
+	{"searchString": "restaurant", "kgmid": "/g/a", "placeId": "a", "title": "Cafe A", "popularTimesHistogram": []},
+	{"searchString": "restaurant", "kgmid": "/g/b", "placeId": "b", "title": "Cafe B", "popularTimesLivePercent": "45%", "hotelStars": 4},
+	{"searchString": "restaurant", "kgmid": "/g/c", "placeId": "c", "title": "Cafe C", "popularTimesHistogram": {"Mo": {"hour": 8}}},
+	{"searchString": "restaurant", "kgmid": "/g/d", "placeId": "d", "title": {"en": "Cafe D"}}
+]`
+
+const driftingTripadvisorDataset = `[
+	{"type": "VACATION_RENTAL", "id": "f", "name": "Cabin F"},
+	{"type": "HOTEL", "id": "e", "name": "Hotel E", "rankingDenominator": 177, "rating": "4.5"}
+]`
+
+func TestParsePOIs(t *testing.T) {
+	t.Run("Strict", func(t *testing.T) {
+		if _, err := ParsePOIs([]byte(driftingDataset), ParseStrict); err == nil {
+			t.Fatal("expected strict mode to fail on the first drifting item")
+		}
+		if _, err := ParsePOIsFromJSON([]byte(driftingDataset)); err == nil {
+			t.Fatal("expected ParsePOIsFromJSON to stay strict")
+		}
+	})
+
+	t.Run("Lenient", func(t *testing.T) {
+		res, err := ParsePOIs([]byte(driftingDataset), ParseLenient)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if res.Parser != ParserGoogleMapsScraper {
+			t.Fatalf("expected the scraper parser, got %s", res.Parser)
+		}
+		if len(res.POIs) != 3 {
+			t.Fatalf("expected 3 POIs, got %d", len(res.POIs))
+		}
+		if len(res.Errors) != 1 || res.Errors[0].Index != 3 {
+			t.Fatalf("expected a single error for item 3, got %v", res.Errors)
+		}
+		if len(res.Warnings) != 3 {
+			t.Fatalf("expected 3 repaired items, got %v", res.Warnings)
+		}
+
+		b := res.POIs[1].(*PlaceScraper)
+		if b.PopularTimesLivePercent == nil || *b.PopularTimesLivePercent != 45 {
+			t.Errorf("expected popularTimesLivePercent 45, got %v", b.PopularTimesLivePercent)
+		}
+		if b.HotelStars == nil || *b.HotelStars != "4" {
+			t.Errorf("expected hotelStars \"4\", got %v", b.HotelStars)
+		}
+
+		c := res.POIs[2].(*PlaceScraper)
+		if c.PopularTimesHistogram != nil {
+			t.Errorf("expected undecodable popularTimesHistogram to be dropped, got %v", c.PopularTimesHistogram)
+		}
+	})
+
+	t.Run("LenientTripadvisor", func(t *testing.T) {
+		res, err := ParsePOIs([]byte(driftingTripadvisorDataset), ParseLenient)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.Parser != ParserTripadvisor {
+			t.Fatalf("expected the tripadvisor parser, got %s", res.Parser)
+		}
+		if len(res.POIs) != 1 {
+			t.Fatalf("expected 1 POI, got %d", len(res.POIs))
+		}
+		if len(res.Indexes) != 1 || res.Indexes[0] != 1 {
+			t.Errorf("expected the hotel at dataset index 1, got %v", res.Indexes)
+		}
+
+		h := res.POIs[0].(*Hotel)
+		if h.RankingDenominator == nil || *h.RankingDenominator != "177" {
+			t.Errorf("expected rankingDenominator \"177\", got %v", h.RankingDenominator)
+		}
+		if h.Rating == nil || *h.Rating != 4.5 {
+			t.Errorf("expected rating 4.5, got %v", h.Rating)
+		}
+	})
+
+	t.Run("MalformedArray", func(t *testing.T) {
+		if _, err := ParsePOIs([]byte(`{"not": "an array"}`), ParseLenient); err == nil {
+			t.Fatal("expected an error for a malformed top-level array")
+		}
+	})
+}
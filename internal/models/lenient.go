@@ -0,0 +1,293 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fieldKind is the JSON shape a field is expected to have.
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindInt
+	kindFloat
+	kindObject
+	kindArray
+)
+
+// Fields that Apify actors are known to emit with more than one shape,
+// e.g. "hotelStars": 4 vs "4 stars", or "popularTimesHistogram": [] vs {}.
+var (
+	placeUnstableFields = map[string]fieldKind{
+		"rank":         kindInt,
+		"totalScore":   kindFloat,
+		"reviewsCount": kindInt,
+		"imagesCount":  kindInt,
+		"price":        kindString,
+		"subTitle":     kindString,
+		"categories":   kindArray,
+		"location":     kindObject,
+	}
+
+	placeScraperUnstableFields = map[string]fieldKind{
+		"rank":                    kindInt,
+		"totalScore":              kindFloat,
+		"reviewsCount":            kindInt,
+		"imagesCount":             kindInt,
+		"price":                   kindString,
+		"subTitle":                kindString,
+		"categories":              kindArray,
+		"location":                kindObject,
+		"hotelStars":              kindString,
+		"popularTimesLivePercent": kindInt,
+		"popularTimesHistogram":   kindObject,
+		"reviewsDistribution":     kindObject,
+		"imageUrls":               kindArray,
+		"images":                  kindString,
+	}
+
+	tripadvisorUnstableFields = map[string]fieldKind{
+		"rankingPosition":    kindInt,
+		"rankingDenominator": kindString,
+		"rating":             kindFloat,
+		"rawRanking":         kindFloat,
+		"photoCount":         kindInt,
+		"latitude":           kindFloat,
+		"longitude":          kindFloat,
+		"subcategories":      kindArray,
+		"ratingHistogram":    kindObject,
+		"hours":              kindObject,
+		"offerGroup":         kindObject,
+		"booking":            kindObject,
+	}
+
+	hotelUnstableFields = mergeFieldKinds(tripadvisorUnstableFields, map[string]fieldKind{
+		"hotelClass":    kindString,
+		"numberOfRooms": kindInt,
+		"amenities":     kindArray,
+	})
+)
+
+func mergeFieldKinds(maps ...map[string]fieldKind) map[string]fieldKind {
+	out := map[string]fieldKind{}
+	for _, m := range maps {
+		for k, v := range m {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// decodeItem unmarshals raw into target. In lenient mode a failed decode is
+// retried after coercing the unstable fields into their expected shape, and as
+// a last resort with the unstable fields that still do not decode set to null.
+// It returns the names of the fields that were changed.
+func decodeItem(raw json.RawMessage, target any, unstable map[string]fieldKind, mode ParseMode) ([]string, error) {
+	err := json.Unmarshal(raw, target)
+	if err == nil || mode == ParseStrict {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if jsonErr := json.Unmarshal(raw, &fields); jsonErr != nil {
+		return nil, err
+	}
+
+	repaired := coerceFields(fields, unstable)
+	if len(repaired) > 0 {
+		patched, marshalErr := json.Marshal(fields)
+		if marshalErr != nil {
+			return nil, marshalErr
+		}
+		resetTarget(target)
+		if err = json.Unmarshal(patched, target); err == nil {
+			return repaired, nil
+		}
+	}
+
+	// The shape of a nested value still does not match; drop the unstable
+	// fields that cannot be decoded on their own.
+	dropped := dropUndecodableFields(fields, target, unstable)
+	if len(dropped) == 0 {
+		return nil, err
+	}
+	patched, marshalErr := json.Marshal(fields)
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+	resetTarget(target)
+	if err := json.Unmarshal(patched, target); err != nil {
+		return nil, err
+	}
+	return mergeNames(repaired, dropped), nil
+}
+
+// resetTarget zeroes what a failed decode left in target, so nothing of it
+// survives into the retry: a null in the patched item leaves a struct as it is.
+func resetTarget(target any) {
+	v := reflect.ValueOf(target).Elem()
+	v.Set(reflect.Zero(v.Type()))
+}
+
+// coerceFields rewrites the unstable fields that do not have their expected shape.
+func coerceFields(fields map[string]json.RawMessage, unstable map[string]fieldKind) []string {
+	var repaired []string
+	for name, kind := range unstable {
+		value, ok := fields[name]
+		if !ok {
+			continue
+		}
+		coerced, changed := coerceValue(value, kind)
+		if !changed {
+			continue
+		}
+		fields[name] = coerced
+		repaired = append(repaired, name)
+	}
+	sort.Strings(repaired)
+	return repaired
+}
+
+// dropUndecodableFields nulls the unstable fields that fail to decode into a
+// fresh value of target's type when they are the only field present.
+func dropUndecodableFields(fields map[string]json.RawMessage, target any, unstable map[string]fieldKind) []string {
+	var dropped []string
+	for name := range unstable {
+		value, ok := fields[name]
+		if !ok || isNull(value) {
+			continue
+		}
+		single, err := json.Marshal(map[string]json.RawMessage{name: value})
+		if err != nil {
+			continue
+		}
+		fresh := reflect.New(reflect.TypeOf(target).Elem()).Interface()
+		if err := json.Unmarshal(single, fresh); err != nil {
+			fields[name] = json.RawMessage("null")
+			dropped = append(dropped, name)
+		}
+	}
+	sort.Strings(dropped)
+	return dropped
+}
+
+// coerceValue converts value into the given kind. It reports false when the
+// value already has the right shape or is null.
+func coerceValue(value json.RawMessage, kind fieldKind) (json.RawMessage, bool) {
+	trimmed := bytes.TrimSpace(value)
+	if len(trimmed) == 0 || isNull(trimmed) {
+		return value, false
+	}
+
+	switch kind {
+	case kindString:
+		switch trimmed[0] {
+		case '"':
+			return value, false
+		case '{', '[':
+			return json.RawMessage("null"), true
+		default:
+			out, _ := json.Marshal(string(trimmed))
+			return out, true
+		}
+
+	case kindInt:
+		f, ok := numberFromJSON(trimmed)
+		if !ok {
+			return json.RawMessage("null"), true
+		}
+		if trimmed[0] != '"' {
+			if _, err := strconv.ParseInt(string(trimmed), 10, 64); err == nil {
+				return value, false
+			}
+		}
+		return json.RawMessage(strconv.FormatInt(int64(math.Round(f)), 10)), true
+
+	case kindFloat:
+		if trimmed[0] != '"' {
+			if _, ok := numberFromJSON(trimmed); ok {
+				return value, false
+			}
+			return json.RawMessage("null"), true
+		}
+		f, ok := numberFromJSON(trimmed)
+		if !ok {
+			return json.RawMessage("null"), true
+		}
+		return json.RawMessage(strconv.FormatFloat(f, 'f', -1, 64)), true
+
+	case kindObject:
+		if trimmed[0] == '{' {
+			return value, false
+		}
+		return json.RawMessage("null"), true
+
+	case kindArray:
+		switch trimmed[0] {
+		case '[':
+			return value, false
+		case '{':
+			return json.RawMessage("null"), true
+		default:
+			// A single scalar where a list is expected, e.g. "categories": "Cafe"
+			return json.RawMessage("[" + string(trimmed) + "]"), true
+		}
+	}
+
+	return value, false
+}
+
+// leadingNumber matches a number at the start of a string, with optional
+// thousands separators: "4.5", "1,234" or "-3".
+var leadingNumber = regexp.MustCompile(`^-?(\d{1,3}(,\d{3})+|\d+)(\.\d+)?`)
+
+// numberFromJSON reads a JSON number, or a string starting with one such as
+// "4.5", "1,234", "45%" or "4.5 of 5". Strings that hold something else, like
+// the range "10-20", are not read.
+func numberFromJSON(value json.RawMessage) (float64, bool) {
+	if value[0] != '"' {
+		f, err := strconv.ParseFloat(string(value), 64)
+		return f, err == nil
+	}
+
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return 0, false
+	}
+	s = strings.TrimSpace(s)
+	m := leadingNumber.FindString(s)
+	if m == "" {
+		return 0, false
+	}
+	// The number must end where the match does, e.g. not "10-20" or "1,2345"
+	if rest := s[len(m):]; rest != "" && strings.ContainsRune("0123456789,.-", rune(rest[0])) {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(strings.ReplaceAll(m, ",", ""), 64)
+	return f, err == nil
+}
+
+func isNull(value json.RawMessage) bool {
+	return string(bytes.TrimSpace(value)) == "null"
+}
+
+func mergeNames(a, b []string) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	out := make([]string, 0, len(a)+len(b))
+	for _, name := range append(append([]string{}, a...), b...) {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNumberFromJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{`4.5`, 4.5, true},
+		{`"4.5"`, 4.5, true},
+		{`"1,234.5"`, 1234.5, true},
+		{`"45%"`, 45, true},
+		{`"4.5 of 5"`, 4.5, true},
+		{`"-3"`, -3, true},
+		{`" 12 reviews"`, 12, true},
+		{`"10-20"`, 0, false},
+		{`"1,2345"`, 0, false},
+		{`"1.2.3"`, 0, false},
+		{`"about 5"`, 0, false},
+		{`""`, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := numberFromJSON(json.RawMessage(tt.in))
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("numberFromJSON(%s) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDecodeItemResetsTarget(t *testing.T) {
+	// The first pass fills location.lat before failing on location.lng; the
+	// dropped location must not keep it.
+	raw := json.RawMessage(`{"placeId": "a", "title": "Cafe A", "location": {"lat": 57.7, "lng": "east"}}`)
+	var p Place
+	repaired, err := decodeItem(raw, &p, placeUnstableFields, ParseLenient)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repaired) != 1 || repaired[0] != "location" {
+		t.Errorf("expected location to be repaired, got %v", repaired)
+	}
+	if p.Location.Lat != 0 || p.Location.Lng != 0 {
+		t.Errorf("expected an empty location, got %+v", p.Location)
+	}
+	if p.PlaceID != "a" || p.Title != "Cafe A" {
+		t.Errorf("expected the other fields to decode, got %q %q", p.PlaceID, p.Title)
+	}
+}
@@ -76,7 +76,7 @@ type Place struct {
 	HotelAds          json.RawMessage `json:"hotelAds"`
 	ImageCategories   []string        `json:"imageCategories"`
 	ImageURL          string          `json:"imageUrl"`
-	ImagesCount       int             `json:"imagesCount"`
+	ImagesCount       *int            `json:"imagesCount"`
 	IsAdvertisement   bool            `json:"isAdvertisement"`
 	Kgmid             string          `json:"kgmid"`
 	Location          Location        `json:"location"`
@@ -91,7 +91,7 @@ type Place struct {
 	PostalCode        string          `json:"postalCode"`
 	Price             *string         `json:"price"` // nil if <nil>
 	Rank              int             `json:"rank"`
-	ReviewsCount      int             `json:"reviewsCount"`
+	ReviewsCount      *int            `json:"reviewsCount"`
 	ReviewsTags       json.RawMessage `json:"reviewsTags"`
 	ScrapedAt         string          `json:"scrapedAt"` // Or time.Time if you parse dates
 	SearchPageURL     string          `json:"searchPageUrl"`
@@ -101,7 +101,7 @@ type Place struct {
 	SubTitle          string          `json:"subTitle"`
 	TemporarilyClosed bool            `json:"temporarilyClosed"`
 	Title             string          `json:"title"`
-	TotalScore        float64         `json:"totalScore"`
+	TotalScore        *float64        `json:"totalScore"`
 	URL               string          `json:"url"`
 	Website           *string         `json:"website"`
 }
@@ -158,16 +158,16 @@ type PlaceScraper struct {
 	LocatedIn               *string                   `json:"locatedIn"`
 	PlusCode                *string                   `json:"plusCode"`
 	Menu                    *string                   `json:"menu"`
-	TotalScore              float64                   `json:"totalScore"`
+	TotalScore              *float64                  `json:"totalScore"`
 	PermanentlyClosed       bool                      `json:"permanentlyClosed"`
 	TemporarilyClosed       bool                      `json:"temporarilyClosed"`
 	PlaceID                 string                    `json:"placeId"`
 	Categories              []string                  `json:"categories"`
 	FID                     string                    `json:"fid"`
 	CID                     string                    `json:"cid"`
-	ReviewsCount            int                       `json:"reviewsCount"`
+	ReviewsCount            *int                      `json:"reviewsCount"`
 	ReviewsDistribution     ReviewDistribution        `json:"reviewsDistribution"`
-	ImagesCount             int                       `json:"imagesCount"`
+	ImagesCount             *int                      `json:"imagesCount"`
 	ImageCategories         []string                  `json:"imageCategories"`
 	ScrapedAt               string                    `json:"scrapedAt"`
 	ReserveTableURL         *string                   `json:"reserveTableUrl"`
@@ -0,0 +1,363 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of the built-in parsers.
+const (
+	ParserAuto                = ""
+	ParserGoogleMapsExtractor = "google_maps_extractor"
+	ParserGoogleMapsScraper   = "google_maps_scraper"
+	ParserTripadvisor         = "tripadvisor"
+)
+
+// detectSampleSize is the number of items the detector scores per dataset.
+const detectSampleSize = 25
+
+// ErrNoParser is returned when no registered parser matches a dataset.
+var ErrNoParser = errors.New("no parser matches the dataset")
+
+// Parser decodes the items of one kind of dataset.
+type Parser interface {
+	// Name identifies the parser in the registry.
+	Name() string
+	// Score reports how well the fields of an item match the parser's schema, from 0 to 1.
+	Score(fields map[string]json.RawMessage) float64
+	// Decode decodes a single item. It returns a nil POI for items that are skipped on purpose,
+	// together with the fields that had to be repaired.
+	Decode(raw json.RawMessage, mode ParseMode) (POI, []string, error)
+}
+
+// Registry holds the known parsers, keyed by name and by alias such as an actor ID.
+type Registry struct {
+	mu      sync.RWMutex
+	parsers map[string]Parser
+	aliases map[string]string
+}
+
+// DefaultRegistry knows the parsers for every source this service ingests.
+var DefaultRegistry = NewRegistry(
+	NewGoogleMapsExtractorParser(),
+	NewGoogleMapsScraperParser(),
+	NewTripadvisorParser(),
+)
+
+// NewRegistry creates a registry holding the given parsers.
+func NewRegistry(parsers ...Parser) *Registry {
+	r := &Registry{
+		parsers: map[string]Parser{},
+		aliases: map[string]string{},
+	}
+	for _, p := range parsers {
+		r.Register(p)
+	}
+	return r
+}
+
+// Register adds a parser, replacing any parser with the same name.
+func (r *Registry) Register(p Parser) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parsers[p.Name()] = p
+}
+
+// Alias makes a parser available under another key, e.g. the ID of the actor producing its datasets.
+func (r *Registry) Alias(alias, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aliases[alias] = name
+}
+
+// Lookup finds a parser by name or alias.
+func (r *Registry) Lookup(key string) (Parser, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if name, ok := r.aliases[key]; ok {
+		key = name
+	}
+	p, ok := r.parsers[key]
+	return p, ok
+}
+
+// Detect sniffs a sample of the items and returns the parser whose schema scores best.
+func (r *Registry) Detect(items []json.RawMessage) (Parser, float64, error) {
+	r.mu.RLock()
+	candidates := make([]Parser, 0, len(r.parsers))
+	for _, p := range r.parsers {
+		candidates = append(candidates, p)
+	}
+	r.mu.RUnlock()
+	// Stable order so that ties are broken the same way on every call
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Name() < candidates[j].Name() })
+
+	var samples []map[string]json.RawMessage
+	for _, raw := range items {
+		if len(samples) == detectSampleSize {
+			break
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			continue
+		}
+		samples = append(samples, fields)
+	}
+	if len(samples) == 0 {
+		return nil, 0, ErrNoParser
+	}
+
+	var best Parser
+	var bestScore float64
+	for _, p := range candidates {
+		var total float64
+		for _, fields := range samples {
+			total += p.Score(fields)
+		}
+		if score := total / float64(len(samples)); score > bestScore {
+			best, bestScore = p, score
+		}
+	}
+	if best == nil {
+		return nil, 0, ErrNoParser
+	}
+	return best, bestScore, nil
+}
+
+// SplitItems splits a dataset, a top-level JSON array, into its raw items.
+func SplitItems(data []byte) ([]json.RawMessage, error) {
+	var rawItems []json.RawMessage
+	if err := json.Unmarshal(data, &rawItems); err != nil {
+		return nil, fmt.Errorf("error unmarshaling top-level array: %w", err)
+	}
+	return rawItems, nil
+}
+
+// Resolve returns the parser registered under key. With ParserAuto the parser
+// is detected from the items themselves.
+func (r *Registry) Resolve(items []json.RawMessage, key string) (Parser, error) {
+	if key != ParserAuto {
+		p, ok := r.Lookup(key)
+		if !ok {
+			return nil, fmt.Errorf("unknown parser: %s", key)
+		}
+		return p, nil
+	}
+	p, score, err := r.Detect(items)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Detected parser %s; score=%.2f", p.Name(), score)
+	return p, nil
+}
+
+// Parse decodes a top-level array of items with the parser registered under key.
+// With ParserAuto the parser is detected from the items themselves.
+func (r *Registry) Parse(data []byte, key string, mode ParseMode) (ParseResult, error) {
+	rawItems, err := SplitItems(data)
+	if err != nil {
+		return ParseResult{}, err
+	}
+	if key == ParserAuto && len(rawItems) == 0 {
+		return ParseResult{POIs: []POI{}, Raw: []json.RawMessage{}, Indexes: []int{}}, nil
+	}
+	parser, err := r.Resolve(rawItems, key)
+	if err != nil {
+		return ParseResult{}, err
+	}
+
+	res := ParseResult{
+		Parser:  parser.Name(),
+		POIs:    make([]POI, 0, len(rawItems)),
+		Raw:     make([]json.RawMessage, 0, len(rawItems)),
+		Indexes: make([]int, 0, len(rawItems)),
+	}
+
+	for i, raw := range rawItems {
+		poi, repaired, err := parser.Decode(raw, mode)
+		if err != nil {
+			if mode == ParseStrict {
+				return ParseResult{}, ItemError{Index: i, Err: err}
+			}
+			res.Errors = append(res.Errors, ItemError{Index: i, Err: err})
+			continue
+		}
+		if len(repaired) > 0 {
+			res.Warnings = append(res.Warnings, ItemError{
+				Index: i,
+				Err:   fmt.Errorf("repaired fields: %s", strings.Join(repaired, ", ")),
+			})
+		}
+		if poi != nil {
+			res.POIs = append(res.POIs, poi)
+			res.Raw = append(res.Raw, raw)
+			res.Indexes = append(res.Indexes, i)
+		}
+	}
+
+	return res, nil
+}
+
+// structParser decodes every item into the same struct type.
+type structParser struct {
+	name     string
+	newPOI   func() POI
+	schema   map[string]struct{}
+	unstable map[string]fieldKind
+}
+
+// NewGoogleMapsExtractorParser decodes items of the Google Maps Extractor actor into Place.
+func NewGoogleMapsExtractorParser() Parser {
+	return &structParser{
+		name:     ParserGoogleMapsExtractor,
+		newPOI:   func() POI { return &Place{} },
+		schema:   jsonFieldNames(reflect.TypeOf(Place{})),
+		unstable: placeUnstableFields,
+	}
+}
+
+// NewGoogleMapsScraperParser decodes items of the Google Maps Scraper actor into PlaceScraper.
+func NewGoogleMapsScraperParser() Parser {
+	return &structParser{
+		name:     ParserGoogleMapsScraper,
+		newPOI:   func() POI { return &PlaceScraper{} },
+		schema:   jsonFieldNames(reflect.TypeOf(PlaceScraper{})),
+		unstable: placeScraperUnstableFields,
+	}
+}
+
+func (p *structParser) Name() string {
+	return p.name
+}
+
+func (p *structParser) Score(fields map[string]json.RawMessage) float64 {
+	return schemaScore(fields, p.schema)
+}
+
+func (p *structParser) Decode(raw json.RawMessage, mode ParseMode) (POI, []string, error) {
+	poi := p.newPOI()
+	repaired, err := decodeItem(raw, poi, p.unstable, mode)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error unmarshaling %s: %w", p.name, err)
+	}
+	return poi, repaired, nil
+}
+
+// tripadvisorParser decodes Tripadvisor items, whose "type" decides between Hotel, Restaurant and Attraction.
+type tripadvisorParser struct {
+	schemas map[string]map[string]struct{}
+}
+
+// NewTripadvisorParser decodes items of the Tripadvisor actor.
+func NewTripadvisorParser() Parser {
+	return &tripadvisorParser{
+		schemas: map[string]map[string]struct{}{
+			"HOTEL":      jsonFieldNames(reflect.TypeOf(Hotel{})),
+			"RESTAURANT": jsonFieldNames(reflect.TypeOf(Restaurant{})),
+			"ATTRACTION": jsonFieldNames(reflect.TypeOf(Attraction{})),
+		},
+	}
+}
+
+func (p *tripadvisorParser) Name() string {
+	return ParserTripadvisor
+}
+
+func (p *tripadvisorParser) Score(fields map[string]json.RawMessage) float64 {
+	var typ string
+	if err := json.Unmarshal(fields["type"], &typ); err != nil {
+		return 0
+	}
+	schema, ok := p.schemas[typ]
+	if !ok {
+		return 0
+	}
+	return schemaScore(fields, schema)
+}
+
+func (p *tripadvisorParser) Decode(raw json.RawMessage, mode ParseMode) (POI, []string, error) {
+	var st struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &st); err != nil {
+		return nil, nil, fmt.Errorf("error reading type field: %w", err)
+	}
+
+	switch st.Type {
+	case "HOTEL":
+		var h Hotel
+		repaired, err := decodeItem(raw, &h, hotelUnstableFields, mode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error unmarshaling HOTEL: %w", err)
+		}
+		return &h, repaired, nil
+
+	case "RESTAURANT":
+		var r Restaurant
+		repaired, err := decodeItem(raw, &r, tripadvisorUnstableFields, mode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error unmarshaling RESTAURANT: %w", err)
+		}
+		return &r, repaired, nil
+
+	case "ATTRACTION":
+		var a Attraction
+		repaired, err := decodeItem(raw, &a, tripadvisorUnstableFields, mode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error unmarshaling ATTRACTION: %w", err)
+		}
+		return &a, repaired, nil
+
+	default:
+		// either skip or store in a generic type
+		// for now, we'll just skip
+		log.Printf("Skipping unrecognized type: %s", st.Type)
+		return nil, nil, nil
+	}
+}
+
+// schemaScore rates an item's fields against a schema. Mostly it is the share
+// of the item's fields the schema knows, so that a scraper item with fields
+// the extractor never emits is not decoded as an extractor Place. The share
+// of the schema the item covers breaks ties: an extractor item fits inside the
+// scraper schema too, but fills more of the smaller extractor schema.
+func schemaScore(fields map[string]json.RawMessage, schema map[string]struct{}) float64 {
+	if len(fields) == 0 || len(schema) == 0 {
+		return 0
+	}
+	var known int
+	for name := range fields {
+		if _, ok := schema[name]; ok {
+			known++
+		}
+	}
+	precision := float64(known) / float64(len(fields))
+	recall := float64(known) / float64(len(schema))
+	return 0.9*precision + 0.1*recall
+}
+
+// jsonFieldNames collects the JSON names of a struct's fields, including those of embedded structs.
+func jsonFieldNames(t reflect.Type) map[string]struct{} {
+	names := map[string]struct{}{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for name := range jsonFieldNames(f.Type) {
+				names[name] = struct{}{}
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		names[name] = struct{}{}
+	}
+	return names
+}
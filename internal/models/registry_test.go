@@ -0,0 +1,73 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// extractorItem carries every field the extractor emits, most of them null.
+const extractorItem = `{
+	"additionalInfo": null, "address": "Kungstorget 9", "categories": ["Cafe"], "categoryName": "Cafe",
+	"cid": "1", "city": "Gothenburg", "claimThisBusiness": false, "countryCode": "SE", "fid": "0x1",
+	"gasPrices": null, "googleFoodUrl": null, "hotelAds": null, "imageCategories": null, "imageUrl": "",
+	"imagesCount": 3, "isAdvertisement": false, "kgmid": "/g/1", "location": {"lat": 57.7, "lng": 11.96},
+	"neighborhood": null, "openingHours": null, "peopleAlsoSearch": null, "permanentlyClosed": false,
+	"phone": null, "phoneUnformatted": null, "placeId": "p1", "placesTags": null, "postalCode": "411 17",
+	"price": null, "rank": 1, "reviewsCount": 10, "reviewsTags": null, "scrapedAt": "2025-01-01T00:00:00Z",
+	"searchPageUrl": "", "searchString": "cafe", "state": null, "street": "Kungstorget 9", "subTitle": "",
+	"temporarilyClosed": false, "title": "Cafe", "totalScore": 4.2, "url": "", "website": null
+}`
+
+func TestRegistryDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []string
+		want  string
+	}{
+		{
+			name:  "Extractor",
+			items: []string{extractorItem},
+			want:  ParserGoogleMapsExtractor,
+		},
+		{
+			name: "ScraperWithKgmid",
+			items: []string{
+				`{"searchString": "cafe", "kgmid": "/g/1", "placeId": "p1", "title": "Cafe", "plusCode": "9F5M+2C", "reviewsDistribution": {"oneStar": 1}}`,
+			},
+			want: ParserGoogleMapsScraper,
+		},
+		{
+			name:  "Tripadvisor",
+			items: []string{`{"type": "RESTAURANT", "id": "1", "name": "Bistro", "cuisines": ["French"]}`},
+			want:  ParserTripadvisor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var items []json.RawMessage
+			for _, item := range tt.items {
+				items = append(items, json.RawMessage(item))
+			}
+			p, _, err := DefaultRegistry.Detect(items)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.Name() != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, p.Name())
+			}
+		})
+	}
+}
+
+func TestRegistryLookup(t *testing.T) {
+	r := NewRegistry(NewGoogleMapsScraperParser())
+	r.Alias("actor-id", ParserGoogleMapsScraper)
+
+	if _, ok := r.Lookup("actor-id"); !ok {
+		t.Fatal("expected the alias to resolve")
+	}
+	if _, err := r.Parse([]byte(`[]`), ParserTripadvisor, ParseLenient); err == nil {
+		t.Fatal("expected an error for an unregistered parser")
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Review is a single entry of the reviews array of the Google Maps scraper.
+type Review struct {
+	ReviewID                string   `json:"reviewId"`
+	ReviewURL               string   `json:"reviewUrl"`
+	ReviewOrigin            string   `json:"reviewOrigin"`
+	Name                    string   `json:"name"`
+	ReviewerID              string   `json:"reviewerId"`
+	ReviewerURL             string   `json:"reviewerUrl"`
+	ReviewerPhotoURL        string   `json:"reviewerPhotoUrl"`
+	ReviewerNumberOfReviews *int     `json:"reviewerNumberOfReviews"`
+	IsLocalGuide            *bool    `json:"isLocalGuide"`
+	Text                    *string  `json:"text"`
+	TextTranslated          *string  `json:"textTranslated"`
+	OriginalLanguage        *string  `json:"originalLanguage"`
+	PublishAt               string   `json:"publishAt"`       // Relative, e.g. "a month ago"
+	PublishedAtDate         string   `json:"publishedAtDate"` // RFC 3339
+	LikesCount              *int     `json:"likesCount"`
+	Stars                   *int     `json:"stars"`
+	ResponseFromOwnerDate   *string  `json:"responseFromOwnerDate"`
+	ResponseFromOwnerText   *string  `json:"responseFromOwnerText"`
+	ReviewImageURLs         []string `json:"reviewImageUrls"`
+}
+
+// ParseReviews decodes the reviews of a scraped place. Reviews without an ID
+// cannot be deduplicated across scrapes and are dropped.
+func ParseReviews(raw json.RawMessage) ([]Review, error) {
+	if len(raw) == 0 || strings.TrimSpace(string(raw)) == "null" {
+		return nil, nil
+	}
+	var all []Review
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, err
+	}
+	reviews := all[:0]
+	for _, r := range all {
+		if r.ReviewID != "" {
+			reviews = append(reviews, r)
+		}
+	}
+	return reviews, nil
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	admin_v1 "apify-poi-data/proto/apify/admin/v1"
+)
+
+const (
+	defaultQuarantineLimit = 100
+	maxQuarantineLimit     = 1000
+)
+
+// Statuses of a quarantined POI.
+const (
+	quarantinePending   = "pending"
+	quarantineReleased  = "released"
+	quarantineDiscarded = "discarded"
+)
+
+type AdminService struct {
+	admin_v1.UnimplementedAdminServiceServer
+	Database *sqlc_db.Database
+	Maps     *MapsService // Ingests released POIs
+}
+
+func (a *AdminService) ListQuarantinedPOIs(ctx context.Context, in *admin_v1.ListQuarantinedPOIsRequest) (*admin_v1.ListQuarantinedPOIsResponse, error) {
+	limit := in.GetLimit()
+	if limit <= 0 {
+		limit = defaultQuarantineLimit
+	}
+	if limit > maxQuarantineLimit {
+		limit = maxQuarantineLimit
+	}
+
+	rows, err := a.Database.Queries.ListQuarantinedPOIs(ctx, sqlc_db.ListQuarantinedPOIsParams{
+		Column1: in.GetAfterId(),
+		Column2: in.GetStatus(),
+		Column3: in.GetReason(),
+		Column4: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &admin_v1.ListQuarantinedPOIsResponse{}
+	for _, row := range rows {
+		poi, err := toQuarantinedPOI(row)
+		if err != nil {
+			return nil, err
+		}
+		resp.Pois = append(resp.Pois, poi)
+	}
+	if len(rows) == int(limit) {
+		resp.NextAfterId = rows[len(rows)-1].ID
+	}
+	return resp, nil
+}
+
+// ResolveQuarantinedPOI claims a pending POI and, when it is released, upserts
+// it in the same transaction, so that a POI is released at most once.
+func (a *AdminService) ResolveQuarantinedPOI(ctx context.Context, in *admin_v1.ResolveQuarantinedPOIRequest) (*admin_v1.ResolveQuarantinedPOIResponse, error) {
+	var status string
+	switch in.GetAction() {
+	case admin_v1.ResolveQuarantinedPOIRequest_DISCARD:
+		status = quarantineDiscarded
+	case admin_v1.ResolveQuarantinedPOIRequest_RELEASE, admin_v1.ResolveQuarantinedPOIRequest_SWAP_AND_RELEASE:
+		status = quarantineReleased
+	default:
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "unsupported action: %s", in.GetAction())
+	}
+	swap := in.GetAction() == admin_v1.ResolveQuarantinedPOIRequest_SWAP_AND_RELEASE
+
+	tx, err := a.Database.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	q := a.Database.Queries.WithTx(tx)
+
+	row, err := q.ClaimQuarantinedPOI(ctx, sqlc_db.ClaimQuarantinedPOIParams{
+		ID:     in.GetId(),
+		Status: status,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		prev, err := a.Database.Queries.GetQuarantinedPOI(ctx, in.GetId())
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("quarantined POI %d not found", in.GetId())
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("quarantined POI %d is already %s", prev.ID, prev.Status)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if status == quarantineReleased {
+		items := []ingestItem{{
+			parser: row.Parser,
+			raw:    row.Item,
+			swap:   swap,
+		}}
+		res := a.Maps.ingest(ctx, items, ingestOptions{
+			sourceRunID:    row.SourceRunID,
+			skipValidation: true,
+			write:          a.Maps.upsertPOIInTx(q),
+		})
+		if len(res.Errors) > 0 {
+			return nil, res.Errors[0]
+		}
+		if res.Err != nil {
+			return nil, res.Err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &admin_v1.ResolveQuarantinedPOIResponse{
+		Status: status,
+	}, nil
+}
+
+func toQuarantinedPOI(row sqlc_db.PoiDataSchemaQuarantine) (*admin_v1.QuarantinedPOI, error) {
+	item, err := rawMessageToValue(row.Item)
+	if err != nil {
+		return nil, err
+	}
+	poi := &admin_v1.QuarantinedPOI{
+		Id:          row.ID,
+		ItemId:      row.ItemID,
+		Parser:      row.Parser,
+		SourceRunId: row.SourceRunID,
+		Reason:      row.Reason,
+		Details:     row.Details.String,
+		Item:        item,
+		Status:      row.Status,
+		CreatedAt:   row.CreatedAt.Format(time.RFC3339),
+	}
+	if row.LocationLat.Valid {
+		poi.LocationLat = &row.LocationLat.Float64
+	}
+	if row.LocationLng.Valid {
+		poi.LocationLng = &row.LocationLng.Float64
+	}
+	if row.ReviewedAt.Valid {
+		reviewedAt := row.ReviewedAt.Time.Format(time.RFC3339)
+		poi.ReviewedAt = &reviewedAt
+	}
+	return poi, nil
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/models"
+	poi_v1 "apify-poi-data/proto/apify/poi/v1"
+)
+
+// attributeKey names an attribute within its additional_info section.
+type attributeKey struct {
+	section string
+	name    string
+}
+
+// parseAttributeKeys lists the attributes of an additionalInfo object, which
+// maps each section to a list of single-key objects:
+//
+//	{"Accessibility": [{"Wheelchair accessible entrance": true}], ...}
+func parseAttributeKeys(raw json.RawMessage) ([]attributeKey, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var sections map[string][]map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &sections); err != nil {
+		return nil, fmt.Errorf("decoding additional info: %w", err)
+	}
+	var keys []attributeKey
+	for section, items := range sections {
+		for _, item := range items {
+			for name := range item {
+				keys = append(keys, attributeKey{section: section, name: name})
+			}
+		}
+	}
+	return keys, nil
+}
+
+// storeAttributes adds the attribute keys of a place to the catalog.
+func (m *MapsService) storeAttributes(ctx context.Context, poi models.POI) error {
+	var raw json.RawMessage
+	switch p := poi.(type) {
+	case *models.Place:
+		raw = p.AdditionalInfo
+	case *models.PlaceScraper:
+		raw = p.AdditionalInfo
+	default:
+		return nil
+	}
+	keys, err := parseAttributeKeys(raw)
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+
+	sections := make([]string, len(keys))
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		sections[i], names[i] = k.section, k.name
+	}
+	return m.Database.Queries.UpsertAttributes(ctx, sqlc_db.UpsertAttributesParams{
+		Column1: sections,
+		Column2: names,
+	})
+}
+
+// attributeFilter is implemented by the list requests that can require or exclude attributes.
+type attributeFilter interface {
+	GetRequireAttributes() []string
+	GetExcludeAttributes() []string
+}
+
+// resolveAttributes turns "Section/Attribute" or bare "Attribute" values into
+// keys. A bare attribute is looked up in the catalog and resolves to every
+// section it appears in.
+func (p *PoiService) resolveAttributes(ctx context.Context, values []string) ([][]attributeKey, error) {
+	resolved := make([][]attributeKey, 0, len(values))
+	for _, v := range values {
+		if section, name, ok := strings.Cut(v, "/"); ok && section != "" && name != "" {
+			resolved = append(resolved, []attributeKey{{section: section, name: name}})
+			continue
+		}
+		sections, err := p.Database.Queries.ListAttributeSections(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		if len(sections) == 0 {
+			return nil, fmt.Errorf("unknown attribute: %q", v)
+		}
+		keys := make([]attributeKey, len(sections))
+		for i, section := range sections {
+			keys[i] = attributeKey{section: section, name: v}
+		}
+		resolved = append(resolved, keys)
+	}
+	return resolved, nil
+}
+
+// attributeDocument builds the additional_info fragment that contains keys set to true.
+func attributeDocument(keys ...attributeKey) ([]byte, error) {
+	doc := map[string][]map[string]bool{}
+	for _, k := range keys {
+		doc[k.section] = append(doc[k.section], map[string]bool{k.name: true})
+	}
+	return json.Marshal(doc)
+}
+
+// attributeConditions are the additional_info fragments the list queries match
+// the required and excluded attributes of a request with, through the GIN index
+// on additional_info.
+type attributeConditions struct {
+	required []byte   // Fragment every place contains, nil when no attribute is required
+	excluded [][]byte // Fragments no place contains; never nil, a NULL array would match nothing
+}
+
+// attributeConditions resolves the required and excluded attributes of a list request.
+func (p *PoiService) attributeConditions(ctx context.Context, in attributeFilter) (attributeConditions, error) {
+	conds := attributeConditions{excluded: [][]byte{}}
+	if len(in.GetRequireAttributes()) == 0 && len(in.GetExcludeAttributes()) == 0 {
+		return conds, nil
+	}
+
+	required, err := p.resolveAttributes(ctx, in.GetRequireAttributes())
+	if err != nil {
+		return attributeConditions{}, err
+	}
+	var requiredKeys []attributeKey
+	for _, keys := range required {
+		if len(keys) > 1 {
+			sections := make([]string, len(keys))
+			for i, k := range keys {
+				sections[i] = k.section
+			}
+			return attributeConditions{}, fmt.Errorf("attribute %q appears in %s; require it as Section/%s", keys[0].name, strings.Join(sections, ", "), keys[0].name)
+		}
+		requiredKeys = append(requiredKeys, keys[0])
+	}
+	if len(requiredKeys) > 0 {
+		conds.required, err = attributeDocument(requiredKeys...)
+		if err != nil {
+			return attributeConditions{}, err
+		}
+	}
+
+	excluded, err := p.resolveAttributes(ctx, in.GetExcludeAttributes())
+	if err != nil {
+		return attributeConditions{}, err
+	}
+	for _, keys := range excluded {
+		for _, k := range keys {
+			doc, err := attributeDocument(k)
+			if err != nil {
+				return attributeConditions{}, err
+			}
+			conds.excluded = append(conds.excluded, doc)
+		}
+	}
+	return conds, nil
+}
+
+// ListAttributes returns the known attribute keys, grouped by section.
+func (p *PoiService) ListAttributes(ctx context.Context, in *poi_v1.ListAttributesRequest) (*poi_v1.ListAttributesResponse, error) {
+	rows, err := p.Database.Queries.ListAttributes(ctx, in.GetSection())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &poi_v1.ListAttributesResponse{}
+	var section *poi_v1.AttributeSection
+	for _, row := range rows {
+		if section == nil || section.Name != row.Section {
+			section = &poi_v1.AttributeSection{Name: row.Section}
+			res.Sections = append(res.Sections, section)
+		}
+		section.Attributes = append(section.Attributes, row.Attribute)
+	}
+	return res, nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	poi_v1 "apify-poi-data/proto/apify/poi/v1"
+)
+
+// likeEscaper escapes the LIKE wildcards in a category, using the default escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// normalizeCategory folds a category the way google_maps_categories.category_norm is:
+// lowercase, trimmed and with runs of white space collapsed.
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.Join(strings.Fields(category), " "))
+}
+
+// categoryPattern returns the LIKE pattern that matches category in the given mode.
+// Fuzzy matches do not use a pattern.
+func categoryPattern(category string, match poi_v1.CategoryMatch) string {
+	escaped := likeEscaper.Replace(normalizeCategory(category))
+	switch match {
+	case poi_v1.CategoryMatch_CATEGORY_MATCH_EXACT:
+		return escaped
+	case poi_v1.CategoryMatch_CATEGORY_MATCH_PREFIX:
+		return escaped + "%"
+	default:
+		return "%" + escaped + "%"
+	}
+}
+
+// listPOIInBoxWithCategory runs the box query that matches the requested category mode.
+func (p *PoiService) listPOIInBoxWithCategory(ctx context.Context, in *poi_v1.ListPOIInBoxWithCategorySearchRequest) ([]sqlc_db.PoiDataSchemaGoogleMap, error) {
+	conds, err := p.attributeConditions(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if in.GetCategoryMatch() == poi_v1.CategoryMatch_CATEGORY_MATCH_FUZZY {
+		return p.Database.Queries.ListPOIInBoxWithCategoryFuzzyH3(ctx, sqlc_db.ListPOIInBoxWithCategoryFuzzyH3Params{
+			Column1: in.GetMinX(),
+			Column2: in.GetMinY(),
+			Column3: in.GetMaxX(),
+			Column4: in.GetMaxY(),
+			Column5: normalizeCategory(in.GetCategorySubstring()),
+			Column6: conds.required,
+			Column7: conds.excluded,
+		})
+	}
+	return p.Database.Queries.ListPOIInBoxWithCategoryH3(ctx, sqlc_db.ListPOIInBoxWithCategoryH3Params{
+		Column1: in.GetMinX(),
+		Column2: in.GetMinY(),
+		Column3: in.GetMaxX(),
+		Column4: in.GetMaxY(),
+		Column5: categoryPattern(in.GetCategorySubstring(), in.GetCategoryMatch()),
+		Column6: conds.required,
+		Column7: conds.excluded,
+	})
+}
+
+// listPOIAlongRouteWithCategory runs the route query that matches the requested category mode.
+func (p *PoiService) listPOIAlongRouteWithCategory(ctx context.Context, in *poi_v1.ListPOIAlongRouteWithCategoryRequest) ([]sqlc_db.PoiDataSchemaGoogleMap, error) {
+	conds, err := p.attributeConditions(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if in.GetCategoryMatch() == poi_v1.CategoryMatch_CATEGORY_MATCH_FUZZY {
+		return p.Database.Queries.ListPOIAlongRouteWithCategoryFuzzyH3(ctx, sqlc_db.ListPOIAlongRouteWithCategoryFuzzyH3Params{
+			Column1: in.GetALat(),
+			Column2: in.GetALon(),
+			Column3: in.GetBLat(),
+			Column4: in.GetBLon(),
+			Column5: float64(in.GetBuffer()),
+			Column6: normalizeCategory(in.GetCategorySubstring()),
+			Column7: conds.required,
+			Column8: conds.excluded,
+		})
+	}
+	return p.Database.Queries.ListPOIAlongRouteWithCategoryH3(ctx, sqlc_db.ListPOIAlongRouteWithCategoryH3Params{
+		Column1: in.GetALat(),
+		Column2: in.GetALon(),
+		Column3: in.GetBLat(),
+		Column4: in.GetBLon(),
+		Column5: float64(in.GetBuffer()),
+		Column6: categoryPattern(in.GetCategorySubstring(), in.GetCategoryMatch()),
+		Column7: conds.required,
+		Column8: conds.excluded,
+	})
+}
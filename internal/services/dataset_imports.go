@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/models"
+	maps_v1 "apify-poi-data/proto/apify/maps/v1"
+)
+
+// Statuses recorded in the dataset import ledger.
+const (
+	importRunning   = "running"
+	importSucceeded = "succeeded"
+	importFailed    = "failed"
+)
+
+const (
+	importPollInterval = 2 * time.Second
+	importStaleAfter   = time.Hour // Running imports older than this are assumed dead, as in ClaimDatasetImport
+)
+
+// importDataset imports a dataset at most once per content. Concurrent calls for
+// the same dataset share a single import, whatever their parser or force flag,
+// and calls on other instances wait for it through the ledger.
+func (m *MapsService) importDataset(ctx context.Context, datasetID, parser string, force bool) (*maps_v1.DatasetItemsResponse, error) {
+	ch := m.imports.DoChan(datasetID, func() (any, error) {
+		// The import outlives a caller that gives up waiting, others may be attached to it
+		return m.runDatasetImport(context.WithoutCancel(ctx), datasetID, parser, force)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		if res.Shared {
+			log.Printf("Attached to in-flight import of dataset %s", datasetID)
+		}
+		return proto.Clone(res.Val.(*maps_v1.DatasetItemsResponse)).(*maps_v1.DatasetItemsResponse), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (m *MapsService) runDatasetImport(ctx context.Context, datasetID, parser string, force bool) (*maps_v1.DatasetItemsResponse, error) {
+	data, err := m.ApifyClient.GetDataset(datasetID)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	contentHash := hex.EncodeToString(sum[:])
+
+	if !force {
+		prev, err := m.Database.Queries.GetDatasetImport(ctx, sqlc_db.GetDatasetImportParams{
+			DatasetID:   datasetID,
+			ContentHash: contentHash,
+		})
+		switch {
+		case err == nil && prev.Status == importSucceeded:
+			fmt.Printf("Dataset %s already imported; hash=%s\n", datasetID, contentHash)
+			return importedResponse(prev), nil
+		case err != nil && !errors.Is(err, pgx.ErrNoRows):
+			return nil, err
+		}
+	}
+
+	rawItems, err := models.SplitItems(data)
+	if err != nil {
+		return nil, err
+	}
+	p, err := models.DefaultRegistry.Resolve(rawItems, parser)
+	if err != nil {
+		return nil, err
+	}
+	switch p.Name() {
+	case models.ParserGoogleMapsExtractor, models.ParserGoogleMapsScraper:
+	case models.ParserTripadvisor:
+		// Tripadvisor places have their own tables, which only SearchTripadvisor writes
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "dataset %s holds Tripadvisor places, which are imported by running SearchTripadvisor (POST /v1/tripadvisor/search) rather than InsertApifyDatasetItems", datasetID)
+	default:
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "datasets decoded by %s cannot be inserted", p.Name())
+	}
+
+	var importID int64
+	for {
+		importID, err = m.Database.Queries.ClaimDatasetImport(ctx, sqlc_db.ClaimDatasetImportParams{
+			DatasetID:   datasetID,
+			ContentHash: contentHash,
+			Parser:      p.Name(),
+		})
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		// Another instance is importing the same content
+		log.Printf("Waiting for the import of dataset %s on another instance", datasetID)
+		prev, err := m.waitDatasetImport(ctx, datasetID, contentHash)
+		if err != nil {
+			return nil, err
+		}
+		switch prev.Status {
+		case importSucceeded:
+			return importedResponse(prev), nil
+		case importFailed:
+			return nil, fmt.Errorf("import of dataset %s failed: %s", datasetID, prev.Error.String)
+		}
+		// The other import died; claim it again
+	}
+
+	fmt.Printf("Data received inside InsertApifyDatasetItems; parser=%s\n", p.Name())
+	items := make([]ingestItem, len(rawItems))
+	for i, raw := range rawItems {
+		items[i] = ingestItem{
+			parser: p.Name(),
+			raw:    raw,
+		}
+	}
+	res := m.ingest(ctx, items, ingestOptions{
+		sourceRunID: datasetID,
+		write:       m.upsertPOItoDB,
+	})
+
+	finish := sqlc_db.FinishDatasetImportParams{
+		ID:            importID,
+		Status:        importSucceeded,
+		ItemCount:     int32(len(rawItems)),
+		InsertedCount: int32(res.Completed),
+		FailedCount:   int32(len(res.Errors)),
+	}
+	if res.Completed == 0 && len(res.Errors) > 0 {
+		finish.Status = importFailed
+		finish.Error = pgtype.Text{String: "no POI could be inserted", Valid: true}
+	}
+	if err := m.Database.Queries.FinishDatasetImport(ctx, finish); err != nil {
+		log.Printf("Failed to record import of dataset %s: %v", datasetID, err)
+	}
+
+	return &maps_v1.DatasetItemsResponse{
+		Status:      "success",
+		ItemErrors:  pipelineErrorsToProto(res.Errors),
+		Parser:      p.Name(),
+		ContentHash: contentHash,
+		ItemCount:   finish.ItemCount,
+		Inserted:    finish.InsertedCount,
+		Failed:      finish.FailedCount,
+	}, nil
+}
+
+// waitDatasetImport polls the ledger until the running import of a dataset
+// content finishes, or has run so long it is assumed dead.
+func (m *MapsService) waitDatasetImport(ctx context.Context, datasetID, contentHash string) (sqlc_db.PoiDataSchemaDatasetImport, error) {
+	ticker := time.NewTicker(importPollInterval)
+	defer ticker.Stop()
+	for {
+		prev, err := m.Database.Queries.GetDatasetImport(ctx, sqlc_db.GetDatasetImportParams{
+			DatasetID:   datasetID,
+			ContentHash: contentHash,
+		})
+		if err != nil {
+			return prev, err
+		}
+		if prev.Status != importRunning || time.Since(prev.StartedAt) > importStaleAfter {
+			return prev, nil
+		}
+		select {
+		case <-ctx.Done():
+			return prev, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// importedResponse reports an import recorded in the ledger.
+func importedResponse(prev sqlc_db.PoiDataSchemaDatasetImport) *maps_v1.DatasetItemsResponse {
+	return &maps_v1.DatasetItemsResponse{
+		Status:          "success",
+		Parser:          prev.Parser,
+		ContentHash:     prev.ContentHash,
+		AlreadyImported: true,
+		ItemCount:       prev.ItemCount,
+		Inserted:        prev.InsertedCount,
+		Failed:          prev.FailedCount,
+	}
+}
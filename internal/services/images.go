@@ -0,0 +1,281 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"apify-poi-data/config"
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/models"
+	"apify-poi-data/pkg/blobstore"
+	"apify-poi-data/pkg/imaging"
+	poi_v1 "apify-poi-data/proto/apify/poi/v1"
+)
+
+// Statuses of an archived image.
+const (
+	imageStored = "stored"
+	imageFailed = "failed"
+)
+
+const imageFetchTimeout = 30 * time.Second
+
+// ImageArchive downloads the images of scraped places into a blob store,
+// together with a thumbnail and a perceptual hash of each image.
+type ImageArchive struct {
+	Store    blobstore.Store
+	Database *sqlc_db.Database
+	Client   *http.Client
+	Config   config.Images
+}
+
+// NewImageArchive creates the archive for the configured store. It returns
+// nil when archiving images is disabled.
+func NewImageArchive(cfg config.Images, db *sqlc_db.Database) (*ImageArchive, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	var store blobstore.Store
+	var err error
+	switch cfg.Store {
+	case "s3":
+		store, err = blobstore.NewS3(blobstore.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		}, nil)
+	default:
+		store, err = blobstore.NewFS(cfg.Dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ImageArchive{
+		Store:    store,
+		Database: db,
+		Client:   &http.Client{Timeout: imageFetchTimeout},
+		Config:   cfg,
+	}, nil
+}
+
+// poiImageURLs returns the image URLs of a place, main image first.
+func poiImageURLs(poi models.POI, limit int) (placeID string, urls []string) {
+	var all []string
+	switch p := poi.(type) {
+	case *models.Place:
+		placeID = p.PlaceID
+		all = []string{p.ImageURL}
+	case *models.PlaceScraper:
+		placeID = p.PlaceID
+		all = append([]string{p.ImageURL}, p.ImageUrls...)
+	default:
+		return "", nil
+	}
+	seen := map[string]bool{}
+	for _, u := range all {
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		urls = append(urls, u)
+		if len(urls) == limit {
+			break
+		}
+	}
+	return placeID, urls
+}
+
+// archivePOIImages archives the images of a place that are not archived yet.
+// It returns the first error but still tries every image.
+func (a *ImageArchive) archivePOIImages(ctx context.Context, poi models.POI) error {
+	placeID, urls := poiImageURLs(poi, a.Config.MaxPerPOI)
+	var firstErr error
+	for i, u := range urls {
+		if err := a.archiveImage(ctx, placeID, u, i); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (a *ImageArchive) archiveImage(ctx context.Context, placeID, sourceURL string, position int) error {
+	existing, err := a.Database.Queries.GetImageBySource(ctx, sqlc_db.GetImageBySourceParams{
+		PlaceID:   placeID,
+		SourceUrl: sourceURL,
+	})
+	if err == nil && existing.Status == imageStored {
+		return nil
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	params, err := a.fetchImage(ctx, placeID, sourceURL)
+	params.PlaceID = placeID
+	params.SourceUrl = sourceURL
+	params.Position = int32(position)
+	if err != nil {
+		params.Status = imageFailed
+		params.Error = pgtype.Text{String: err.Error(), Valid: true}
+	}
+	if _, upsertErr := a.Database.Queries.UpsertImage(ctx, params); upsertErr != nil {
+		return upsertErr
+	}
+	if err != nil {
+		return fmt.Errorf("archiving image %s of %s: %w", sourceURL, placeID, err)
+	}
+	return nil
+}
+
+// fetchImage downloads an image and stores it with its thumbnail.
+func (a *ImageArchive) fetchImage(ctx context.Context, placeID, sourceURL string) (sqlc_db.UpsertImageParams, error) {
+	var params sqlc_db.UpsertImageParams
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL, nil)
+	if err != nil {
+		return params, err
+	}
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return params, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return params, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, a.Config.MaxBytes+1))
+	if err != nil {
+		return params, err
+	}
+	if int64(len(data)) > a.Config.MaxBytes {
+		return params, fmt.Errorf("image is larger than %d bytes", a.Config.MaxBytes)
+	}
+
+	img, format, err := imaging.Decode(data)
+	if err != nil {
+		return params, err
+	}
+	thumb, err := imaging.EncodeJPEG(imaging.Thumbnail(img, a.Config.ThumbnailSize))
+	if err != nil {
+		return params, err
+	}
+
+	// Keys are derived from the source URL, so a re-fetch replaces the same objects.
+	sum := sha256.Sum256([]byte(sourceURL))
+	name := hex.EncodeToString(sum[:12])
+	contentType := "image/" + format
+	key := fmt.Sprintf("images/%s/%s.%s", placeID, name, format)
+	thumbKey := fmt.Sprintf("thumbnails/%s/%s.jpeg", placeID, name)
+	if err := a.Store.Put(ctx, key, bytes.NewReader(data), contentType); err != nil {
+		return params, err
+	}
+	if err := a.Store.Put(ctx, thumbKey, bytes.NewReader(thumb), "image/jpeg"); err != nil {
+		return params, err
+	}
+
+	b := img.Bounds()
+	params.Status = imageStored
+	params.BlobKey = pgtype.Text{String: key, Valid: true}
+	params.ThumbnailKey = pgtype.Text{String: thumbKey, Valid: true}
+	params.ContentType = pgtype.Text{String: contentType, Valid: true}
+	params.Width = pgtype.Int4{Int32: int32(b.Dx()), Valid: true}
+	params.Height = pgtype.Int4{Int32: int32(b.Dy()), Valid: true}
+	params.SizeBytes = pgtype.Int8{Int64: int64(len(data)), Valid: true}
+	params.Dhash = pgtype.Int8{Int64: int64(imaging.DHash(img)), Valid: true}
+	return params, nil
+}
+
+// ServeImage serves the archived original of an image, GET /v1/images/{id}.
+func (a *ImageArchive) ServeImage(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+	a.serve(w, r, pathParams["id"], false)
+}
+
+// ServeThumbnail serves the thumbnail of an image, GET /v1/images/{id}/thumbnail.
+func (a *ImageArchive) ServeThumbnail(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+	a.serve(w, r, pathParams["id"], true)
+}
+
+func (a *ImageArchive) serve(w http.ResponseWriter, r *http.Request, rawID string, thumbnail bool) {
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid image id", http.StatusBadRequest)
+		return
+	}
+	row, err := a.Database.Queries.GetImage(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && row.Status != imageStored) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	key := row.BlobKey.String
+	if thumbnail {
+		key = row.ThumbnailKey.String
+	}
+	body, info, err := a.Store.Get(r.Context(), key)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", info.ContentType)
+	if info.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	}
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	// The status is sent already; a failed copy can only be logged
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("Failed to serve image %d (%s): %v", id, key, err)
+	}
+}
+
+func (p *PoiService) ListPOIImages(ctx context.Context, in *poi_v1.ListPOIImagesRequest) (*poi_v1.ListPOIImagesResponse, error) {
+	if in.GetPlaceId() == "" {
+		return nil, fmt.Errorf("place_id is required")
+	}
+	rows, err := p.Database.Queries.ListImagesByPlace(ctx, in.GetPlaceId())
+	if err != nil {
+		return nil, err
+	}
+	resp := &poi_v1.ListPOIImagesResponse{}
+	for _, row := range rows {
+		resp.Images = append(resp.Images, &poi_v1.PoiImage{
+			Id:           row.ID,
+			PlaceId:      row.PlaceID,
+			Position:     row.Position,
+			SourceUrl:    row.SourceUrl,
+			Url:          fmt.Sprintf("/v1/images/%d", row.ID),
+			ThumbnailUrl: fmt.Sprintf("/v1/images/%d/thumbnail", row.ID),
+			ContentType:  row.ContentType.String,
+			Width:        row.Width.Int32,
+			Height:       row.Height.Int32,
+			SizeBytes:    row.SizeBytes.Int64,
+			Dhash:        fmt.Sprintf("%016x", uint64(row.Dhash.Int64)),
+			FetchedAt:    row.FetchedAt.Format(time.RFC3339),
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/models"
+	"apify-poi-data/pkg/geo"
+	"apify-poi-data/pkg/pipeline"
+	maps_v1 "apify-poi-data/proto/apify/maps/v1"
+)
+
+// Names of the ingestion stages.
+const (
+	stageDecode    = "decode"
+	stageNormalize = "normalize"
+	stageValidate  = "validate"
+	stageH3        = "h3"
+	stageWrite     = "write"
+	stageImages    = "images"
+)
+
+// ingestItem carries a single dataset item through the ingestion pipeline.
+type ingestItem struct {
+	parser     string          // Name of the parser that decodes raw
+	raw        json.RawMessage // Source JSON of the item
+	poi        models.POI      // Decoded item, set up front when the source is already decoded
+	params     sqlc_db.InsertPOIParams
+	swap       bool           // Exchange latitude and longitude before validation
+	hours      *openingHours  // Weekly schedule, nil when the item has none
+	categories *poiCategories // Taxonomy nodes, nil when no category is mapped
+}
+
+// ingestOptions controls where an ingestion run reads from and writes to.
+type ingestOptions struct {
+	sourceRunID    string   // Raw items are stored under this run when set
+	area           geo.Area // Area the search was limited to, if any
+	skipValidation bool     // Items were reviewed already
+	write          poiWriter
+}
+
+// ingest decodes, maps, validates and writes items through the ingestion pipeline,
+// archiving their images when enabled. Items with impossible coordinates are
+// quarantined instead of written.
+func (m *MapsService) ingest(ctx context.Context, items []ingestItem, opts ingestOptions) pipeline.Result {
+	stages := []pipeline.Stage[ingestItem]{
+		{
+			Name:    stageDecode,
+			Workers: m.Ingest.DecodeWorkers,
+			Run:     decodeIngestItem,
+		},
+		{
+			Name:    stageNormalize,
+			Workers: m.Ingest.NormalizeWorkers,
+			Run: func(ctx context.Context, it *ingestItem) error {
+				params, err := mapPOIParams(it.poi)
+				if err != nil {
+					return err
+				}
+				normalizePOIParams(&params)
+				it.params = params
+
+				// Items with hours that cannot be parsed are still written, without a schedule.
+				hours, err := parseOpeningHours(it.poi)
+				if err != nil {
+					log.Printf("Failed to parse opening hours of %s: %v", it.poi.GetID(), err)
+				}
+				it.hours = hours
+				it.categories = categorizePOI(it.poi)
+				return nil
+			},
+		},
+		{
+			Name:    stageValidate,
+			Workers: m.Ingest.NormalizeWorkers,
+			Run: func(ctx context.Context, it *ingestItem) error {
+				return m.validateIngestItem(ctx, it, opts)
+			},
+		},
+		{
+			Name:    stageH3,
+			Workers: m.Ingest.H3Workers,
+			Run: func(ctx context.Context, it *ingestItem) error {
+				return setH3Index(&it.params)
+			},
+		},
+		{
+			Name:    stageWrite,
+			Workers: m.Ingest.WriteWorkers,
+			Run: func(ctx context.Context, it *ingestItem) error {
+				if opts.sourceRunID != "" {
+					if err := m.storeRawItem(ctx, it.parser, opts.sourceRunID, it.poi, it.raw); err != nil {
+						log.Printf("Failed to store raw item: %v", err)
+					}
+				}
+				if err := opts.write(ctx, it.params); err != nil {
+					return err
+				}
+				m.storePOIDetails(ctx, it)
+				return nil
+			},
+		},
+	}
+	if m.Images != nil {
+		stages = append(stages, pipeline.Stage[ingestItem]{
+			Name:    stageImages,
+			Workers: m.Images.Config.Workers,
+			Run: func(ctx context.Context, it *ingestItem) error {
+				// The POI is written already; images that fail are retried on the next ingest.
+				if err := m.Images.archivePOIImages(ctx, it.poi); err != nil {
+					log.Printf("Failed to archive images: %v", err)
+				}
+				return nil
+			},
+		})
+	}
+
+	res := pipeline.New(m.Ingest.Buffer, stages...).Run(ctx, items)
+	logIngestStats(res)
+	return res
+}
+
+// storePOIDetails writes the reviews, popular times, attribute keys, opening
+// hours and taxonomy categories of a written POI. Failures are logged; the POI itself is already stored.
+func (m *MapsService) storePOIDetails(ctx context.Context, it *ingestItem) {
+	lat, lng := it.params.LocationLat.Float64, it.params.LocationLng.Float64
+	if err := m.storeReviews(ctx, it.poi); err != nil {
+		log.Printf("Failed to store reviews: %v", err)
+	}
+	if err := m.storePopularTimes(ctx, it.poi, lat, lng); err != nil {
+		log.Printf("Failed to store popular times: %v", err)
+	}
+	if err := m.storeAttributes(ctx, it.poi); err != nil {
+		log.Printf("Failed to store attributes: %v", err)
+	}
+	if it.hours != nil {
+		if err := m.storeOpeningHours(ctx, it.hours, lat, lng); err != nil {
+			log.Printf("Failed to store opening hours: %v", err)
+		}
+	}
+	if it.categories != nil {
+		if err := m.storePOICategories(ctx, it.categories); err != nil {
+			log.Printf("Failed to store categories: %v", err)
+		}
+	}
+}
+
+// decodeIngestItem decodes the raw JSON of items that are not decoded yet.
+func decodeIngestItem(ctx context.Context, it *ingestItem) error {
+	if it.poi != nil {
+		return nil
+	}
+	parser, ok := models.DefaultRegistry.Lookup(it.parser)
+	if !ok {
+		return fmt.Errorf("unknown parser: %s", it.parser)
+	}
+	poi, repaired, err := parser.Decode(it.raw, models.ParseLenient)
+	if err != nil {
+		return err
+	}
+	if poi == nil {
+		return pipeline.ErrSkip
+	}
+	if len(repaired) > 0 {
+		log.Printf("Decoded POI %s after repair: repaired fields: %s", poi.GetID(), strings.Join(repaired, ", "))
+	}
+	it.poi = poi
+	return nil
+}
+
+// validateIngestItem quarantines items whose coordinates cannot be right or lie
+// far outside the area that was searched.
+func (m *MapsService) validateIngestItem(ctx context.Context, it *ingestItem, opts ingestOptions) error {
+	if it.swap {
+		it.params.LocationLat, it.params.LocationLng = it.params.LocationLng, it.params.LocationLat
+	}
+	if opts.skipValidation {
+		return nil
+	}
+
+	p := geo.Point{Lat: it.params.LocationLat.Float64, Lng: it.params.LocationLng.Float64}
+	issue := geo.Check(p)
+	var details string
+	if code := poiPlusCode(it.poi); code != "" && (issue == geo.IssueNone || issue == geo.IssueNullIsland) {
+		issue, details = checkPlusCode(&it.params, code, opts.area)
+		p = geo.Point{Lat: it.params.LocationLat.Float64, Lng: it.params.LocationLng.Float64}
+	}
+	if issue == geo.IssueNone {
+		issue = opts.area.Check(p, m.Ingest.SearchAreaToleranceKm)
+		if issue == geo.IssueOutsideArea {
+			details = fmt.Sprintf("%.1f km outside the search area", opts.area.DistanceKm(p))
+		}
+	}
+	if issue == geo.IssueNone {
+		return nil
+	}
+
+	params := sqlc_db.InsertQuarantinedPOIParams{
+		ItemID:      it.poi.GetID(),
+		Parser:      it.parser,
+		SourceRunID: opts.sourceRunID,
+		Reason:      string(issue),
+		Details: pgtype.Text{
+			String: details,
+			Valid:  details != "",
+		},
+		LocationLat: it.params.LocationLat,
+		LocationLng: it.params.LocationLng,
+		Item:        it.raw,
+	}
+	if err := m.Database.Queries.InsertQuarantinedPOI(ctx, params); err != nil {
+		log.Printf("Failed to quarantine POI %s: %v", it.poi.GetID(), err)
+	}
+	return fmt.Errorf("quarantined: %s (lat=%f, lng=%f)", issue, p.Lat, p.Lng)
+}
+
+// customGeolocationArea converts the polygons a search was limited to.
+func customGeolocationArea(g *maps_v1.CustomGeolocation) geo.Area {
+	var area geo.Area
+	for _, polygon := range g.GetPolygons() {
+		var ring geo.Polygon
+		for _, c := range polygon.GetCoordinates() {
+			ring = append(ring, geo.Point{Lat: float64(c.GetLatitude()), Lng: float64(c.GetLongitude())})
+		}
+		if len(ring) > 2 {
+			area = append(area, ring)
+		}
+	}
+	return area
+}
+
+func logIngestStats(res pipeline.Result) {
+	fmt.Printf("Ingested %d items in %s; failed=%d\n", res.Completed, res.Elapsed, len(res.Errors))
+	for _, s := range res.Stats {
+		fmt.Printf("  stage=%s workers=%d items=%d failed=%d skipped=%d busy=%s mean=%s max=%s\n",
+			s.Name, s.Workers, s.Items, s.Failed, s.Skipped, s.Busy, s.Mean(), s.Max)
+	}
+	if res.Err != nil {
+		log.Printf("Ingestion stopped early: %v", res.Err)
+	}
+}
+
+// pipelineErrorsToProto converts the per-item errors of an ingestion run.
+func pipelineErrorsToProto(errs []pipeline.ItemError) []*maps_v1.ItemError {
+	out := make([]*maps_v1.ItemError, 0, len(errs))
+	for _, e := range errs {
+		log.Printf("Skipping POI: %v", e)
+		out = append(out, &maps_v1.ItemError{
+			Index:   int32(e.Index),
+			Stage:   e.Stage,
+			Message: e.Err.Error(),
+		})
+	}
+	return out
+}
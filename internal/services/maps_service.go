@@ -7,15 +7,17 @@ import (
 	"log"
 	"time"
 
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/uber/h3-go/v4"
+	"golang.org/x/sync/singleflight"
 
+	"apify-poi-data/config"
 	sqlc_db "apify-poi-data/db/sqlc"
 	"apify-poi-data/internal/models"
 	"apify-poi-data/internal/services/converter"
 	"apify-poi-data/pkg/apify"
+	"apify-poi-data/pkg/geo"
+	"apify-poi-data/pkg/pipeline"
 	maps_v1 "apify-poi-data/proto/apify/maps/v1"
 )
 
@@ -23,84 +25,62 @@ type MapsService struct {
 	maps_v1.UnimplementedMapsServiceServer
 	ApifyClient *apify.Client
 	Database    *sqlc_db.Database
+	Ingest      config.Ingest
+	Images      *ImageArchive // nil when images are not archived
+	Changes     config.Changes
+
+	imports singleflight.Group // in-flight dataset imports, keyed by dataset ID
 }
 
-func (m *MapsService) castPOIToPlace(pois []models.POI) []models.Place {
-	places := make([]models.Place, 0, len(pois))
-	for _, poi := range pois {
-		switch p := poi.(type) {
-		case *models.Place:
-			places = append(places, *p)
-		default:
-			fmt.Printf("Skipping non-Place POI: %s\n", poi.GetType())
-		}
+// poiWriter persists the mapped parameters of a single POI.
+type poiWriter func(ctx context.Context, params sqlc_db.InsertPOIParams) error
+
+// mapPOIParams maps a decoded POI to its row.
+func mapPOIParams(poi models.POI) (sqlc_db.InsertPOIParams, error) {
+	switch p := poi.(type) {
+	case *models.Place:
+		return googlePlaceParams(*p), nil
+	case *models.PlaceScraper:
+		return googlePlaceScraperParams(*p), nil
+	default:
+		return sqlc_db.InsertPOIParams{}, fmt.Errorf("unsupported POI type: %s", poi.GetType())
 	}
-	return places
 }
 
-func (m *MapsService) castPOIToPlaceScraper(pois []models.POI) []models.PlaceScraper {
-	places := make([]models.PlaceScraper, 0, len(pois))
-	for _, poi := range pois {
-		switch p := poi.(type) {
-		case *models.PlaceScraper:
-			places = append(places, *p)
-		default:
-			fmt.Printf("Skipping non-Place POI: %s, Type: %s\n", poi.GetType(), p)
-		}
+// setH3Index fills in the H3 cell of a row from its location.
+func setH3Index(params *sqlc_db.InsertPOIParams) error {
+	latLng := h3.LatLng{Lat: params.LocationLat.Float64, Lng: params.LocationLng.Float64}
+	cell, err := h3.LatLngToCell(latLng, DATABASE_RESOLUTION)
+	if err != nil {
+		return fmt.Errorf("failed to get H3Index: %w", err)
+	}
+	params.H3Index = pgtype.Text{
+		String: cell.String(),
+		Valid:  true,
 	}
-	return places
+	return nil
 }
 
-func (m *MapsService) processPOIGooglePlace(ctx context.Context, poi models.Place) error {
+func googlePlaceParams(poi models.Place) sqlc_db.InsertPOIParams {
 	poiParams := sqlc_db.InsertPOIParams{
-		SearchString: pgtype.Text{
-			String: poi.SearchString,
-			Valid:  true,
-		},
+		SearchString: textFromString(poi.SearchString),
 		Rank: pgtype.Int4{
 			Int32: int32(poi.Rank),
 			Valid: true,
 		},
-		SearchPageUrl: pgtype.Text{
-			String: poi.SearchPageURL,
-			Valid:  true,
-		},
+		SearchPageUrl: textFromString(poi.SearchPageURL),
 		IsAdvertisement: pgtype.Bool{
 			Bool:  poi.IsAdvertisement,
 			Valid: true,
 		},
-		Title: pgtype.Text{
-			String: poi.Title,
-			Valid:  true,
-		},
-		SubTitle: pgtype.Text{
-			String: poi.SubTitle,
-			Valid:  true,
-		},
-		CategoryName: pgtype.Text{
-			String: poi.CategoryName,
-			Valid:  true,
-		},
-		Address: pgtype.Text{
-			String: poi.Address,
-			Valid:  true,
-		},
-		Street: pgtype.Text{
-			String: poi.Street,
-			Valid:  true,
-		},
-		City: pgtype.Text{
-			String: poi.City,
-			Valid:  true,
-		},
-		PostalCode: pgtype.Text{
-			String: poi.PostalCode,
-			Valid:  true,
-		},
-		CountryCode: pgtype.Text{
-			String: poi.CountryCode,
-			Valid:  true,
-		},
+		Title:        textFromString(poi.Title),
+		SubTitle:     textFromString(poi.SubTitle),
+		CategoryName: textFromString(poi.CategoryName),
+		Address:      textFromString(poi.Address),
+		Street:       textFromString(poi.Street),
+		City:         textFromString(poi.City),
+		PostalCode:   textFromString(poi.PostalCode),
+		CountryCode:  textFromString(poi.CountryCode),
 		ClaimThisBusiness: pgtype.Bool{
 			Bool:  poi.ClaimThisBusiness,
 			Valid: true,
@@ -113,10 +93,7 @@ func (m *MapsService) processPOIGooglePlace(ctx context.Context, poi models.Plac
 			Float64: poi.Location.Lng,
 			Valid:   true,
 		},
-		TotalScore: pgtype.Float8{
-			Float64: poi.TotalScore,
-			Valid:   true,
-		},
+		TotalScore: float8FromPtr(poi.TotalScore),
 		PermanentlyClosed: pgtype.Bool{
 			Bool:  poi.PermanentlyClosed,
 			Valid: true,
@@ -129,23 +106,11 @@ func (m *MapsService) processPOIGooglePlace(ctx context.Context, poi models.Plac
 			String: poi.PlaceID,
 			Valid:  true,
 		},
-		Categories: poi.Categories,
-		Fid: pgtype.Text{
-			String: poi.FID,
-			Valid:  true,
-		},
-		Cid: pgtype.Text{
-			String: poi.CID,
-			Valid:  true,
-		},
-		ReviewsCount: pgtype.Int4{
-			Int32: int32(poi.ReviewsCount),
-			Valid: true,
-		},
-		ImagesCount: pgtype.Int4{
-			Int32: int32(poi.ImagesCount),
-			Valid: true,
-		},
+		Categories:       poi.Categories,
+		Fid:              textFromString(poi.FID),
+		Cid:              textFromString(poi.CID),
+		ReviewsCount:     int4FromPtr(poi.ReviewsCount),
+		ImagesCount:      int4FromPtr(poi.ImagesCount),
 		ImageCategories:  poi.ImageCategories,
 		HotelAds:         poi.HotelAds,
 		OpeningHours:     poi.OpeningHours,
@@ -154,55 +119,16 @@ func (m *MapsService) processPOIGooglePlace(ctx context.Context, poi models.Plac
 		ReviewsTags:      poi.ReviewsTags,
 		AdditionalInfo:   poi.AdditionalInfo,
 		GasPrices:        poi.GasPrices,
-		Url: pgtype.Text{
-			String: poi.URL,
-			Valid:  true,
-		},
-		ImageUrl: pgtype.Text{
-			String: poi.ImageURL,
-			Valid:  true,
-		},
-		Kgmid: pgtype.Text{
-			String: poi.Kgmid,
-			Valid:  true,
-		},
-		Neighborhood: pgtype.Text{
-			String: models.ValueOrEmpty(poi.Neighborhood),
-			Valid:  true,
-		},
-		Price: pgtype.Text{
-			String: models.ValueOrEmpty(poi.Price),
-			Valid:  true,
-		},
-		State: pgtype.Text{
-			String: models.ValueOrEmpty(poi.State),
-			Valid:  true,
-		},
-		Phone: pgtype.Text{
-			String: models.ValueOrEmpty(poi.Phone),
-			Valid:  true,
-		},
-		Website: pgtype.Text{
-			String: models.ValueOrEmpty(poi.Website),
-			Valid:  true,
-		},
-		PhoneUnformatted: pgtype.Text{
-			String: models.ValueOrEmpty(poi.PhoneUnformatted),
-			Valid:  true,
-		},
-		GoogleFoodUrl: pgtype.Text{
-			String: models.ValueOrEmpty(poi.GoogleFoodURL),
-			Valid:  false,
-		},
-	}
-
-	if cell, err := h3.LatLngToCell(h3.LatLng{Lat: poi.Location.Lat, Lng: poi.Location.Lng}, DATABASE_RESOLUTION); err != nil {
-		log.Printf("Failed to get H3Index: %v", err)
-	} else {
-		poiParams.H3Index = pgtype.Text{
-			String: cell.String(),
-			Valid:  true,
-		}
+		Url:              textFromString(poi.URL),
+		ImageUrl:         textFromString(poi.ImageURL),
+		Kgmid:            textFromString(poi.Kgmid),
+		Neighborhood:     textFromPtr(poi.Neighborhood),
+		Price:            textFromPtr(poi.Price),
+		State:            textFromPtr(poi.State),
+		Phone:            textFromPtr(poi.Phone),
+		Website:          textFromPtr(poi.Website),
+		PhoneUnformatted: textFromPtr(poi.PhoneUnformatted),
+		GoogleFoodUrl:    textFromPtr(poi.GoogleFoodURL),
 	}
 
 	// Convert to proto
@@ -215,64 +141,29 @@ func (m *MapsService) processPOIGooglePlace(ctx context.Context, poi models.Plac
 		Time:  t,
 		Valid: true,
 	}
-	// Send to client
-	err = m.insertPOItoDB(ctx, poiParams)
-	if err != nil {
-		return err
-	}
-	return nil
+	return poiParams
 }
 
-func (m *MapsService) processPOIGooglePlaceScraper(ctx context.Context, poi models.PlaceScraper) error {
+func googlePlaceScraperParams(poi models.PlaceScraper) sqlc_db.InsertPOIParams {
 	poiParams := sqlc_db.InsertPOIParams{
-		SearchString: pgtype.Text{
-			String: poi.SearchString,
-			Valid:  true,
-		},
+		SearchString: textFromString(poi.SearchString),
 		Rank: pgtype.Int4{
 			Int32: int32(poi.Rank),
 			Valid: true,
 		},
-		SearchPageUrl: pgtype.Text{
-			String: poi.SearchPageURL,
-			Valid:  true,
-		},
+		SearchPageUrl: textFromString(poi.SearchPageURL),
 		IsAdvertisement: pgtype.Bool{
 			Bool:  poi.IsAdvertisement,
 			Valid: true,
 		},
-		Title: pgtype.Text{
-			String: poi.Title,
-			Valid:  true,
-		},
-		SubTitle: pgtype.Text{
-			String: models.ValueOrEmpty(poi.SubTitle),
-			Valid:  true,
-		},
-		CategoryName: pgtype.Text{
-			String: poi.CategoryName,
-			Valid:  true,
-		},
-		Address: pgtype.Text{
-			String: poi.Address,
-			Valid:  true,
-		},
-		Street: pgtype.Text{
-			String: poi.Street,
-			Valid:  true,
-		},
-		City: pgtype.Text{
-			String: poi.City,
-			Valid:  true,
-		},
-		PostalCode: pgtype.Text{
-			String: poi.PostalCode,
-			Valid:  true,
-		},
-		CountryCode: pgtype.Text{
-			String: poi.CountryCode,
-			Valid:  true,
-		},
+		Title:        textFromString(poi.Title),
+		SubTitle:     textFromPtr(poi.SubTitle),
+		CategoryName: textFromString(poi.CategoryName),
+		Address:      textFromString(poi.Address),
+		Street:       textFromString(poi.Street),
+		City:         textFromString(poi.City),
+		PostalCode:   textFromString(poi.PostalCode),
+		CountryCode:  textFromString(poi.CountryCode),
 		ClaimThisBusiness: pgtype.Bool{
 			Bool:  poi.ClaimThisBusiness,
 			Valid: true,
@@ -285,10 +176,7 @@ func (m *MapsService) processPOIGooglePlaceScraper(ctx context.Context, poi mode
 			Float64: poi.Location.Lng,
 			Valid:   true,
 		},
-		TotalScore: pgtype.Float8{
-			Float64: poi.TotalScore,
-			Valid:   true,
-		},
+		TotalScore: float8FromPtr(poi.TotalScore),
 		PermanentlyClosed: pgtype.Bool{
 			Bool:  poi.PermanentlyClosed,
 			Valid: true,
@@ -301,149 +189,56 @@ func (m *MapsService) processPOIGooglePlaceScraper(ctx context.Context, poi mode
 			String: poi.PlaceID,
 			Valid:  true,
 		},
-		Categories: poi.Categories,
-		Fid: pgtype.Text{
-			String: poi.FID,
-			Valid:  true,
-		},
-		Cid: pgtype.Text{
-			String: poi.CID,
-			Valid:  true,
-		},
-		ReviewsCount: pgtype.Int4{
-			Int32: int32(poi.ReviewsCount),
-			Valid: true,
-		},
-		ImagesCount: pgtype.Int4{
-			Int32: int32(poi.ImagesCount),
-			Valid: true,
-		},
-		ImageCategories:  poi.ImageCategories,
-		HotelAds:         poi.HotelAds,
-		OpeningHours:     poi.OpeningHours,
-		PeopleAlsoSearch: poi.PeopleAlsoSearch,
-		PlacesTags:       poi.PlacesTags,
-		ReviewsTags:      poi.ReviewsTags,
-		AdditionalInfo:   poi.AdditionalInfo,
-		GasPrices:        poi.GasPrices,
-		Url: pgtype.Text{
-			String: poi.URL,
-			Valid:  true,
-		},
-		ImageUrl: pgtype.Text{
-			String: poi.ImageURL,
-			Valid:  true,
-		},
-		Kgmid: pgtype.Text{
-			String: poi.Kgmid,
-			Valid:  true,
-		},
-		Neighborhood: pgtype.Text{
-			String: models.ValueOrEmpty(poi.Neighborhood),
-			Valid:  true,
-		},
-		Price: pgtype.Text{
-			String: models.ValueOrEmpty(poi.Price),
-			Valid:  true,
-		},
-		State: pgtype.Text{
-			String: models.ValueOrEmpty(poi.State),
-			Valid:  true,
-		},
-		Phone: pgtype.Text{
-			String: models.ValueOrEmpty(poi.Phone),
-			Valid:  true,
-		},
-		Website: pgtype.Text{
-			String: models.ValueOrEmpty(poi.Website),
-			Valid:  true,
-		},
-		PhoneUnformatted: pgtype.Text{
-			String: models.ValueOrEmpty(poi.PhoneUnformatted),
-			Valid:  true,
-		},
-		GoogleFoodUrl: pgtype.Text{
-			String: models.ValueOrEmpty(poi.GoogleFoodURL),
-			Valid:  false,
-		},
-		SearchPageLoadedUrl: pgtype.Text{
-			String: models.ValueOrEmpty(poi.SearchPageLoadedURL),
-			Valid:  true,
-		},
-		Description: pgtype.Text{
-			String: models.ValueOrEmpty(poi.Description),
-			Valid:  true,
-		},
-		LocatedIn: pgtype.Text{
-			String: models.ValueOrEmpty(poi.LocatedIn),
-			Valid:  true,
-		},
-		PlusCode: pgtype.Text{
-			String: models.ValueOrEmpty(poi.PlusCode),
-			Valid:  true,
-		},
-		Menu: pgtype.Text{
-			String: models.ValueOrEmpty(poi.Menu),
-			Valid:  true,
-		},
-		ReserveTableUrl: pgtype.Text{
-			String: models.ValueOrEmpty(poi.ReserveTableURL),
-			Valid:  true,
-		},
-		HotelStars: pgtype.Text{
-			String: models.ValueOrEmpty(poi.HotelStars),
-			Valid:  true,
-		},
-		HotelDescription: pgtype.Text{
-			String: models.ValueOrEmpty(poi.HotelDescription),
-			Valid:  true,
-		},
-		CheckInDate: pgtype.Text{
-			String: models.ValueOrEmpty(poi.CheckInDate),
-			Valid:  true,
-		},
-		CheckOutDate: pgtype.Text{
-			String: models.ValueOrEmpty(poi.CheckOutDate),
-			Valid:  true,
-		},
-		SimilarHotelsNearby: poi.SimilarHotelsNearby,
-		HotelReviewSummary:  poi.HotelReviewSummary,
-		PopularTimesLiveText: pgtype.Text{
-			String: models.ValueOrEmpty(poi.PopularTimesLiveText),
-			Valid:  true,
-		},
-		PopularTimesLivePercent: pgtype.Int4{
-			Int32: int32(models.ValueOrZero(poi.PopularTimesLivePercent)),
-			Valid: true,
-		},
-		QuestionsAndAnswers:  poi.QuestionsAndAnswers,
-		UpdatesFromCustomers: poi.UpdatesFromCustomers,
-		WebResults:           poi.WebResults,
-		ParentPlaceUrl: pgtype.Text{
-			String: models.ValueOrEmpty(poi.ParentPlaceURL),
-			Valid:  true,
-		},
-		TableReservationLinks: poi.TableReservationLinks,
-		BookingLinks:          poi.BookingLinks,
-		OrderBy:               poi.OrderBy,
-		Images: pgtype.Text{
-			String: models.ValueOrEmpty(poi.Images),
-			Valid:  true,
-		},
-		ImageUrls:      poi.ImageUrls,
-		Reviews:        poi.Reviews,
-		UserPlaceNote:  poi.UserPlaceNote,
-		RestaurantData: poi.RestaurantData,
-		OwnerUpdates:   poi.OwnerUpdates,
-	}
-
-	if cell, err := h3.LatLngToCell(h3.LatLng{Lat: poi.Location.Lat, Lng: poi.Location.Lng}, DATABASE_RESOLUTION); err != nil {
-		log.Printf("Failed to get H3Index: %v", err)
-	} else {
-		poiParams.H3Index = pgtype.Text{
-			String: cell.String(),
-			Valid:  true,
-		}
+		Categories:              poi.Categories,
+		Fid:                     textFromString(poi.FID),
+		Cid:                     textFromString(poi.CID),
+		ReviewsCount:            int4FromPtr(poi.ReviewsCount),
+		ImagesCount:             int4FromPtr(poi.ImagesCount),
+		ImageCategories:         poi.ImageCategories,
+		HotelAds:                poi.HotelAds,
+		OpeningHours:            poi.OpeningHours,
+		PeopleAlsoSearch:        poi.PeopleAlsoSearch,
+		PlacesTags:              poi.PlacesTags,
+		ReviewsTags:             poi.ReviewsTags,
+		AdditionalInfo:          poi.AdditionalInfo,
+		GasPrices:               poi.GasPrices,
+		Url:                     textFromString(poi.URL),
+		ImageUrl:                textFromString(poi.ImageURL),
+		Kgmid:                   textFromString(poi.Kgmid),
+		Neighborhood:            textFromPtr(poi.Neighborhood),
+		Price:                   textFromPtr(poi.Price),
+		State:                   textFromPtr(poi.State),
+		Phone:                   textFromPtr(poi.Phone),
+		Website:                 textFromPtr(poi.Website),
+		PhoneUnformatted:        textFromPtr(poi.PhoneUnformatted),
+		GoogleFoodUrl:           textFromPtr(poi.GoogleFoodURL),
+		SearchPageLoadedUrl:     textFromPtr(poi.SearchPageLoadedURL),
+		Description:             textFromPtr(poi.Description),
+		LocatedIn:               textFromPtr(poi.LocatedIn),
+		PlusCode:                textFromPtr(poi.PlusCode),
+		Menu:                    textFromPtr(poi.Menu),
+		ReserveTableUrl:         textFromPtr(poi.ReserveTableURL),
+		HotelStars:              textFromPtr(poi.HotelStars),
+		HotelDescription:        textFromPtr(poi.HotelDescription),
+		CheckInDate:             textFromPtr(poi.CheckInDate),
+		CheckOutDate:            textFromPtr(poi.CheckOutDate),
+		SimilarHotelsNearby:     poi.SimilarHotelsNearby,
+		HotelReviewSummary:      poi.HotelReviewSummary,
+		PopularTimesLiveText:    textFromPtr(poi.PopularTimesLiveText),
+		PopularTimesLivePercent: int4FromPtr(poi.PopularTimesLivePercent),
+		QuestionsAndAnswers:     poi.QuestionsAndAnswers,
+		UpdatesFromCustomers:    poi.UpdatesFromCustomers,
+		WebResults:              poi.WebResults,
+		ParentPlaceUrl:          textFromPtr(poi.ParentPlaceURL),
+		TableReservationLinks:   poi.TableReservationLinks,
+		BookingLinks:            poi.BookingLinks,
+		OrderBy:                 poi.OrderBy,
+		Images:                  textFromPtr(poi.Images),
+		ImageUrls:               poi.ImageUrls,
+		Reviews:                 poi.Reviews,
+		UserPlaceNote:           poi.UserPlaceNote,
+		RestaurantData:          poi.RestaurantData,
+		OwnerUpdates:            poi.OwnerUpdates,
 	}
 
 	if poi.PopularTimesHistogram != nil {
@@ -464,73 +259,104 @@ func (m *MapsService) processPOIGooglePlaceScraper(ctx context.Context, poi mode
 		Time:  t,
 		Valid: true,
 	}
-	// Send to client
-	err = m.insertPOItoDB(ctx, poiParams)
-	if err != nil {
-		return err
-	}
-	return nil
+	return poiParams
 }
 
-func (m *MapsService) insertPOItoDB(ctx context.Context, params sqlc_db.InsertPOIParams) error {
-	_, err := m.Database.Queries.InsertPOI(ctx, params)
+func (m *MapsService) upsertPOItoDB(ctx context.Context, params sqlc_db.InsertPOIParams) error {
+	err := m.writePOI(ctx, params, func(q *sqlc_db.Queries) error {
+		_, err := q.UpsertPOI(ctx, sqlc_db.UpsertPOIParams(params))
+		return err
+	})
 	if err != nil {
-		// Check if the error is a unique constraint violation
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
-			log.Printf("POI already exists: %v", err)
-			return nil // Skip to the next POI
-		}
-		// Check if the error is sql.ErrNoRows
-		if err == pgx.ErrNoRows {
-			log.Printf("No rows in result set: %v", err)
-			return nil // Skip to the next POI
-		}
-		log.Printf("Failed to insert POI: %v", err)
+		log.Printf("Failed to upsert POI: %v", err)
 		return err
 	}
 	return nil
 }
 
-func (m *MapsService) handleGoogleMapsScraper(ctx context.Context, pois []models.PlaceScraper) {
-	for _, poi := range pois {
-		if err := m.processPOIGooglePlaceScraper(ctx, poi); err != nil {
-			log.Printf("Failed to process POI: %v", err)
+// upsertPOIInTx returns a writer that upserts places in the transaction of q,
+// for callers that commit other changes with them.
+func (m *MapsService) upsertPOIInTx(q *sqlc_db.Queries) poiWriter {
+	return func(ctx context.Context, params sqlc_db.InsertPOIParams) error {
+		return m.writePOIInTx(ctx, q, params, func(q *sqlc_db.Queries) error {
+			_, err := q.UpsertPOI(ctx, sqlc_db.UpsertPOIParams(params))
+			return err
+		})
+	}
+}
 
+// ingestParseResult keeps the raw item of every decoded POI and writes the
+// POIs. The indexes of the item errors are positions in the dataset.
+func (m *MapsService) ingestParseResult(ctx context.Context, res models.ParseResult, sourceRunID string, area geo.Area, write poiWriter) pipeline.Result {
+	items := make([]ingestItem, len(res.POIs))
+	for i, poi := range res.POIs {
+		items[i] = ingestItem{
+			parser: res.Parser,
+			raw:    res.Raw[i],
+			poi:    poi,
 		}
 	}
+	out := m.ingest(ctx, items, ingestOptions{
+		sourceRunID: sourceRunID,
+		area:        area,
+		write:       write,
+	})
+	for i := range out.Errors {
+		out.Errors[i].Index = res.Indexes[out.Errors[i].Index]
+	}
+	return out
 }
 
-func (m *MapsService) handleGoogleMapsExtractor(ctx context.Context, pois []models.Place) {
-	for _, poi := range pois {
-		if err := m.processPOIGooglePlace(ctx, poi); err != nil {
-			log.Printf("Failed to process POI: %v", err)
-		}
+// searchResponse reports what happened to every item of a search run.
+func searchResponse(data models.ParseResult, runID string, res pipeline.Result) *maps_v1.SearchResponse {
+	return &maps_v1.SearchResponse{
+		Status:     "success",
+		RunId:      runID,
+		Items:      int32(len(data.POIs) + len(data.Errors)),
+		Inserted:   int32(res.Completed),
+		Failed:     int32(len(data.Errors) + len(res.Errors)),
+		ItemErrors: append(itemErrorsToProto(data), pipelineErrorsToProto(res.Errors)...),
 	}
 }
 
-func (m *MapsService) InsertApifyDatasetItems(ctx context.Context, in *maps_v1.DatasetItemsRequest) (*maps_v1.DatasetItemsResponse, error) {
-	data, err := m.ApifyClient.GetDataset(in.GetDatasetId())
-	if err != nil {
-		return nil, err
+// itemErrorsToProto logs the parse warnings and converts the per-item errors of a parse result.
+func itemErrorsToProto(res models.ParseResult) []*maps_v1.ItemError {
+	for _, w := range res.Warnings {
+		log.Printf("Decoded POI after repair: %v", w)
 	}
-	pois, err := models.ParsePOIsFromJSON(data)
-	if err != nil {
-		return nil, err
+	out := make([]*maps_v1.ItemError, 0, len(res.Errors))
+	for _, e := range res.Errors {
+		log.Printf("Skipping POI: %v", e)
+		out = append(out, &maps_v1.ItemError{
+			Index:   int32(e.Index),
+			Stage:   stageDecode,
+			Message: e.Err.Error(),
+		})
 	}
-	switch in.GetDatasetType() {
-	case maps_v1.DatasetItemsRequest_GOOGLE_MAPS_EXTRACTOR:
-		fmt.Println("Data received inside SearchGoogleMaps")
-		d := m.castPOIToPlace(pois)
-		m.handleGoogleMapsExtractor(ctx, d)
-	case maps_v1.DatasetItemsRequest_GOOGLE_MAPS_SCRAPER:
-		fmt.Println("Data received inside SearchGoogleMapsScraper")
-		d := m.castPOIToPlaceScraper(pois)
-		m.handleGoogleMapsScraper(ctx, d)
+	return out
+}
 
+// datasetTypeParsers maps the requested dataset type to the parser registered for it.
+var datasetTypeParsers = map[maps_v1.DatasetItemsRequest_DatasetType]string{
+	maps_v1.DatasetItemsRequest_GOOGLE_MAPS_EXTRACTOR: models.ParserGoogleMapsExtractor,
+	maps_v1.DatasetItemsRequest_GOOGLE_MAPS_SCRAPER:   models.ParserGoogleMapsScraper,
+	maps_v1.DatasetItemsRequest_AUTO:                  models.ParserAuto,
+}
+
+func (m *MapsService) InsertApifyDatasetItems(ctx context.Context, in *maps_v1.DatasetItemsRequest) (*maps_v1.DatasetItemsResponse, error) {
+	parser, ok := datasetTypeParsers[in.GetDatasetType()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported dataset type: %s", in.GetDatasetType())
 	}
-	return &maps_v1.DatasetItemsResponse{
-		Status: "success",
-	}, nil
+	if in.ActorId != nil {
+		p, ok := models.DefaultRegistry.Lookup(in.GetActorId())
+		if !ok {
+			return nil, fmt.Errorf("no parser registered for actor: %s", in.GetActorId())
+		}
+		parser = p.Name()
+	}
+
+	return m.importDataset(ctx, in.GetDatasetId(), parser, in.GetForce())
 }
 
 func (m *MapsService) SearchGoogleMapsExtractor(ctx context.Context, in *maps_v1.SearchRequest) (*maps_v1.SearchResponse, error) {
@@ -545,11 +371,8 @@ func (m *MapsService) SearchGoogleMapsExtractor(ctx context.Context, in *maps_v1
 	select {
 	case data := <-resp.Data:
 		fmt.Println("Data received inside SearchGoogleMaps")
-		d := m.castPOIToPlace(data)
-		m.handleGoogleMapsExtractor(ctx, d)
-		return &maps_v1.SearchResponse{
-			Status: "success",
-		}, nil
+		res := m.ingestParseResult(ctx, data, resp.RunID, customGeolocationArea(in.GetCustomGeolocation()), m.upsertPOItoDB)
+		return searchResponse(data, resp.RunID, res), nil
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	case err := <-resp.Err:
@@ -569,11 +392,8 @@ func (m *MapsService) SearchGoogleMapsScraper(ctx context.Context, request *maps
 	select {
 	case data := <-resp.Data:
 		fmt.Println("Data received inside SearchGoogleMapsScraper")
-		d := m.castPOIToPlaceScraper(data)
-		m.handleGoogleMapsScraper(ctx, d)
-		return &maps_v1.SearchResponse{
-			Status: "success",
-		}, nil
+		res := m.ingestParseResult(ctx, data, resp.RunID, customGeolocationArea(request.GetCustomGeolocation()), m.upsertPOItoDB)
+		return searchResponse(data, resp.RunID, res), nil
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	case err := <-resp.Err:
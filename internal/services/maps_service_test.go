@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"apify-poi-data/config"
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/pkg/apify"
+	"apify-poi-data/pkg/changes"
+	maps_v1 "apify-poi-data/proto/apify/maps/v1"
+)
+
+// testDatabase connects to the database in TEST_DATABASE_URL, which must be
+// migrated to the current version. Tests that need it are skipped without it.
+func testDatabase(t *testing.T) *sqlc_db.Database {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sqlc_db.NewDatabase(context.Background(), dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+// fakeApify answers the run, poll and dataset requests of the client with the
+// current dataset. Every request is routed to it, whatever its host.
+type fakeApify struct {
+	dataset string
+	runs    int
+}
+
+func (f *fakeApify) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/v2/actor-tasks/"):
+		f.runs++
+		fmt.Fprintf(w, `{"data":{"id":"run-%d"}}`, f.runs)
+	case strings.HasSuffix(r.URL.Path, "/dataset/items"):
+		fmt.Fprint(w, f.dataset)
+	case strings.HasPrefix(r.URL.Path, "/v2/actor-runs/"):
+		id := strings.TrimPrefix(r.URL.Path, "/v2/actor-runs/")
+		fmt.Fprintf(w, `{"data":{"id":%q,"status":"SUCCEEDED"}}`, id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme, r.URL.Host = t.target.Scheme, t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestSearchRecordsChangesOfKnownPlaces(t *testing.T) {
+	db := testDatabase(t)
+	ctx := context.Background()
+	placeID := fmt.Sprintf("test-search-%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		for _, stmt := range []string{
+			"DELETE FROM poi_data_schema.poi_events WHERE poi_id = $1",
+			"DELETE FROM poi_data_schema.outbox WHERE key = $1",
+			"DELETE FROM poi_data_schema.raw_items WHERE item_id = $1",
+			"DELETE FROM poi_data_schema.google_maps WHERE place_id = $1",
+		} {
+			if _, err := db.Pool.Exec(ctx, stmt, placeID); err != nil {
+				t.Errorf("cleaning up: %v", err)
+			}
+		}
+	})
+
+	fake := &fakeApify{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+	client := apify.NewClient("key", "extractor", "scraper", "").
+		WithHTTPClient(&http.Client{Transport: rewriteTransport{target: target}})
+
+	m := &MapsService{
+		ApifyClient: client,
+		Database:    db,
+		Ingest:      config.Ingest{DecodeWorkers: 1, NormalizeWorkers: 1, H3Workers: 1, WriteWorkers: 1},
+		Changes:     config.Changes{RatingJump: 0.5, MoveMeters: 100},
+	}
+	const item = `[{"placeId":%q,"title":"Test Cafe","location":{"lat":57.7,"lng":11.97},"totalScore":4.5,"permanentlyClosed":%t}]`
+	search := func(closed bool) {
+		t.Helper()
+		fake.dataset = fmt.Sprintf(item, placeID, closed)
+		resp, err := m.SearchGoogleMapsExtractor(ctx, &maps_v1.SearchRequest{
+			SearchStringsArray: []string{"cafe"},
+			NumberOfResults:    1,
+		})
+		if err != nil {
+			t.Fatalf("SearchGoogleMapsExtractor() = %v", err)
+		}
+		if resp.GetInserted() != 1 || resp.GetFailed() != 0 {
+			t.Fatalf("inserted = %d, failed = %d (%v), want 1 and 0", resp.GetInserted(), resp.GetFailed(), resp.GetItemErrors())
+		}
+	}
+
+	search(false)
+	search(true)
+
+	events, err := db.Queries.ListPOIEvents(ctx, sqlc_db.ListPOIEventsParams{
+		Column3:  placeID,
+		Column10: time.Now().Add(time.Hour),
+		Column11: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	if len(types) != 2 || types[0] != string(changes.TypeNew) || types[1] != string(changes.TypeClosed) {
+		t.Errorf("events = %v, want [new closed]", types)
+	}
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/pkg/normalize"
+)
+
+// normalizePOIParams fills in the normalized phone, website and address columns
+// from the values as scraped.
+func normalizePOIParams(params *sqlc_db.InsertPOIParams) {
+	phone := params.PhoneUnformatted
+	if !phone.Valid {
+		phone = params.Phone
+	}
+	if phone.Valid {
+		if e164, ok := normalize.Phone(phone.String, params.CountryCode.String); ok {
+			params.PhoneE164 = textFromString(e164)
+		}
+	}
+
+	if params.Website.Valid {
+		if canonical, host, ok := normalize.Website(params.Website.String); ok {
+			params.WebsiteUrl = textFromString(canonical)
+			params.WebsiteHost = textFromString(host)
+		}
+	}
+
+	address := normalize.NewAddress(
+		params.Street.String,
+		params.PostalCode.String,
+		params.City.String,
+		params.CountryCode.String,
+	)
+	params.AddressStreet = textFromString(address.Street)
+	params.AddressHouseNumber = textFromString(address.HouseNumber)
+	params.AddressPostalCode = textFromString(address.PostalCode)
+	params.AddressCity = textFromString(address.City)
+	params.AddressCountryCode = textFromString(address.CountryCode)
+	params.AddressKey = textFromString(address.Key())
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/models"
+	"apify-poi-data/pkg/hours"
+)
+
+// Sources of the POIs that opening hours are stored for.
+const (
+	sourceGoogleMaps  = "google_maps"
+	sourceTripadvisor = "tripadvisor"
+)
+
+// openingHours is the weekly schedule of a POI, ready to be stored.
+type openingHours struct {
+	source   string
+	poiID    string
+	timezone string // Empty until resolved from the coordinates
+	week     hours.Week
+}
+
+// parseOpeningHours converts the opening hours of a decoded POI. It returns
+// nil for POIs without opening hours.
+func parseOpeningHours(poi models.POI) (*openingHours, error) {
+	switch p := poi.(type) {
+	case *models.Place:
+		return parseGoogleOpeningHours(p.PlaceID, p.OpeningHours)
+	case *models.PlaceScraper:
+		return parseGoogleOpeningHours(p.PlaceID, p.OpeningHours)
+	case *models.Hotel:
+		return tripadvisorOpeningHours(&p.BasePOI), nil
+	case *models.Restaurant:
+		return tripadvisorOpeningHours(&p.BasePOI), nil
+	case *models.Attraction:
+		return tripadvisorOpeningHours(&p.BasePOI), nil
+	}
+	return nil, nil
+}
+
+func parseGoogleOpeningHours(placeID string, raw json.RawMessage) (*openingHours, error) {
+	var days []hours.Day
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	if err := json.Unmarshal(raw, &days); err != nil {
+		return nil, fmt.Errorf("decoding opening hours: %w", err)
+	}
+	if len(days) == 0 {
+		return nil, nil
+	}
+	week, err := hours.ParseGoogle(days)
+	if err != nil {
+		return nil, err
+	}
+	return &openingHours{
+		source: sourceGoogleMaps,
+		poiID:  placeID,
+		week:   week,
+	}, nil
+}
+
+func tripadvisorOpeningHours(p *models.BasePOI) *openingHours {
+	if p.Hours == nil || len(p.Hours.WeekRanges) == 0 {
+		return nil
+	}
+	days := make([][]hours.Range, len(p.Hours.WeekRanges))
+	for i, ranges := range p.Hours.WeekRanges {
+		for _, r := range ranges {
+			days[i] = append(days[i], hours.Range{Open: r.Open, Close: r.Close})
+		}
+	}
+	oh := &openingHours{
+		source: sourceTripadvisor,
+		poiID:  p.GetID(),
+		week:   hours.FromWeekRanges(days),
+	}
+	if p.Hours.Timezone != nil {
+		oh.timezone = *p.Hours.Timezone
+	}
+	return oh
+}
+
+// storeOpeningHours replaces the stored schedule of a POI. The time zone is
+// looked up from the coordinates when the source does not provide one.
+func (m *MapsService) storeOpeningHours(ctx context.Context, oh *openingHours, lat, lng float64) error {
+	if oh.timezone == "" {
+		oh.timezone = hours.TimezoneAt(lat, lng)
+	}
+	if _, err := time.LoadLocation(oh.timezone); err != nil || oh.timezone == "" {
+		return fmt.Errorf("no time zone for %s at %f,%f", oh.poiID, lat, lng)
+	}
+
+	starts := make([]int32, len(oh.week))
+	ends := make([]int32, len(oh.week))
+	for i, iv := range oh.week {
+		starts[i], ends[i] = int32(iv.Start), int32(iv.End)
+	}
+
+	tx, err := m.Database.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	q := m.Database.Queries.WithTx(tx)
+
+	err = q.UpsertOpeningHours(ctx, sqlc_db.UpsertOpeningHoursParams{
+		Source:   oh.source,
+		PoiID:    oh.poiID,
+		Timezone: oh.timezone,
+	})
+	if err != nil {
+		return err
+	}
+	err = q.DeleteOpeningIntervals(ctx, sqlc_db.DeleteOpeningIntervalsParams{
+		Source: oh.source,
+		PoiID:  oh.poiID,
+	})
+	if err != nil {
+		return err
+	}
+	err = q.InsertOpeningIntervals(ctx, sqlc_db.InsertOpeningIntervalsParams{
+		Column1: oh.source,
+		Column2: oh.poiID,
+		Column3: starts,
+		Column4: ends,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// openFilter is implemented by the list requests that can be limited to open POIs.
+type openFilter interface {
+	GetOpenAt() string
+	GetOpenNow() bool
+}
+
+// openFilterTime returns the time POIs must be open at, or false when the
+// request does not filter on opening hours.
+func openFilterTime(in openFilter) (time.Time, bool, error) {
+	if in.GetOpenNow() {
+		return time.Now(), true, nil
+	}
+	if in.GetOpenAt() == "" {
+		return time.Time{}, false, nil
+	}
+	t, err := time.Parse(time.RFC3339, in.GetOpenAt())
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid open_at: %w", err)
+	}
+	return t, true, nil
+}
+
+// filterOpen keeps the POIs that are open at the time the request asks for.
+// POIs without known opening hours are left out.
+func (p *PoiService) filterOpen(ctx context.Context, res []sqlc_db.PoiDataSchemaGoogleMap, in openFilter) ([]sqlc_db.PoiDataSchemaGoogleMap, error) {
+	at, ok, err := openFilterTime(in)
+	if err != nil || !ok || len(res) == 0 {
+		return res, err
+	}
+
+	ids := make([]string, 0, len(res))
+	for _, poi := range res {
+		if poi.PlaceID.Valid {
+			ids = append(ids, poi.PlaceID.String)
+		}
+	}
+	open, err := p.Database.Queries.ListOpenPOIIDs(ctx, sqlc_db.ListOpenPOIIDsParams{
+		Column1: sourceGoogleMaps,
+		Column2: ids,
+		Column3: at,
+	})
+	if err != nil {
+		return nil, err
+	}
+	isOpen := make(map[string]bool, len(open))
+	for _, id := range open {
+		isOpen[id] = true
+	}
+
+	filtered := res[:0]
+	for _, poi := range res {
+		if isOpen[poi.PlaceID.String] {
+			filtered = append(filtered, poi)
+		}
+	}
+	return filtered, nil
+}
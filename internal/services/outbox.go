@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"apify-poi-data/config"
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/pkg/changes"
+	"apify-poi-data/pkg/publish"
+)
+
+const (
+	subjectInserted = "inserted"
+	subjectUpdated  = "updated"
+
+	outboxCleanupInterval = time.Hour
+)
+
+// poiMessage is the outbox document about a written place.
+type poiMessage struct {
+	PlaceID           string   `json:"placeId"`
+	Title             string   `json:"title,omitempty"`
+	Category          string   `json:"category,omitempty"`
+	Categories        []string `json:"categories,omitempty"`
+	Address           string   `json:"address,omitempty"`
+	Lat               *float64 `json:"lat,omitempty"`
+	Lng               *float64 `json:"lng,omitempty"`
+	PrevLat           *float64 `json:"prevLat,omitempty"` // Location before the write, when the place had one
+	PrevLng           *float64 `json:"prevLng,omitempty"`
+	TotalScore        *float64 `json:"totalScore,omitempty"`
+	ReviewsCount      *int32   `json:"reviewsCount,omitempty"`
+	PermanentlyClosed bool     `json:"permanentlyClosed"`
+	TemporarilyClosed bool     `json:"temporarilyClosed"`
+	URL               string   `json:"url,omitempty"`
+	ScrapedAt         string   `json:"scrapedAt,omitempty"` // RFC3339
+	Changes           []string `json:"changes,omitempty"`   // Types of the events recorded with the write
+}
+
+// enqueuePOIMessage adds a message about a written place to the outbox. prev
+// is the stored state before the write, nil when the place is new.
+func enqueuePOIMessage(ctx context.Context, q *sqlc_db.Queries, params sqlc_db.InsertPOIParams, prev *changes.Snapshot, detected []changes.Change) error {
+	msg := poiMessage{
+		PlaceID:           params.PlaceID.String,
+		Title:             params.Title.String,
+		Category:          params.CategoryName.String,
+		Categories:        params.Categories,
+		Address:           params.Address.String,
+		PermanentlyClosed: params.PermanentlyClosed.Valid && params.PermanentlyClosed.Bool,
+		TemporarilyClosed: params.TemporarilyClosed.Valid && params.TemporarilyClosed.Bool,
+		URL:               params.Url.String,
+	}
+	if params.LocationLat.Valid && params.LocationLng.Valid {
+		msg.Lat, msg.Lng = &params.LocationLat.Float64, &params.LocationLng.Float64
+	}
+	if prev != nil && prev.Location != nil {
+		msg.PrevLat, msg.PrevLng = &prev.Location.Lat, &prev.Location.Lng
+	}
+	if params.TotalScore.Valid {
+		msg.TotalScore = &params.TotalScore.Float64
+	}
+	if params.ReviewsCount.Valid {
+		msg.ReviewsCount = &params.ReviewsCount.Int32
+	}
+	if params.ScrapedAt.Valid {
+		msg.ScrapedAt = params.ScrapedAt.Time.Format(time.RFC3339)
+	}
+	for _, c := range detected {
+		msg.Changes = append(msg.Changes, string(c.Type))
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	subject := subjectUpdated
+	if prev == nil {
+		subject = subjectInserted
+	}
+	return q.InsertOutboxMessage(ctx, sqlc_db.InsertOutboxMessageParams{
+		Column1: subject,
+		Column2: msg.PlaceID,
+		Column3: data,
+	})
+}
+
+// NewPublisher connects to the broker the outbox is published to.
+func NewPublisher(cfg config.Outbox) (publish.Publisher, error) {
+	switch cfg.Publisher {
+	case config.PublisherLog:
+		return publish.Log{}, nil
+	case config.PublisherNATS:
+		return publish.DialNATS(cfg.NATSURL, cfg.Prefix)
+	case config.PublisherKafka:
+		return publish.NewKafka(cfg.KafkaRESTURL, cfg.Prefix, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown outbox publisher: %q", cfg.Publisher)
+	}
+}
+
+// OutboxRelay publishes the outbox. Several instances may share a database:
+// each batch is claimed with FOR UPDATE SKIP LOCKED and marked published in
+// the same transaction, once the broker accepted it.
+type OutboxRelay struct {
+	Database  *sqlc_db.Database
+	Publisher publish.Publisher
+	Config    config.Outbox
+}
+
+func NewOutboxRelay(cfg config.Outbox, db *sqlc_db.Database, publisher publish.Publisher) *OutboxRelay {
+	return &OutboxRelay{
+		Database:  db,
+		Publisher: publisher,
+		Config:    cfg,
+	}
+}
+
+// Run publishes new messages until ctx is done and deletes the published ones
+// that are older than the retention.
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	defer r.Publisher.Close()
+	ticker := time.NewTicker(r.Config.PollInterval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(outboxCleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		n, err := r.relayBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to publish outbox messages: %v", err)
+		}
+		if err == nil && n == r.Config.BatchSize {
+			continue // More are waiting
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-cleanup.C:
+			r.cleanup(ctx)
+		case <-ticker.C:
+		}
+	}
+}
+
+// relayBatch publishes the oldest unpublished messages and returns how many there were.
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	tx, err := r.Database.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	q := r.Database.Queries.WithTx(tx)
+
+	rows, err := q.ClaimOutboxMessages(ctx, int32(r.Config.BatchSize))
+	if err != nil || len(rows) == 0 {
+		return 0, err
+	}
+	msgs := make([]publish.Message, len(rows))
+	ids := make([]int64, len(rows))
+	for i, row := range rows {
+		msgs[i] = publish.Message{
+			ID:      row.ID,
+			Subject: row.Subject,
+			Key:     row.Key,
+			Data:    row.Data,
+			Time:    row.CreatedAt,
+		}
+		ids[i] = row.ID
+	}
+	if err := r.Publisher.Publish(ctx, msgs); err != nil {
+		return 0, err
+	}
+	if err := q.MarkOutboxPublished(ctx, ids); err != nil {
+		return 0, err
+	}
+	return len(rows), tx.Commit(ctx)
+}
+
+func (r *OutboxRelay) cleanup(ctx context.Context) {
+	n, err := r.Database.Queries.DeleteOutboxMessages(ctx, time.Now().Add(-r.Config.Retention))
+	if err != nil {
+		log.Printf("Failed to delete published outbox messages: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Deleted %d published outbox messages", n)
+	}
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+)
+
+const (
+	outboxChannel       = "poi_outbox"
+	listenerRetryDelay  = 5 * time.Second
+	watchFallbackPoll   = 5 * time.Second // Between reads of the outbox when no notification arrives
+	watchPollNoListener = time.Second
+)
+
+// OutboxListener wakes up the watchers of this replica whenever outbox
+// messages are committed, by any replica, through LISTEN/NOTIFY on a
+// connection taken out of the pool.
+type OutboxListener struct {
+	Database *sqlc_db.Database
+
+	mu   sync.Mutex
+	subs map[chan struct{}]struct{}
+}
+
+func NewOutboxListener(db *sqlc_db.Database) *OutboxListener {
+	return &OutboxListener{
+		Database: db,
+		subs:     map[chan struct{}]struct{}{},
+	}
+}
+
+// Subscribe returns a channel that receives a value after new messages were
+// committed. Wake-ups are coalesced while the subscriber is busy.
+func (l *OutboxListener) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	l.mu.Lock()
+	l.subs[ch] = struct{}{}
+	l.mu.Unlock()
+	return ch, func() {
+		l.mu.Lock()
+		delete(l.subs, ch)
+		l.mu.Unlock()
+	}
+}
+
+func (l *OutboxListener) broadcast() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ch := range l.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run listens until ctx is done, reconnecting when the connection fails.
+func (l *OutboxListener) Run(ctx context.Context) error {
+	for {
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		log.Printf("Outbox listener disconnected, retrying in %s: %v", listenerRetryDelay, err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(listenerRetryDelay):
+		}
+	}
+}
+
+func (l *OutboxListener) listen(ctx context.Context) error {
+	pooled, err := l.Database.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	conn := pooled.Hijack()
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+outboxChannel); err != nil {
+		return err
+	}
+	// Messages committed while not listening are picked up by the next read
+	l.broadcast()
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		l.broadcast()
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/models"
+	"apify-poi-data/pkg/geo"
+	"apify-poi-data/pkg/olc"
+)
+
+const (
+	// issuePlusCodeMismatch marks coordinates that lie outside the POI's own plus code.
+	issuePlusCodeMismatch geo.Issue = "plus_code_mismatch"
+
+	// Scraped coordinates may sit a little outside a 14 m plus code cell
+	plusCodeToleranceKm = 0.5
+)
+
+// poiPlusCode returns the plus code a scraper reported for the POI, if any.
+func poiPlusCode(poi models.POI) string {
+	if p, ok := poi.(*models.PlaceScraper); ok {
+		return strings.TrimSpace(models.ValueOrEmpty(p.PlusCode))
+	}
+	return ""
+}
+
+// decodeScrapedPlusCode decodes a plus code as scraped, e.g. "9FFW84J9+XG" or
+// "84J9+XG Gothenburg, Sweden". Short codes are recovered near ref.
+func decodeScrapedPlusCode(scraped string, ref geo.Point, haveRef bool) (olc.CodeArea, error) {
+	code, _, _ := strings.Cut(scraped, " ")
+	if olc.IsShort(code) {
+		if !haveRef {
+			return olc.CodeArea{}, fmt.Errorf("short plus code %s without a reference location", code)
+		}
+		full, err := olc.RecoverNearest(code, ref.Lat, ref.Lng)
+		if err != nil {
+			return olc.CodeArea{}, err
+		}
+		code = full
+	}
+	return olc.Decode(code)
+}
+
+// checkPlusCode fills in a missing location from the plus code, or checks the
+// scraped location against it. Short codes of POIs without a location are
+// recovered near the center of the search area.
+func checkPlusCode(params *sqlc_db.InsertPOIParams, code string, area geo.Area) (geo.Issue, string) {
+	p := geo.Point{Lat: params.LocationLat.Float64, Lng: params.LocationLng.Float64}
+	missing := geo.Check(p) == geo.IssueNullIsland
+	ref, haveRef := p, !missing
+	if missing {
+		ref, haveRef = area.Center()
+	}
+
+	codeArea, err := decodeScrapedPlusCode(code, ref, haveRef)
+	if err != nil {
+		log.Printf("Failed to decode plus code %q: %v", code, err)
+		if missing {
+			return geo.IssueNullIsland, ""
+		}
+		return geo.IssueNone, ""
+	}
+
+	if missing {
+		lat, lng := codeArea.Center()
+		params.LocationLat = pgtype.Float8{Float64: lat, Valid: true}
+		params.LocationLng = pgtype.Float8{Float64: lng, Valid: true}
+		log.Printf("Recovered location of %s from plus code %q", params.PlaceID.String, code)
+		return geo.IssueNone, ""
+	}
+
+	cell := codeAreaPolygon(codeArea)
+	if d := cell.DistanceKm(p); d > plusCodeToleranceKm {
+		if cell.DistanceKm(p.Swapped()) <= plusCodeToleranceKm {
+			return geo.IssueSwapped, fmt.Sprintf("plus code %s matches the swapped coordinates", code)
+		}
+		return issuePlusCodeMismatch, fmt.Sprintf("%.2f km from plus code %s", d, code)
+	}
+	return geo.IssueNone, ""
+}
+
+func codeAreaPolygon(a olc.CodeArea) geo.Polygon {
+	return geo.Polygon{
+		{Lat: a.LatLo, Lng: a.LngLo},
+		{Lat: a.LatLo, Lng: a.LngHi},
+		{Lat: a.LatHi, Lng: a.LngHi},
+		{Lat: a.LatHi, Lng: a.LngLo},
+	}
+}
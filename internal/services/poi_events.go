@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/pkg/changes"
+	"apify-poi-data/pkg/geo"
+	poi_v1 "apify-poi-data/proto/apify/poi/v1"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	defaultEventLimit = 100
+	maxEventLimit     = 1000
+)
+
+// writePOI writes a place with write, records the changes to its stored state
+// as events and adds a message about it to the outbox, in one transaction
+// that locks the stored row. Places without an ID are only written.
+func (m *MapsService) writePOI(ctx context.Context, params sqlc_db.InsertPOIParams, write func(q *sqlc_db.Queries) error) error {
+	if !params.PlaceID.Valid || params.PlaceID.String == "" {
+		return write(m.Database.Queries)
+	}
+
+	tx, err := m.Database.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := m.writePOIInTx(ctx, m.Database.Queries.WithTx(tx), params, write); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// writePOIInTx is writePOI within the transaction of q, which the caller commits.
+func (m *MapsService) writePOIInTx(ctx context.Context, q *sqlc_db.Queries, params sqlc_db.InsertPOIParams, write func(q *sqlc_db.Queries) error) error {
+	if !params.PlaceID.Valid || params.PlaceID.String == "" {
+		return write(q)
+	}
+
+	var prev *changes.Snapshot
+	row, err := q.GetPOISnapshot(ctx, params.PlaceID)
+	switch {
+	case err == nil:
+		s := storedSnapshot(row)
+		prev = &s
+	case !errors.Is(err, pgx.ErrNoRows):
+		return err
+	}
+
+	if err := write(q); err != nil {
+		return err
+	}
+	detected := changes.Detect(prev, paramsSnapshot(params), m.changeThresholds())
+	for _, c := range detected {
+		if err := recordEvent(ctx, q, params.PlaceID.String, c.Type, changeDetails(c), 0); err != nil {
+			return fmt.Errorf("recording %s event: %w", c.Type, err)
+		}
+	}
+	if err := enqueuePOIMessage(ctx, q, params, prev, detected); err != nil {
+		return fmt.Errorf("adding outbox message: %w", err)
+	}
+	return nil
+}
+
+func (m *MapsService) changeThresholds() changes.Thresholds {
+	return changes.Thresholds{
+		RatingJump: m.Changes.RatingJump,
+		MoveKm:     m.Changes.MoveMeters / 1000,
+	}
+}
+
+func storedSnapshot(row sqlc_db.GetPOISnapshotRow) changes.Snapshot {
+	return snapshot(row.Title, row.PermanentlyClosed, row.TemporarilyClosed, row.TotalScore, row.LocationLat, row.LocationLng)
+}
+
+func paramsSnapshot(params sqlc_db.InsertPOIParams) changes.Snapshot {
+	return snapshot(params.Title, params.PermanentlyClosed, params.TemporarilyClosed, params.TotalScore, params.LocationLat, params.LocationLng)
+}
+
+func snapshot(title pgtype.Text, permanentlyClosed, temporarilyClosed pgtype.Bool, score, lat, lng pgtype.Float8) changes.Snapshot {
+	s := changes.Snapshot{
+		Name:              title.String,
+		PermanentlyClosed: permanentlyClosed.Valid && permanentlyClosed.Bool,
+		TemporarilyClosed: temporarilyClosed.Valid && temporarilyClosed.Bool,
+	}
+	if score.Valid {
+		rating := score.Float64
+		s.Rating = &rating
+	}
+	if lat.Valid && lng.Valid {
+		s.Location = &geo.Point{Lat: lat.Float64, Lng: lng.Float64}
+	}
+	return s
+}
+
+func changeDetails(c changes.Change) map[string]any {
+	details := map[string]any{}
+	if c.From != nil {
+		details["from"] = c.From
+	}
+	if c.To != nil {
+		details["to"] = c.To
+	}
+	return details
+}
+
+// recordEvent stores an event of a Google Maps place.
+func recordEvent(ctx context.Context, q *sqlc_db.Queries, placeID string, t changes.Type, details map[string]any, savedSearchID int64) error {
+	doc, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	return q.InsertPOIEvent(ctx, sqlc_db.InsertPOIEventParams{
+		Column1: sourceGoogleMaps,
+		Column2: placeID,
+		Column3: string(t),
+		Column4: doc,
+		Column5: savedSearchID,
+	})
+}
+
+// trackSavedSearchPlaces records which places a successful run of a saved
+// search returned. Places the search returned before and that were missing
+// from DisappearAfterRuns consecutive runs since are recorded as disappeared.
+func (m *MapsService) trackSavedSearchPlaces(ctx context.Context, searchID int64, placeIDs []string) error {
+	tx, err := m.Database.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	q := m.Database.Queries.WithTx(tx)
+
+	err = q.MarkSavedSearchPlacesSeen(ctx, sqlc_db.MarkSavedSearchPlacesSeenParams{
+		Column1: searchID,
+		Column2: placeIDs,
+	})
+	if err != nil {
+		return err
+	}
+	missed, err := q.MissSavedSearchPlaces(ctx, sqlc_db.MissSavedSearchPlacesParams{
+		Column1: searchID,
+		Column2: placeIDs,
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range missed {
+		// Recorded once, when the place reaches the threshold
+		if int(p.MissedRuns) != m.Changes.DisappearAfterRuns {
+			continue
+		}
+		details := map[string]any{"missedRuns": p.MissedRuns}
+		if err := recordEvent(ctx, q, p.PlaceID, changes.TypeDisappeared, details, searchID); err != nil {
+			return err
+		}
+		log.Printf("Place %s disappeared from saved search %d", p.PlaceID, searchID)
+	}
+	return tx.Commit(ctx)
+}
+
+// ListPOIEvents pages through the recorded changes of the places.
+func (p *PoiService) ListPOIEvents(ctx context.Context, in *poi_v1.ListPOIEventsRequest) (*poi_v1.ListPOIEventsResponse, error) {
+	limit := in.GetLimit()
+	if limit <= 0 {
+		limit = defaultEventLimit
+	}
+	if limit > maxEventLimit {
+		limit = maxEventLimit
+	}
+	for _, t := range in.GetTypes() {
+		if !changes.Type(t).Valid() {
+			return nil, fmt.Errorf("unknown event type: %q", t)
+		}
+	}
+
+	params := sqlc_db.ListPOIEventsParams{
+		Column1:  in.GetAfterId(),
+		Column2:  in.GetTypes(),
+		Column3:  in.GetPlaceId(),
+		Column9:  time.Time{},
+		Column10: scrapedForever,
+		Column11: limit,
+	}
+	if params.Column2 == nil {
+		params.Column2 = []string{}
+	}
+	switch box := []*float64{in.MinX, in.MinY, in.MaxX, in.MaxY}; {
+	case box[0] != nil && box[1] != nil && box[2] != nil && box[3] != nil:
+		params.Column4 = true
+		params.Column5, params.Column6 = *box[0], *box[1]
+		params.Column7, params.Column8 = *box[2], *box[3]
+	case box[0] != nil || box[1] != nil || box[2] != nil || box[3] != nil:
+		return nil, fmt.Errorf("min_x, min_y, max_x and max_y must be set together")
+	}
+	if s := in.GetOccurredAfter(); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid occurred_after: %w", err)
+		}
+		params.Column9 = t
+	}
+	if s := in.GetOccurredBefore(); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid occurred_before: %w", err)
+		}
+		params.Column10 = t
+	}
+
+	rows, err := p.Database.Queries.ListPOIEvents(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &poi_v1.ListPOIEventsResponse{}
+	for _, row := range rows {
+		event, err := toPOIEvent(row)
+		if err != nil {
+			return nil, err
+		}
+		resp.Events = append(resp.Events, event)
+	}
+	if len(rows) == int(limit) {
+		resp.NextAfterId = rows[len(rows)-1].ID
+	}
+	return resp, nil
+}
+
+func toPOIEvent(row sqlc_db.ListPOIEventsRow) (*poi_v1.PoiEvent, error) {
+	event := &poi_v1.PoiEvent{
+		Id:         row.ID,
+		Source:     row.Source,
+		PlaceId:    row.PoiID,
+		Type:       row.Type,
+		OccurredAt: row.OccurredAt.Format(time.RFC3339),
+	}
+	if len(row.Details) > 0 {
+		var details map[string]any
+		if err := json.Unmarshal(row.Details, &details); err != nil {
+			return nil, fmt.Errorf("decoding details of event %d: %w", row.ID, err)
+		}
+		s, err := structpb.NewStruct(details)
+		if err != nil {
+			return nil, err
+		}
+		event.Details = s
+	}
+	if row.HasLocation {
+		event.Lat, event.Lng = &row.Lat, &row.Lng
+	}
+	if row.SavedSearchID.Valid {
+		event.SavedSearchId = &row.SavedSearchID.Int64
+	}
+	return event, nil
+}
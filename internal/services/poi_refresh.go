@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/models"
+	maps_v1 "apify-poi-data/proto/apify/maps/v1"
+)
+
+const (
+	defaultRefreshPOILimit = 500
+	maxRefreshPOILimit     = 2000
+	defaultRefreshBatch    = 100
+
+	refreshStatusRefreshed = "refreshed"
+	refreshStatusNotFound  = "not_found"
+	refreshStatusFailed    = "failed"
+)
+
+// RefreshPOIs scrapes stored places again from their Google Maps URL, in
+// batches of startUrls, and upserts the results. Requested places the scraper
+// no longer returns are reported as not found.
+func (m *MapsService) RefreshPOIs(ctx context.Context, in *maps_v1.RefreshPOIsRequest) (*maps_v1.RefreshPOIsResponse, error) {
+	if len(in.GetPlaceIds()) == 0 && in.Region == nil && in.StaleBefore == nil {
+		return nil, fmt.Errorf("one of placeIds, region or staleBefore is required")
+	}
+	limit := int(in.GetLimit())
+	if limit <= 0 {
+		limit = defaultRefreshPOILimit
+	}
+	if limit > maxRefreshPOILimit || len(in.GetPlaceIds()) > maxRefreshPOILimit {
+		return nil, fmt.Errorf("at most %d places can be refreshed at once", maxRefreshPOILimit)
+	}
+	if n := len(in.GetPlaceIds()); n > limit {
+		limit = n
+	}
+	batchSize := int(in.GetBatchSize())
+	if batchSize <= 0 {
+		batchSize = defaultRefreshBatch
+	}
+
+	params := sqlc_db.ListPOIRefreshTargetsParams{
+		Column1: in.GetPlaceIds(),
+		Column7: scrapedForever,
+		Column8: int32(limit),
+	}
+	if params.Column1 == nil {
+		params.Column1 = []string{}
+	}
+	if r := in.GetRegion(); r != nil {
+		params.Column2 = true
+		params.Column3, params.Column4 = r.GetMinX(), r.GetMinY()
+		params.Column5, params.Column6 = r.GetMaxX(), r.GetMaxY()
+	}
+	if in.StaleBefore != nil {
+		staleBefore, err := time.Parse(time.RFC3339, in.GetStaleBefore())
+		if err != nil {
+			return nil, fmt.Errorf("invalid staleBefore: %w", err)
+		}
+		params.Column7 = staleBefore
+	}
+
+	targets, err := m.Database.Queries.ListPOIRefreshTargets(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &maps_v1.RefreshPOIsResponse{Status: "success"}
+	byPlace := map[string]*maps_v1.PlaceRefresh{}
+	var batches [][]*maps_v1.PlaceRefresh
+	var urls [][]string
+	for _, t := range targets {
+		place := &maps_v1.PlaceRefresh{PlaceId: t.PlaceID}
+		resp.Places = append(resp.Places, place)
+		byPlace[place.PlaceId] = place
+		if !t.Url.Valid || t.Url.String == "" {
+			place.Status = refreshStatusFailed
+			place.Error = stringPtr("place has no Google Maps URL")
+			continue
+		}
+		if len(batches) == 0 || len(batches[len(batches)-1]) == batchSize {
+			batches = append(batches, nil)
+			urls = append(urls, nil)
+		}
+		last := len(batches) - 1
+		batches[last] = append(batches[last], place)
+		urls[last] = append(urls[last], t.Url.String)
+	}
+	for _, id := range in.GetPlaceIds() {
+		if _, ok := byPlace[id]; !ok {
+			place := &maps_v1.PlaceRefresh{
+				PlaceId: id,
+				Status:  refreshStatusNotFound,
+				Error:   stringPtr("place not found"),
+			}
+			resp.Places = append(resp.Places, place)
+			byPlace[id] = place
+		}
+	}
+
+	// Start every run before waiting, the scraper runs them in parallel.
+	pending := make([]func() error, 0, len(batches))
+	for i, batch := range batches {
+		payload := models.ScraperInputPayloadMaps{
+			StartUrls: make([]map[string]string, 0, len(urls[i])),
+			Language:  in.GetLanguage(),
+		}
+		for _, u := range urls[i] {
+			payload.StartUrls = append(payload.StartUrls, map[string]string{"url": u})
+		}
+		res := m.ApifyClient.ScrapePOIs(payload, true)
+		for _, place := range batch {
+			place.RunId = res.RunID
+		}
+
+		pending = append(pending, func() error {
+			select {
+			case data := <-res.Data:
+				m.ingestRefresh(ctx, data, res.RunID, byPlace)
+				for _, place := range batch {
+					if place.Status == "" {
+						place.Status = refreshStatusNotFound
+						place.Error = stringPtr("place was not returned by the scraper")
+					}
+				}
+			case err := <-res.Err:
+				log.Printf("POI refresh run %s failed: %v", res.RunID, err)
+				for _, place := range batch {
+					place.Status = refreshStatusFailed
+					place.Error = stringPtr(err.Error())
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		})
+	}
+	for _, wait := range pending {
+		if err := wait(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, place := range resp.Places {
+		switch place.Status {
+		case refreshStatusRefreshed:
+			resp.Refreshed++
+		case refreshStatusNotFound:
+			resp.NotFound++
+		default:
+			resp.Failed++
+		}
+	}
+	return resp, nil
+}
+
+// ingestRefresh upserts the places scraped by a refresh run and sets the
+// status of the requested ones among them.
+func (m *MapsService) ingestRefresh(ctx context.Context, res models.ParseResult, runID string, byPlace map[string]*maps_v1.PlaceRefresh) {
+	for _, e := range res.Errors {
+		log.Printf("Skipping refreshed POI of run %s: %v", runID, e)
+	}
+	items := make([]ingestItem, len(res.POIs))
+	for i, poi := range res.POIs {
+		items[i] = ingestItem{
+			parser: res.Parser,
+			raw:    res.Raw[i],
+			poi:    poi,
+		}
+	}
+	out := m.ingest(ctx, items, ingestOptions{
+		sourceRunID: runID,
+		write:       m.upsertPOItoDB,
+	})
+
+	failed := make(map[int]error, len(out.Errors))
+	for _, e := range out.Errors {
+		failed[e.Index] = e
+	}
+	for i, poi := range res.POIs {
+		place, ok := byPlace[poi.GetID()]
+		if !ok {
+			continue
+		}
+		err, ok := failed[i]
+		if !ok && out.Err != nil {
+			// The pipeline stopped early, the place may not have been written
+			err = out.Err
+		}
+		if err != nil {
+			place.Status = refreshStatusFailed
+			place.Error = stringPtr(err.Error())
+			continue
+		}
+		place.Status = refreshStatusRefreshed
+		place.Error = nil
+	}
+}
@@ -4,21 +4,26 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
-	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/uber/h3-go/v4"
 	"google.golang.org/protobuf/types/known/structpb"
 
 	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/pkg/normalize"
+	"apify-poi-data/pkg/olc"
 	poi_v1 "apify-poi-data/proto/apify/poi/v1"
 )
 
 const (
 	DATABASE_RESOLUTION = 9
+
+	defaultMatchKeyLimit = 100
 )
 
 type PoiService struct {
 	Database *sqlc_db.Database
+	Outbox   *OutboxListener // Wakes up the watch streams, polled without it
 	poi_v1.UnimplementedPoiServiceServer
 }
 
@@ -193,41 +198,47 @@ func (p PoiService) toPOI(poi sqlc_db.PoiDataSchemaGoogleMap) (*poi_v1.Poi, erro
 		}
 	}
 
+	var scrapedAt *string
+	if poi.ScrapedAt.Valid {
+		s := poi.ScrapedAt.Time.String()
+		scrapedAt = &s
+	}
+
 	return &poi_v1.Poi{
 		Id:                      poi.ID,
-		SearchString:            poi.SearchString.String,
-		Rank:                    poi.Rank.Int32,
-		SearchPageUrl:           poi.SearchPageUrl.String,
-		IsAdvertisement:         poi.IsAdvertisement.Bool,
-		Title:                   poi.Title.String,
-		SubTitle:                poi.SubTitle.String,
-		Price:                   poi.Price.String,
-		CategoryName:            poi.CategoryName.String,
-		Address:                 poi.Address.String,
-		Neighborhood:            poi.Neighborhood.String,
-		Street:                  poi.Street.String,
-		City:                    poi.City.String,
-		PostalCode:              poi.PostalCode.String,
-		State:                   poi.State.String,
-		CountryCode:             poi.CountryCode.String,
-		Website:                 poi.Website.String,
-		Phone:                   poi.Phone.String,
-		PhoneUnformatted:        poi.PhoneUnformatted.String,
-		ClaimThisBusiness:       poi.ClaimThisBusiness.Bool,
-		LocationLat:             poi.LocationLat.Float64,
-		LocationLng:             poi.LocationLng.Float64,
-		TotalScore:              poi.TotalScore.Float64,
-		PermanentlyClosed:       poi.PermanentlyClosed.Bool,
-		TemporarilyClosed:       poi.TemporarilyClosed.Bool,
-		PlaceId:                 poi.PlaceID.String,
+		SearchString:            textPtr(poi.SearchString),
+		Rank:                    int4Ptr(poi.Rank),
+		SearchPageUrl:           textPtr(poi.SearchPageUrl),
+		IsAdvertisement:         boolPtr(poi.IsAdvertisement),
+		Title:                   textPtr(poi.Title),
+		SubTitle:                textPtr(poi.SubTitle),
+		Price:                   textPtr(poi.Price),
+		CategoryName:            textPtr(poi.CategoryName),
+		Address:                 textPtr(poi.Address),
+		Neighborhood:            textPtr(poi.Neighborhood),
+		Street:                  textPtr(poi.Street),
+		City:                    textPtr(poi.City),
+		PostalCode:              textPtr(poi.PostalCode),
+		State:                   textPtr(poi.State),
+		CountryCode:             textPtr(poi.CountryCode),
+		Website:                 textPtr(poi.Website),
+		Phone:                   textPtr(poi.Phone),
+		PhoneUnformatted:        textPtr(poi.PhoneUnformatted),
+		ClaimThisBusiness:       boolPtr(poi.ClaimThisBusiness),
+		LocationLat:             float8Ptr(poi.LocationLat),
+		LocationLng:             float8Ptr(poi.LocationLng),
+		TotalScore:              float8Ptr(poi.TotalScore),
+		PermanentlyClosed:       boolPtr(poi.PermanentlyClosed),
+		TemporarilyClosed:       boolPtr(poi.TemporarilyClosed),
+		PlaceId:                 textPtr(poi.PlaceID),
 		Categories:              poi.Categories,
-		Fid:                     poi.Fid.String,
-		Cid:                     poi.Cid.String,
-		ReviewsCount:            poi.ReviewsCount.Int32,
-		ImagesCount:             poi.ImagesCount.Int32,
+		Fid:                     textPtr(poi.Fid),
+		Cid:                     textPtr(poi.Cid),
+		ReviewsCount:            int4Ptr(poi.ReviewsCount),
+		ImagesCount:             int4Ptr(poi.ImagesCount),
 		ImageCategories:         poi.ImageCategories,
-		ScrapedAt:               poi.ScrapedAt.Time.String(),
-		GoogleFoodUrl:           poi.GoogleFoodUrl.String,
+		ScrapedAt:               scrapedAt,
+		GoogleFoodUrl:           textPtr(poi.GoogleFoodUrl),
 		HotelAds:                hAds,
 		OpeningHours:            openingHours,
 		PeopleAlsoSearch:        peopleAlsoSearch,
@@ -235,39 +246,48 @@ func (p PoiService) toPOI(poi sqlc_db.PoiDataSchemaGoogleMap) (*poi_v1.Poi, erro
 		ReviewsTags:             reviewTags,
 		AdditionalInfo:          additionalInfo,
 		GasPrices:               gasPrices,
-		Url:                     poi.Url.String,
-		ImageUrl:                poi.ImageUrl.String,
-		Kgmid:                   poi.Kgmid.String,
+		Url:                     textPtr(poi.Url),
+		ImageUrl:                textPtr(poi.ImageUrl),
+		Kgmid:                   textPtr(poi.Kgmid),
 		Geom:                    poi.Geom,
-		H3Index:                 poi.H3Index.String,
-		SearchPageLoadedUrl:     poi.SearchPageLoadedUrl.String,
-		Description:             poi.Description.String,
-		LocatedIn:               poi.LocatedIn.String,
-		PlusCode:                poi.PlusCode.String,
-		Menu:                    poi.Menu.String,
-		ReserveTableUrl:         poi.ReserveTableUrl.String,
-		HotelStars:              poi.HotelStars.String,
-		HotelDescription:        poi.HotelDescription.String,
-		CheckInDate:             poi.CheckInDate.String,
-		CheckOutDate:            poi.CheckOutDate.String,
+		H3Index:                 textPtr(poi.H3Index),
+		SearchPageLoadedUrl:     textPtr(poi.SearchPageLoadedUrl),
+		Description:             textPtr(poi.Description),
+		LocatedIn:               textPtr(poi.LocatedIn),
+		PlusCode:                textPtr(poi.PlusCode),
+		Menu:                    textPtr(poi.Menu),
+		ReserveTableUrl:         textPtr(poi.ReserveTableUrl),
+		HotelStars:              textPtr(poi.HotelStars),
+		HotelDescription:        textPtr(poi.HotelDescription),
+		CheckInDate:             textPtr(poi.CheckInDate),
+		CheckOutDate:            textPtr(poi.CheckOutDate),
 		SimilarHotelsNearby:     similarHotelsNearby,
 		HotelReviewSummary:      hotelReviewSummary,
-		PopularTimesLiveText:    poi.PopularTimesLiveText.String,
-		PopularTimesLivePercent: poi.PopularTimesLivePercent.Int32,
+		PopularTimesLiveText:    textPtr(poi.PopularTimesLiveText),
+		PopularTimesLivePercent: int4Ptr(poi.PopularTimesLivePercent),
 		PopularTimesHistogram:   popularTimesHistogram,
 		QuestionsAndAnswers:     questionsAndAnswers,
 		UpdatesFromCustomers:    updatesFromCustomers,
 		WebResults:              webResults,
-		ParentPlaceUrl:          poi.ParentPlaceUrl.String,
+		ParentPlaceUrl:          textPtr(poi.ParentPlaceUrl),
 		TableReservationLinks:   tableReservationLinks,
 		BookingLinks:            bookingLinks,
 		OrderBy:                 orderBy,
-		Images:                  poi.Images.String,
+		Images:                  textPtr(poi.Images),
 		UserPlaceNote:           userPlaceNote,
 		ImageUrls:               poi.ImageUrls,
 		Reviews:                 reviews,
 		RestaurantData:          restaurantData,
 		OwnerUpdates:            ownerUpdates,
+		PhoneE164:               textPtr(poi.PhoneE164),
+		WebsiteUrl:              textPtr(poi.WebsiteUrl),
+		WebsiteHost:             textPtr(poi.WebsiteHost),
+		AddressStreet:           textPtr(poi.AddressStreet),
+		AddressHouseNumber:      textPtr(poi.AddressHouseNumber),
+		AddressPostalCode:       textPtr(poi.AddressPostalCode),
+		AddressCity:             textPtr(poi.AddressCity),
+		AddressCountryCode:      textPtr(poi.AddressCountryCode),
+		AddressKey:              textPtr(poi.AddressKey),
 	}, nil
 }
 
@@ -287,13 +307,23 @@ func (p *PoiService) ListPOIByH3Cells(in *poi_v1.ListPOIsByH3CellsRequest, strea
 		}
 	}
 
+	conds, err := p.attributeConditions(stream.Context(), in)
+	if err != nil {
+		return err
+	}
 	res, err := p.Database.Queries.ListPOIsByH3Cells(stream.Context(), sqlc_db.ListPOIsByH3CellsParams{
 		Column1: DATABASE_RESOLUTION,
 		Column2: indexes,
+		Column3: conds.required,
+		Column4: conds.excluded,
 	})
 	if err != nil {
 		return err
 	}
+	res, err = p.filterList(stream.Context(), res, in)
+	if err != nil {
+		return err
+	}
 	const batchSize = 150 // Adjust the batch size as needed
 	var pois []*poi_v1.Poi
 	for i, poi := range res {
@@ -320,15 +350,81 @@ func (p *PoiService) ListPOIByH3Cells(in *poi_v1.ListPOIsByH3CellsRequest, strea
 }
 
 func (p *PoiService) ListPOIInBox(ctx context.Context, in *poi_v1.ListPOIInBoxRequest) (*poi_v1.ListPOIResponse, error) {
+	conds, err := p.attributeConditions(ctx, in)
+	if err != nil {
+		return nil, err
+	}
 	res, err := p.Database.Queries.ListPOIInBox(ctx, sqlc_db.ListPOIInBoxParams{
 		Column1: in.GetMinX(),
 		Column2: in.GetMinY(),
 		Column3: in.GetMaxX(),
 		Column4: in.GetMaxY(),
+		Column5: conds.required,
+		Column6: conds.excluded,
 	})
 	if err != nil {
 		return nil, err
 	}
+	res, err = p.filterList(ctx, res, in)
+	if err != nil {
+		return nil, err
+	}
+
+	pois, err := p.toPOIs(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &poi_v1.ListPOIResponse{
+		Pois: pois,
+	}, nil
+}
+
+func (p *PoiService) ListPOIByMatchKey(ctx context.Context, in *poi_v1.ListPOIByMatchKeyRequest) (*poi_v1.ListPOIResponse, error) {
+	params := sqlc_db.ListPOIByMatchKeyParams{
+		Column4: in.GetLimit(),
+	}
+	if params.Column4 <= 0 {
+		params.Column4 = defaultMatchKeyLimit
+	}
+	if in.Phone != nil {
+		e164, ok := normalize.Phone(in.GetPhone(), in.GetCountryCode())
+		if !ok {
+			return nil, fmt.Errorf("invalid phone number: %s", in.GetPhone())
+		}
+		params.Column1 = e164
+	}
+	if in.Website != nil {
+		_, host, ok := normalize.Website(in.GetWebsite())
+		if !ok {
+			return nil, fmt.Errorf("invalid website: %s", in.GetWebsite())
+		}
+		params.Column2 = host
+	}
+	if in.Street != nil {
+		params.Column3 = normalize.NewAddress(in.GetStreet(), in.GetPostalCode(), in.GetCity(), in.GetCountryCode()).Key()
+		if params.Column3 == "" {
+			return nil, fmt.Errorf("street requires country_code and a postal_code or city")
+		}
+	}
+	if params.Column1 == "" && params.Column2 == "" && params.Column3 == "" {
+		return nil, fmt.Errorf("one of phone, website or street is required")
+	}
+
+	conds, err := p.attributeConditions(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	params.Column5, params.Column6 = conds.required, conds.excluded
+
+	res, err := p.Database.Queries.ListPOIByMatchKey(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	res, err = p.filterList(ctx, res, in)
+	if err != nil {
+		return nil, err
+	}
 
 	pois, err := p.toPOIs(res)
 	if err != nil {
@@ -340,19 +436,43 @@ func (p *PoiService) ListPOIInBox(ctx context.Context, in *poi_v1.ListPOIInBoxRe
 	}, nil
 }
 
+func (p *PoiService) ListPOIByPlusCode(ctx context.Context, in *poi_v1.ListPOIByPlusCodeRequest) (*poi_v1.ListPOIResponse, error) {
+	code := strings.TrimSpace(in.GetPlusCode())
+	if olc.IsShort(code) {
+		if in.RefLat == nil || in.RefLon == nil {
+			return nil, fmt.Errorf("short plus code %s requires ref_lat and ref_lon", code)
+		}
+		full, err := olc.RecoverNearest(code, in.GetRefLat(), in.GetRefLon())
+		if err != nil {
+			return nil, err
+		}
+		code = full
+	}
+
+	area, err := olc.DecodePrefix(code)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plus code %s: %w", in.GetPlusCode(), err)
+	}
+
+	return p.ListPOIInBox(ctx, &poi_v1.ListPOIInBoxRequest{
+		MinX:              area.LngLo,
+		MinY:              area.LatLo,
+		MaxX:              area.LngHi,
+		MaxY:              area.LatHi,
+		OpenAt:            in.GetOpenAt(),
+		OpenNow:           in.GetOpenNow(),
+		TaxonomyNode:      in.GetTaxonomyNode(),
+		RequireAttributes: in.GetRequireAttributes(),
+		ExcludeAttributes: in.GetExcludeAttributes(),
+	})
+}
+
 func (p *PoiService) ListPOIInBoxWithCategorySearch(ctx context.Context, in *poi_v1.ListPOIInBoxWithCategorySearchRequest) (*poi_v1.ListPOIResponse, error) {
-	res, err := p.Database.Queries.ListPOIInBoxWithCategoryH3(
-		ctx,
-		sqlc_db.ListPOIInBoxWithCategoryH3Params{
-			Column1: in.GetMinX(),
-			Column2: in.GetMinY(),
-			Column3: in.GetMaxX(),
-			Column4: in.GetMaxY(),
-			Column5: pgtype.Text{
-				String: in.GetCategorySubstring(),
-				Valid:  true,
-			},
-		})
+	res, err := p.listPOIInBoxWithCategory(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	res, err = p.filterList(ctx, res, in)
 	if err != nil {
 		return nil, err
 	}
@@ -368,16 +488,26 @@ func (p *PoiService) ListPOIInBoxWithCategorySearch(ctx context.Context, in *poi
 }
 
 func (p *PoiService) ListPOIAlongRoute(ctx context.Context, in *poi_v1.ListPOIAlongRouteRequest) (*poi_v1.ListPOIResponse, error) {
+	conds, err := p.attributeConditions(ctx, in)
+	if err != nil {
+		return nil, err
+	}
 	res, err := p.Database.Queries.ListPOIAlongRouteH3(context.Background(), sqlc_db.ListPOIAlongRouteH3Params{
 		Column1: in.GetALat(),
 		Column2: in.GetALon(),
 		Column3: in.GetBLat(),
 		Column4: in.GetBLon(),
 		Column5: float64(in.GetBuffer()),
+		Column6: conds.required,
+		Column7: conds.excluded,
 	})
 	if err != nil {
 		return nil, err
 	}
+	res, err = p.filterList(ctx, res, in)
+	if err != nil {
+		return nil, err
+	}
 
 	pois, err := p.toPOIs(res)
 	if err != nil {
@@ -389,17 +519,11 @@ func (p *PoiService) ListPOIAlongRoute(ctx context.Context, in *poi_v1.ListPOIAl
 }
 
 func (p *PoiService) ListPOIAlongRouteWithCategorySearch(ctx context.Context, in *poi_v1.ListPOIAlongRouteWithCategoryRequest) (*poi_v1.ListPOIResponse, error) {
-	res, err := p.Database.Queries.ListPOIAlongRouteWithCategoryH3(context.Background(), sqlc_db.ListPOIAlongRouteWithCategoryH3Params{
-		Column1: in.GetALat(),
-		Column2: in.GetALon(),
-		Column3: in.GetBLat(),
-		Column4: in.GetBLon(),
-		Column5: float64(in.GetBuffer()),
-		Column6: pgtype.Text{
-			String: in.GetCategorySubstring(),
-			Valid:  true,
-		},
-	})
+	res, err := p.listPOIAlongRouteWithCategory(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	res, err = p.filterList(ctx, res, in)
 	if err != nil {
 		return nil, err
 	}
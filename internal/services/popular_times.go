@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/models"
+	"apify-poi-data/pkg/hours"
+	poi_v1 "apify-poi-data/proto/apify/poi/v1"
+)
+
+const (
+	defaultQuietestHoursLimit = 10
+	hoursPerWeek              = 7 * 24
+)
+
+// popularTimesWeekdays maps the keys of popularTimesHistogram to weekdays, 0 being Monday.
+var popularTimesWeekdays = map[string]int16{
+	"Mo": 0,
+	"Tu": 1,
+	"We": 2,
+	"Th": 3,
+	"Fr": 4,
+	"Sa": 5,
+	"Su": 6,
+}
+
+// popularTimeHours flattens a popular times histogram into parallel columns.
+// An hour listed twice for a day keeps its last value, as the columns are
+// keyed by weekday and hour.
+func popularTimeHours(histogram map[string][]models.PopularTimes) (weekdays, hrs, occupancy []int16) {
+	for day, entries := range histogram {
+		weekday, ok := popularTimesWeekdays[day]
+		if !ok {
+			continue
+		}
+		seen := make(map[int]int, len(entries)) // Hour to its position in the columns
+		for _, e := range entries {
+			if e.Hour < 0 || e.Hour > 23 {
+				continue
+			}
+			if i, ok := seen[e.Hour]; ok {
+				occupancy[i] = int16(e.OccupancyPercent)
+				continue
+			}
+			seen[e.Hour] = len(weekdays)
+			weekdays = append(weekdays, weekday)
+			hrs = append(hrs, int16(e.Hour))
+			occupancy = append(occupancy, int16(e.OccupancyPercent))
+		}
+	}
+	return weekdays, hrs, occupancy
+}
+
+// storePopularTimes replaces the stored popular times of a scraped place.
+func (m *MapsService) storePopularTimes(ctx context.Context, poi models.POI, lat, lng float64) error {
+	place, ok := poi.(*models.PlaceScraper)
+	if !ok || len(place.PopularTimesHistogram) == 0 {
+		return nil
+	}
+	weekdays, hrs, occupancy := popularTimeHours(place.PopularTimesHistogram)
+	if len(weekdays) == 0 {
+		return nil
+	}
+	timezone := hours.TimezoneAt(lat, lng)
+	if timezone == "" {
+		return fmt.Errorf("no time zone for %s at %f,%f", place.PlaceID, lat, lng)
+	}
+
+	tx, err := m.Database.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	q := m.Database.Queries.WithTx(tx)
+
+	err = q.UpsertPopularTimes(ctx, sqlc_db.UpsertPopularTimesParams{
+		PlaceID:  place.PlaceID,
+		Timezone: timezone,
+	})
+	if err != nil {
+		return err
+	}
+	if err := q.DeletePopularTimeHours(ctx, place.PlaceID); err != nil {
+		return err
+	}
+	err = q.InsertPopularTimeHours(ctx, sqlc_db.InsertPopularTimeHoursParams{
+		Column1: place.PlaceID,
+		Column2: weekdays,
+		Column3: hrs,
+		Column4: occupancy,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (p *PoiService) ListQuietestHours(ctx context.Context, in *poi_v1.ListQuietestHoursRequest) (*poi_v1.ListQuietestHoursResponse, error) {
+	if in.GetPlaceId() == "" {
+		return nil, fmt.Errorf("place_id is required")
+	}
+	weekday := int32(-1)
+	if in.Weekday != nil {
+		weekday = in.GetWeekday()
+		if weekday < 0 || weekday > 6 {
+			return nil, fmt.Errorf("weekday must be between 0 (Monday) and 6 (Sunday)")
+		}
+	}
+	limit := in.GetLimit()
+	if limit <= 0 {
+		limit = defaultQuietestHoursLimit
+	}
+	if limit > hoursPerWeek {
+		limit = hoursPerWeek
+	}
+
+	rows, err := p.Database.Queries.ListQuietestHours(ctx, sqlc_db.ListQuietestHoursParams{
+		Column1: in.GetPlaceId(),
+		Column2: weekday,
+		Column3: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &poi_v1.ListQuietestHoursResponse{}
+	for _, row := range rows {
+		resp.Slots = append(resp.Slots, &poi_v1.PopularTimeSlot{
+			Weekday:          int32(row.Weekday),
+			Hour:             int32(row.Hour),
+			OccupancyPercent: int32(row.OccupancyPercent),
+		})
+	}
+	return resp, nil
+}
+
+func (p *PoiService) ListPOIInBoxUnderOccupancy(ctx context.Context, in *poi_v1.ListPOIInBoxUnderOccupancyRequest) (*poi_v1.ListPOIOccupancyResponse, error) {
+	at := time.Now()
+	if in.GetAt() != "" {
+		t, err := time.Parse(time.RFC3339, in.GetAt())
+		if err != nil {
+			return nil, fmt.Errorf("invalid at: %w", err)
+		}
+		at = t
+	}
+
+	rows, err := p.Database.Queries.ListPOIInBoxUnderOccupancy(ctx, sqlc_db.ListPOIInBoxUnderOccupancyParams{
+		Column1: in.GetMinX(),
+		Column2: in.GetMinY(),
+		Column3: in.GetMaxX(),
+		Column4: in.GetMaxY(),
+		Column5: at,
+		Column6: in.GetMaxOccupancyPercent(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &poi_v1.ListPOIOccupancyResponse{}
+	for _, row := range rows {
+		poi, err := p.toPOI(row.PoiDataSchemaGoogleMap)
+		if err != nil {
+			return nil, err
+		}
+		resp.Pois = append(resp.Pois, &poi_v1.PoiOccupancy{
+			Poi:              poi,
+			OccupancyPercent: int32(row.OccupancyPercent),
+		})
+	}
+	return resp, nil
+}
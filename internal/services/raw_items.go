@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/models"
+	maps_v1 "apify-poi-data/proto/apify/maps/v1"
+)
+
+const (
+	reprocessBatchSize = 500
+)
+
+// scrapedForever bounds reprocessing when no end of the date range is requested.
+var scrapedForever = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// poiLocation returns the coordinates of a decoded POI.
+func poiLocation(poi models.POI) (lat, lng float64, ok bool) {
+	switch p := poi.(type) {
+	case *models.Place:
+		return p.Location.Lat, p.Location.Lng, true
+	case *models.PlaceScraper:
+		return p.Location.Lat, p.Location.Lng, true
+	case *models.Hotel:
+		return p.Latitude, p.Longitude, true
+	case *models.Restaurant:
+		return p.Latitude, p.Longitude, true
+	case *models.Attraction:
+		return p.Latitude, p.Longitude, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// poiScrapedAt returns when a decoded POI was scraped, falling back to now for sources that do not say.
+func poiScrapedAt(poi models.POI) time.Time {
+	var scrapedAt string
+	switch p := poi.(type) {
+	case *models.Place:
+		scrapedAt = p.ScrapedAt
+	case *models.PlaceScraper:
+		scrapedAt = p.ScrapedAt
+	}
+	t, err := time.Parse(time.RFC3339, scrapedAt)
+	if err != nil {
+		return time.Now()
+	}
+	return t
+}
+
+// storeRawItem keeps the source JSON of a POI once per source run.
+func (m *MapsService) storeRawItem(ctx context.Context, parser, sourceRunID string, poi models.POI, raw []byte) error {
+	hash := sha256.Sum256(raw)
+	params := sqlc_db.InsertRawItemParams{
+		ItemID:      poi.GetID(),
+		Parser:      parser,
+		SourceRunID: sourceRunID,
+		ContentHash: hex.EncodeToString(hash[:]),
+		Item:        raw,
+		ScrapedAt: pgtype.Timestamptz{
+			Time:  poiScrapedAt(poi),
+			Valid: true,
+		},
+	}
+	if lat, lng, ok := poiLocation(poi); ok {
+		params.LocationLat = pgtype.Float8{Float64: lat, Valid: true}
+		params.LocationLng = pgtype.Float8{Float64: lng, Valid: true}
+	}
+	return m.Database.Queries.InsertRawItem(ctx, params)
+}
+
+func (m *MapsService) ReprocessRawItems(ctx context.Context, in *maps_v1.ReprocessRequest) (*maps_v1.ReprocessResponse, error) {
+	if in.GetParser() == models.ParserTripadvisor {
+		return nil, fmt.Errorf("%s items cannot be reprocessed", models.ParserTripadvisor)
+	}
+	params := sqlc_db.ListRawItemsParams{
+		Column2:  in.GetSourceRunId(),
+		Column3:  in.GetParser(),
+		Column10: scrapedForever,
+		Column11: reprocessBatchSize,
+	}
+	if region := in.GetRegion(); region != nil {
+		params.Column4 = true
+		params.Column5 = region.GetMinX()
+		params.Column6 = region.GetMinY()
+		params.Column7 = region.GetMaxX()
+		params.Column8 = region.GetMaxY()
+	}
+	if in.ScrapedFrom != nil {
+		t, err := time.Parse(time.RFC3339, in.GetScrapedFrom())
+		if err != nil {
+			return nil, fmt.Errorf("invalid scrapedFrom: %w", err)
+		}
+		params.Column9 = t
+	}
+	if in.ScrapedTo != nil {
+		t, err := time.Parse(time.RFC3339, in.GetScrapedTo())
+		if err != nil {
+			return nil, fmt.Errorf("invalid scrapedTo: %w", err)
+		}
+		params.Column10 = t
+	}
+
+	resp := &maps_v1.ReprocessResponse{
+		Status: "success",
+	}
+	for {
+		rows, err := m.Database.Queries.ListRawItems(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		// Stored items are decoded by the parser that originally read them and upserted
+		items := make([]ingestItem, len(rows))
+		for i, row := range rows {
+			items[i] = ingestItem{
+				parser: row.Parser,
+				raw:    row.Item,
+			}
+		}
+		res := m.ingest(ctx, items, ingestOptions{
+			write: m.upsertPOItoDB,
+		})
+		resp.Processed += int32(res.Completed)
+		resp.Failed += int32(len(res.Errors))
+		for _, e := range res.Errors {
+			resp.ItemErrors = append(resp.ItemErrors, &maps_v1.ItemError{
+				RawItemId: rows[e.Index].ID,
+				Stage:     e.Stage,
+				Message:   e.Err.Error(),
+			})
+		}
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		if len(rows) < reprocessBatchSize {
+			break
+		}
+		params.Column1 = rows[len(rows)-1].ID
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/models"
+	maps_v1 "apify-poi-data/proto/apify/maps/v1"
+)
+
+const (
+	defaultRefreshMaxReviews = 100
+	maxRefreshPlaces         = 500
+)
+
+// reviewRefreshRun is one scraper run over the places whose newest stored
+// review was published on the same day.
+type reviewRefreshRun struct {
+	startDate string // reviewsStartDate, empty to scrape reviews of any age
+	places    []*maps_v1.PlaceReviewRefresh
+	urls      []string
+}
+
+// RefreshReviews scrapes the reviews published since the newest stored review
+// of each place and merges the ones that are not stored yet.
+func (m *MapsService) RefreshReviews(ctx context.Context, in *maps_v1.RefreshReviewsRequest) (*maps_v1.RefreshReviewsResponse, error) {
+	if len(in.GetPlaceIds()) == 0 {
+		return nil, fmt.Errorf("placeIds is required")
+	}
+	if len(in.GetPlaceIds()) > maxRefreshPlaces {
+		return nil, fmt.Errorf("at most %d places can be refreshed at once", maxRefreshPlaces)
+	}
+	maxReviews := int(in.GetMaxReviews())
+	if maxReviews <= 0 {
+		maxReviews = defaultRefreshMaxReviews
+	}
+
+	targets, err := m.Database.Queries.ListReviewRefreshTargets(ctx, in.GetPlaceIds())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &maps_v1.RefreshReviewsResponse{Status: "success"}
+	byPlace := map[string]*maps_v1.PlaceReviewRefresh{}
+	runs := map[string]*reviewRefreshRun{}
+	for _, t := range targets {
+		place := &maps_v1.PlaceReviewRefresh{PlaceId: t.PlaceID.String}
+		resp.Places = append(resp.Places, place)
+		byPlace[place.PlaceId] = place
+		if !t.Url.Valid || t.Url.String == "" {
+			place.Error = stringPtr("place has no Google Maps URL")
+			continue
+		}
+
+		// Reviews from the day of the newest stored review are scraped again;
+		// they are already stored and are skipped when merging.
+		var startDate string
+		if newest, ok := t.NewestReviewAt.(time.Time); ok {
+			startDate = newest.UTC().Format(time.DateOnly)
+			place.ReviewsStartDate = &startDate
+		}
+		run, ok := runs[startDate]
+		if !ok {
+			run = &reviewRefreshRun{startDate: startDate}
+			runs[startDate] = run
+		}
+		run.places = append(run.places, place)
+		run.urls = append(run.urls, t.Url.String)
+	}
+	for _, id := range in.GetPlaceIds() {
+		if _, ok := byPlace[id]; !ok {
+			resp.Places = append(resp.Places, &maps_v1.PlaceReviewRefresh{
+				PlaceId: id,
+				Error:   stringPtr("place not found"),
+			})
+		}
+	}
+
+	// Start every run before waiting, the scraper runs them in parallel.
+	dates := make([]string, 0, len(runs))
+	for date := range runs {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	pending := make([]func() error, 0, len(dates))
+	for _, date := range dates {
+		run := runs[date]
+		payload := models.ScraperInputPayloadMaps{
+			StartUrls:        make([]map[string]string, 0, len(run.urls)),
+			Language:         in.GetLanguage(),
+			MaxReviews:       maxReviews,
+			ReviewsStartDate: run.startDate,
+			ReviewsSort:      "newest",
+		}
+		for _, u := range run.urls {
+			payload.StartUrls = append(payload.StartUrls, map[string]string{"url": u})
+		}
+		res := m.ApifyClient.ScrapePOIs(payload, true)
+		for _, place := range run.places {
+			place.RunId = res.RunID
+		}
+
+		pending = append(pending, func() error {
+			select {
+			case data := <-res.Data:
+				for _, poi := range data.POIs {
+					place, ok := byPlace[poi.GetID()]
+					if !ok {
+						continue
+					}
+					n, err := m.mergeNewReviews(ctx, poi)
+					if err != nil {
+						log.Printf("Failed to merge reviews of %s: %v", poi.GetID(), err)
+						place.Error = stringPtr(err.Error())
+					}
+					place.NewReviews += int32(n)
+					resp.NewReviews += int32(n)
+				}
+			case err := <-res.Err:
+				log.Printf("Review refresh run %s failed: %v", res.RunID, err)
+				for _, place := range run.places {
+					place.Error = stringPtr(err.Error())
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		})
+	}
+	for _, wait := range pending {
+		if err := wait(); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// mergeNewReviews stores the reviews of a scraped place that are not stored yet
+// and returns how many there were.
+func (m *MapsService) mergeNewReviews(ctx context.Context, poi models.POI) (int, error) {
+	place, ok := poi.(*models.PlaceScraper)
+	if !ok {
+		return 0, nil
+	}
+	reviews, err := models.ParseReviews(place.Reviews)
+	if err != nil {
+		return 0, fmt.Errorf("decoding reviews of %s: %w", place.PlaceID, err)
+	}
+	var merged int
+	for _, r := range reviews {
+		params, err := reviewParams(place.PlaceID, r)
+		if err != nil {
+			log.Printf("Skipping review %s of %s: %v", r.ReviewID, place.PlaceID, err)
+			continue
+		}
+		n, err := m.Database.Queries.InsertNewReview(ctx, sqlc_db.InsertNewReviewParams(params))
+		if err != nil {
+			return merged, fmt.Errorf("storing review %s: %w", r.ReviewID, err)
+		}
+		merged += int(n)
+	}
+	return merged, nil
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
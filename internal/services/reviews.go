@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/models"
+	poi_v1 "apify-poi-data/proto/apify/poi/v1"
+)
+
+const (
+	defaultReviewLimit = 50
+	maxReviewLimit     = 500
+)
+
+// storeReviews writes the reviews of a scraped place to the reviews table.
+// Reviews seen in an earlier scrape are updated in place.
+func (m *MapsService) storeReviews(ctx context.Context, poi models.POI) error {
+	place, ok := poi.(*models.PlaceScraper)
+	if !ok {
+		return nil
+	}
+	reviews, err := models.ParseReviews(place.Reviews)
+	if err != nil {
+		return fmt.Errorf("decoding reviews of %s: %w", place.PlaceID, err)
+	}
+	for _, r := range reviews {
+		params, err := reviewParams(place.PlaceID, r)
+		if err != nil {
+			log.Printf("Skipping review %s of %s: %v", r.ReviewID, place.PlaceID, err)
+			continue
+		}
+		if err := m.Database.Queries.UpsertReview(ctx, params); err != nil {
+			return fmt.Errorf("storing review %s: %w", r.ReviewID, err)
+		}
+	}
+	return nil
+}
+
+func reviewParams(placeID string, r models.Review) (sqlc_db.UpsertReviewParams, error) {
+	publishedAt, err := time.Parse(time.RFC3339, r.PublishedAtDate)
+	if err != nil {
+		return sqlc_db.UpsertReviewParams{}, fmt.Errorf("invalid publishedAtDate %q", r.PublishedAtDate)
+	}
+	params := sqlc_db.UpsertReviewParams{
+		ReviewID:             r.ReviewID,
+		PlaceID:              placeID,
+		Text:                 textFromPtr(r.Text),
+		TextTranslated:       textFromPtr(r.TextTranslated),
+		Language:             textFromPtr(r.OriginalLanguage),
+		PublishedAt:          publishedAt,
+		OwnerResponseText:    textFromPtr(r.ResponseFromOwnerText),
+		LikesCount:           int4FromPtr(r.LikesCount),
+		ReviewUrl:            textFromString(r.ReviewURL),
+		ReviewOrigin:         textFromString(r.ReviewOrigin),
+		ImageUrls:            r.ReviewImageURLs,
+		ReviewerID:           textFromString(r.ReviewerID),
+		ReviewerName:         textFromString(r.Name),
+		ReviewerUrl:          textFromString(r.ReviewerURL),
+		ReviewerPhotoUrl:     textFromString(r.ReviewerPhotoURL),
+		ReviewerReviewCount:  int4FromPtr(r.ReviewerNumberOfReviews),
+		ReviewerIsLocalGuide: boolFromPtr(r.IsLocalGuide),
+	}
+	if r.Stars != nil {
+		params.Rating = pgtype.Int2{Int16: int16(*r.Stars), Valid: true}
+	}
+	if r.ResponseFromOwnerDate != nil {
+		if t, err := time.Parse(time.RFC3339, *r.ResponseFromOwnerDate); err == nil {
+			params.OwnerResponseAt = pgtype.Timestamptz{Time: t, Valid: true}
+		}
+	}
+	return params, nil
+}
+
+func (p *PoiService) ListReviews(ctx context.Context, in *poi_v1.ListReviewsRequest) (*poi_v1.ListReviewsResponse, error) {
+	limit := in.GetLimit()
+	if limit <= 0 {
+		limit = defaultReviewLimit
+	}
+	if limit > maxReviewLimit {
+		limit = maxReviewLimit
+	}
+
+	params := sqlc_db.ListReviewsParams{
+		Column1: in.GetPlaceId(),
+		Column2: time.Time{},
+		Column3: scrapedForever,
+		Column4: in.GetMinRating(),
+		Column5: in.GetMaxRating(),
+		Column6: strings.TrimSpace(in.GetQuery()),
+		Column7: scrapedForever,
+		Column8: 0,
+		Column9: limit,
+	}
+	if s := in.GetPublishedAfter(); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid published_after: %w", err)
+		}
+		params.Column2 = t
+	}
+	if s := in.GetPublishedBefore(); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid published_before: %w", err)
+		}
+		params.Column3 = t
+	}
+	if tok := in.GetPageToken(); tok != "" {
+		after, id, err := decodeReviewPageToken(tok)
+		if err != nil {
+			return nil, err
+		}
+		params.Column7, params.Column8 = after, id
+	}
+
+	rows, err := p.Database.Queries.ListReviews(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &poi_v1.ListReviewsResponse{}
+	for _, row := range rows {
+		resp.Reviews = append(resp.Reviews, toReview(row))
+	}
+	if len(rows) == int(limit) {
+		last := rows[len(rows)-1]
+		resp.NextPageToken = encodeReviewPageToken(last.PublishedAt, last.ID)
+	}
+	return resp, nil
+}
+
+// Page tokens hold the publish time and ID of the last review of a page.
+func encodeReviewPageToken(publishedAt time.Time, id int64) string {
+	tok := fmt.Sprintf("%d:%d", publishedAt.UnixNano(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(tok))
+}
+
+func decodeReviewPageToken(tok string) (time.Time, int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(tok)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid page_token")
+	}
+	nanos, id, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf("invalid page_token")
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid page_token")
+	}
+	i, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid page_token")
+	}
+	return time.Unix(0, n).UTC(), i, nil
+}
+
+func toReview(row sqlc_db.PoiDataSchemaReview) *poi_v1.Review {
+	r := &poi_v1.Review{
+		ReviewId:             row.ReviewID,
+		PlaceId:              row.PlaceID,
+		Text:                 textPtr(row.Text),
+		TextTranslated:       textPtr(row.TextTranslated),
+		Language:             textPtr(row.Language),
+		PublishedAt:          row.PublishedAt.Format(time.RFC3339),
+		OwnerResponseText:    textPtr(row.OwnerResponseText),
+		LikesCount:           int4Ptr(row.LikesCount),
+		ReviewUrl:            textPtr(row.ReviewUrl),
+		ReviewOrigin:         textPtr(row.ReviewOrigin),
+		ImageUrls:            row.ImageUrls,
+		ReviewerId:           textPtr(row.ReviewerID),
+		ReviewerName:         textPtr(row.ReviewerName),
+		ReviewerUrl:          textPtr(row.ReviewerUrl),
+		ReviewerPhotoUrl:     textPtr(row.ReviewerPhotoUrl),
+		ReviewerReviewCount:  int4Ptr(row.ReviewerReviewCount),
+		ReviewerIsLocalGuide: boolPtr(row.ReviewerIsLocalGuide),
+	}
+	if row.Rating.Valid {
+		rating := int32(row.Rating.Int16)
+		r.Rating = &rating
+	}
+	if row.OwnerResponseAt.Valid {
+		at := row.OwnerResponseAt.Time.Format(time.RFC3339)
+		r.OwnerResponseAt = &at
+	}
+	return r
+}
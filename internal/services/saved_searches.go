@@ -0,0 +1,393 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/services/converter"
+	"apify-poi-data/pkg/apify"
+	"apify-poi-data/pkg/cron"
+	"apify-poi-data/pkg/geo"
+	maps_v1 "apify-poi-data/proto/apify/maps/v1"
+)
+
+const (
+	defaultSavedSearchLimit = 100
+	maxSavedSearchLimit     = 1000
+)
+
+// Kinds of saved search, by the actor that scrapes them.
+const (
+	savedSearchScraper   = "scraper"
+	savedSearchExtractor = "extractor"
+)
+
+// What started a saved search run, and the statuses it goes through.
+const (
+	triggerSchedule = "schedule"
+	triggerManual   = "manual"
+
+	runRunning   = "running"
+	runSucceeded = "succeeded"
+	runFailed    = "failed"
+)
+
+// savedSearchRow validates a saved search and converts it to its stored form.
+// The next run is computed from the schedule, starting now.
+func savedSearchRow(in *maps_v1.SavedSearch) (sqlc_db.CreateSavedSearchParams, error) {
+	if in.GetName() == "" {
+		return sqlc_db.CreateSavedSearchParams{}, errors.New("name is required")
+	}
+
+	var kind string
+	var request []byte
+	var err error
+	switch r := in.GetRequest().(type) {
+	case *maps_v1.SavedSearch_Scraper:
+		kind = savedSearchScraper
+		request, err = protojson.Marshal(r.Scraper)
+	case *maps_v1.SavedSearch_Extractor:
+		kind = savedSearchExtractor
+		request, err = protojson.Marshal(r.Extractor)
+	default:
+		return sqlc_db.CreateSavedSearchParams{}, errors.New("one of scraper or extractor is required")
+	}
+	if err != nil {
+		return sqlc_db.CreateSavedSearchParams{}, err
+	}
+
+	timezone := in.GetTimezone()
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	next, err := nextSavedSearchRun(in.GetSchedule(), timezone, time.Now())
+	if err != nil {
+		return sqlc_db.CreateSavedSearchParams{}, err
+	}
+
+	return sqlc_db.CreateSavedSearchParams{
+		Name:      in.GetName(),
+		Kind:      kind,
+		Request:   request,
+		Schedule:  in.GetSchedule(),
+		Timezone:  timezone,
+		Enabled:   in.GetEnabled(),
+		NextRunAt: next,
+	}, nil
+}
+
+// nextSavedSearchRun returns when a schedule fires next after now, reading it
+// in timezone. It is NULL when the schedule never fires again.
+func nextSavedSearchRun(schedule, timezone string, now time.Time) (pgtype.Timestamptz, error) {
+	s, err := cron.Parse(schedule)
+	if err != nil {
+		return pgtype.Timestamptz{}, err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return pgtype.Timestamptz{}, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	next := s.Next(now.In(loc))
+	return pgtype.Timestamptz{Time: next, Valid: !next.IsZero()}, nil
+}
+
+func (m *MapsService) CreateSavedSearch(ctx context.Context, in *maps_v1.SavedSearch) (*maps_v1.SavedSearch, error) {
+	params, err := savedSearchRow(in)
+	if err != nil {
+		return nil, err
+	}
+	row, err := m.Database.Queries.CreateSavedSearch(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return toSavedSearch(row)
+}
+
+func (m *MapsService) GetSavedSearch(ctx context.Context, in *maps_v1.GetSavedSearchRequest) (*maps_v1.SavedSearch, error) {
+	row, err := m.getSavedSearch(ctx, in.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return toSavedSearch(row)
+}
+
+func (m *MapsService) getSavedSearch(ctx context.Context, id int64) (sqlc_db.PoiDataSchemaSavedSearch, error) {
+	row, err := m.Database.Queries.GetSavedSearch(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return row, fmt.Errorf("saved search %d not found", id)
+	}
+	return row, err
+}
+
+func (m *MapsService) ListSavedSearches(ctx context.Context, in *maps_v1.ListSavedSearchesRequest) (*maps_v1.ListSavedSearchesResponse, error) {
+	limit := in.GetLimit()
+	if limit <= 0 {
+		limit = defaultSavedSearchLimit
+	}
+	if limit > maxSavedSearchLimit {
+		limit = maxSavedSearchLimit
+	}
+
+	rows, err := m.Database.Queries.ListSavedSearches(ctx, sqlc_db.ListSavedSearchesParams{
+		ID:    in.GetAfterId(),
+		Limit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &maps_v1.ListSavedSearchesResponse{}
+	for _, row := range rows {
+		search, err := toSavedSearch(row)
+		if err != nil {
+			return nil, err
+		}
+		resp.SavedSearches = append(resp.SavedSearches, search)
+	}
+	if len(rows) == int(limit) {
+		resp.NextAfterId = rows[len(rows)-1].ID
+	}
+	return resp, nil
+}
+
+func (m *MapsService) UpdateSavedSearch(ctx context.Context, in *maps_v1.SavedSearch) (*maps_v1.SavedSearch, error) {
+	params, err := savedSearchRow(in)
+	if err != nil {
+		return nil, err
+	}
+	row, err := m.Database.Queries.UpdateSavedSearch(ctx, sqlc_db.UpdateSavedSearchParams{
+		ID:        in.GetId(),
+		Name:      params.Name,
+		Kind:      params.Kind,
+		Request:   params.Request,
+		Schedule:  params.Schedule,
+		Timezone:  params.Timezone,
+		Enabled:   params.Enabled,
+		NextRunAt: params.NextRunAt,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("saved search %d not found", in.GetId())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toSavedSearch(row)
+}
+
+func (m *MapsService) DeleteSavedSearch(ctx context.Context, in *maps_v1.DeleteSavedSearchRequest) (*maps_v1.DeleteSavedSearchResponse, error) {
+	deleted, err := m.Database.Queries.DeleteSavedSearch(ctx, in.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if deleted == 0 {
+		return nil, fmt.Errorf("saved search %d not found", in.GetId())
+	}
+	return &maps_v1.DeleteSavedSearchResponse{Status: "deleted"}, nil
+}
+
+func (m *MapsService) RunSavedSearch(ctx context.Context, in *maps_v1.RunSavedSearchRequest) (*maps_v1.SavedSearchRun, error) {
+	search, err := m.getSavedSearch(ctx, in.GetId())
+	if err != nil {
+		return nil, err
+	}
+	runID, err := m.Database.Queries.StartSavedSearchRun(ctx, sqlc_db.StartSavedSearchRunParams{
+		SavedSearchID: search.ID,
+		Trigger:       triggerManual,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The scrape takes minutes, the caller follows it with ListSavedSearchRuns
+	go m.runSavedSearch(context.WithoutCancel(ctx), search, runID)
+
+	return &maps_v1.SavedSearchRun{
+		Id:            runID,
+		SavedSearchId: search.ID,
+		Trigger:       triggerManual,
+		Status:        runRunning,
+		StartedAt:     time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+func (m *MapsService) ListSavedSearchRuns(ctx context.Context, in *maps_v1.ListSavedSearchRunsRequest) (*maps_v1.ListSavedSearchRunsResponse, error) {
+	limit := in.GetLimit()
+	if limit <= 0 {
+		limit = defaultSavedSearchLimit
+	}
+	if limit > maxSavedSearchLimit {
+		limit = maxSavedSearchLimit
+	}
+
+	rows, err := m.Database.Queries.ListSavedSearchRuns(ctx, sqlc_db.ListSavedSearchRunsParams{
+		SavedSearchID: in.GetSavedSearchId(),
+		Column2:       in.GetBeforeId(),
+		Limit:         limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &maps_v1.ListSavedSearchRunsResponse{}
+	for _, row := range rows {
+		resp.Runs = append(resp.Runs, toSavedSearchRun(row))
+	}
+	if len(rows) == int(limit) {
+		resp.NextBeforeId = rows[len(rows)-1].ID
+	}
+	return resp, nil
+}
+
+// searchOutcome counts what a saved search run scraped and ingested.
+type searchOutcome struct {
+	apifyRunID string
+	items      int
+	inserted   int
+	failed     int
+}
+
+// runSavedSearch scrapes a saved search, ingests the results and records the
+// outcome on its run.
+func (m *MapsService) runSavedSearch(ctx context.Context, search sqlc_db.PoiDataSchemaSavedSearch, runID int64) {
+	log.Printf("Running saved search %d (%s)", search.ID, search.Name)
+	out, err := m.scrapeSavedSearch(ctx, search)
+
+	params := sqlc_db.FinishSavedSearchRunParams{
+		ID:            runID,
+		Status:        runSucceeded,
+		ApifyRunID:    textFromString(out.apifyRunID),
+		ItemCount:     int32(out.items),
+		InsertedCount: int32(out.inserted),
+		FailedCount:   int32(out.failed),
+	}
+	if err != nil {
+		log.Printf("Saved search %d failed: %v", search.ID, err)
+		params.Status = runFailed
+		params.Error = textFromString(err.Error())
+	}
+	// The run is recorded even when the scrape was cancelled by a shutdown
+	if err := m.Database.Queries.FinishSavedSearchRun(context.WithoutCancel(ctx), params); err != nil {
+		log.Printf("Failed to record saved search run %d: %v", runID, err)
+	}
+}
+
+// scrapeSavedSearch starts the actor run of a saved search and ingests its dataset.
+func (m *MapsService) scrapeSavedSearch(ctx context.Context, search sqlc_db.PoiDataSchemaSavedSearch) (searchOutcome, error) {
+	var resp apify.POIResponse
+	var area geo.Area
+	switch search.Kind {
+	case savedSearchScraper:
+		var req maps_v1.ScraperRequest
+		if err := protojson.Unmarshal(search.Request, &req); err != nil {
+			return searchOutcome{}, fmt.Errorf("decoding saved request: %w", err)
+		}
+		payload, err := converter.SearchRequestToInputPayloadMapsScraper(&req)
+		if err != nil {
+			return searchOutcome{}, err
+		}
+		resp = m.ApifyClient.ScrapePOIs(payload, true)
+		area = customGeolocationArea(req.GetCustomGeolocation())
+	case savedSearchExtractor:
+		var req maps_v1.SearchRequest
+		if err := protojson.Unmarshal(search.Request, &req); err != nil {
+			return searchOutcome{}, fmt.Errorf("decoding saved request: %w", err)
+		}
+		payload, err := converter.SearchRequestToInputPayloadMaps(&req)
+		if err != nil {
+			return searchOutcome{}, err
+		}
+		resp = m.ApifyClient.ExtractPOIs(payload, int(req.GetNumberOfResults()), true)
+		area = customGeolocationArea(req.GetCustomGeolocation())
+	default:
+		return searchOutcome{}, fmt.Errorf("unknown saved search kind: %s", search.Kind)
+	}
+
+	out := searchOutcome{apifyRunID: resp.RunID}
+	select {
+	case data := <-resp.Data:
+		out.items = len(data.POIs) + len(data.Errors)
+		// Places are upserted so that changes since the last run are detected
+		res := m.ingestParseResult(ctx, data, resp.RunID, area, m.upsertPOItoDB)
+		out.inserted, out.failed = res.Completed, len(res.Errors)+len(data.Errors)
+		// An empty result more likely means a failed scrape than that every place is gone
+		if len(data.POIs) > 0 {
+			placeIDs := make([]string, 0, len(data.POIs))
+			for _, poi := range data.POIs {
+				if id := poi.GetID(); id != "" {
+					placeIDs = append(placeIDs, id)
+				}
+			}
+			if err := m.trackSavedSearchPlaces(ctx, search.ID, placeIDs); err != nil {
+				log.Printf("Failed to track the places of saved search %d: %v", search.ID, err)
+			}
+		}
+		return out, nil
+	case err := <-resp.Err:
+		return out, err
+	case <-ctx.Done():
+		return out, ctx.Err()
+	}
+}
+
+func toSavedSearch(row sqlc_db.PoiDataSchemaSavedSearch) (*maps_v1.SavedSearch, error) {
+	search := &maps_v1.SavedSearch{
+		Id:        row.ID,
+		Name:      row.Name,
+		Schedule:  row.Schedule,
+		Timezone:  row.Timezone,
+		Enabled:   row.Enabled,
+		CreatedAt: row.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: row.UpdatedAt.Format(time.RFC3339),
+	}
+	switch row.Kind {
+	case savedSearchScraper:
+		req := &maps_v1.ScraperRequest{}
+		if err := protojson.Unmarshal(row.Request, req); err != nil {
+			return nil, err
+		}
+		search.Request = &maps_v1.SavedSearch_Scraper{Scraper: req}
+	case savedSearchExtractor:
+		req := &maps_v1.SearchRequest{}
+		if err := protojson.Unmarshal(row.Request, req); err != nil {
+			return nil, err
+		}
+		search.Request = &maps_v1.SavedSearch_Extractor{Extractor: req}
+	}
+	if row.NextRunAt.Valid {
+		next := row.NextRunAt.Time.Format(time.RFC3339)
+		search.NextRunAt = &next
+	}
+	if row.LastRunAt.Valid {
+		last := row.LastRunAt.Time.Format(time.RFC3339)
+		search.LastRunAt = &last
+	}
+	return search, nil
+}
+
+func toSavedSearchRun(row sqlc_db.PoiDataSchemaSavedSearchRun) *maps_v1.SavedSearchRun {
+	run := &maps_v1.SavedSearchRun{
+		Id:            row.ID,
+		SavedSearchId: row.SavedSearchID,
+		Trigger:       row.Trigger,
+		Status:        row.Status,
+		ApifyRunId:    textPtr(row.ApifyRunID),
+		ItemCount:     row.ItemCount,
+		Inserted:      row.InsertedCount,
+		Failed:        row.FailedCount,
+		Error:         textPtr(row.Error),
+		StartedAt:     row.StartedAt.Format(time.RFC3339),
+	}
+	if row.FinishedAt.Valid {
+		finished := row.FinishedAt.Time.Format(time.RFC3339)
+		run.FinishedAt = &finished
+	}
+	return run
+}
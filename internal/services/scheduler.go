@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"apify-poi-data/config"
+	sqlc_db "apify-poi-data/db/sqlc"
+)
+
+// Scheduler starts saved searches when their schedule is due. Several
+// instances may share a database: due searches are claimed with
+// FOR UPDATE SKIP LOCKED, so each run is started by one of them only.
+type Scheduler struct {
+	Maps   *MapsService
+	Config config.Scheduler
+
+	slots chan struct{} // Held by each running search
+	wg    sync.WaitGroup
+}
+
+func NewScheduler(cfg config.Scheduler, maps *MapsService) *Scheduler {
+	return &Scheduler{
+		Maps:   maps,
+		Config: cfg,
+		slots:  make(chan struct{}, cfg.Workers),
+	}
+}
+
+// Run polls for due searches until ctx is done, then waits for the running
+// searches, which are cancelled with it, to record their outcome.
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.Config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.startDue(ctx)
+		select {
+		case <-ctx.Done():
+			s.wg.Wait()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// startDue starts as many due searches as there are free workers.
+func (s *Scheduler) startDue(ctx context.Context) {
+	free := cap(s.slots) - len(s.slots)
+	if free == 0 {
+		return
+	}
+	searches, err := s.claimDue(ctx, free)
+	if err != nil {
+		log.Printf("Failed to claim due saved searches: %v", err)
+		return
+	}
+
+	for _, search := range searches {
+		runID, err := s.Maps.Database.Queries.StartSavedSearchRun(ctx, sqlc_db.StartSavedSearchRunParams{
+			SavedSearchID: search.ID,
+			Trigger:       triggerSchedule,
+		})
+		if err != nil {
+			log.Printf("Failed to start saved search %d: %v", search.ID, err)
+			continue
+		}
+
+		s.slots <- struct{}{}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() { <-s.slots }()
+			s.Maps.runSavedSearch(ctx, search, runID)
+		}()
+	}
+}
+
+// claimDue locks up to limit due searches and moves each to its next run
+// before committing, so no other scheduler picks them up again.
+func (s *Scheduler) claimDue(ctx context.Context, limit int) ([]sqlc_db.PoiDataSchemaSavedSearch, error) {
+	tx, err := s.Maps.Database.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	q := s.Maps.Database.Queries.WithTx(tx)
+
+	searches, err := q.ClaimDueSavedSearches(ctx, int32(limit))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, search := range searches {
+		next, err := nextSavedSearchRun(search.Schedule, search.Timezone, now)
+		if err != nil {
+			// Stored schedules were validated, this one is not run again until fixed
+			log.Printf("Invalid schedule of saved search %d: %v", search.ID, err)
+		}
+		err = q.SetSavedSearchNextRun(ctx, sqlc_db.SetSavedSearchNextRunParams{
+			ID:        search.ID,
+			NextRunAt: next,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return searches, tx.Commit(ctx)
+}
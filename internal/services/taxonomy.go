@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/models"
+	"apify-poi-data/pkg/taxonomy"
+	poi_v1 "apify-poi-data/proto/apify/poi/v1"
+)
+
+// categoryBackfillBatchSize is the number of stored places categorized per query
+// when a new taxonomy version is stored.
+const categoryBackfillBatchSize = 1000
+
+// poiCategories are the taxonomy nodes a POI is categorized in, ready to be stored.
+type poiCategories struct {
+	source string
+	poiID  string
+	nodes  []string
+}
+
+// categorizePOI maps the source categories of a decoded POI onto the current
+// taxonomy. It returns nil for POIs without any mapped category.
+func categorizePOI(poi models.POI) *poiCategories {
+	source, id, categories := sourceCategories(poi)
+	if source == "" {
+		return nil
+	}
+	nodes := taxonomy.Current.Map(source, categories...)
+	if id == "" || len(nodes) == 0 {
+		return nil
+	}
+	return &poiCategories{source: source, poiID: id, nodes: nodes}
+}
+
+// sourceCategories returns the source, id and source categories of a decoded
+// POI. The source is empty for types that are not categorized.
+func sourceCategories(poi models.POI) (source, id string, categories []string) {
+	switch p := poi.(type) {
+	case *models.Place:
+		return sourceGoogleMaps, p.PlaceID, append([]string{p.CategoryName}, p.Categories...)
+	case *models.PlaceScraper:
+		return sourceGoogleMaps, p.PlaceID, append([]string{p.CategoryName}, p.Categories...)
+	case *models.Hotel:
+		return sourceTripadvisor, p.GetID(), tripadvisorCategories(&p.BasePOI)
+	case *models.Restaurant:
+		categories = tripadvisorCategories(&p.BasePOI)
+		categories = append(categories, p.Cuisines...)
+		categories = append(categories, p.EstablishmentTypes...)
+		return sourceTripadvisor, p.GetID(), categories
+	case *models.Attraction:
+		return sourceTripadvisor, p.GetID(), tripadvisorCategories(&p.BasePOI)
+	default:
+		return "", "", nil
+	}
+}
+
+func tripadvisorCategories(p *models.BasePOI) []string {
+	categories := append([]string{p.Category}, p.Subcategories...)
+	return append(categories, p.Subtype...)
+}
+
+// storePOICategories replaces the categories of a POI in the current taxonomy version.
+func (m *MapsService) storePOICategories(ctx context.Context, c *poiCategories) error {
+	tx, err := m.Database.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	q := m.Database.Queries.WithTx(tx)
+
+	err = q.DeletePOICategories(ctx, sqlc_db.DeletePOICategoriesParams{
+		Source:  c.source,
+		PoiID:   c.poiID,
+		Version: int32(taxonomy.Current.Version),
+	})
+	if err != nil {
+		return err
+	}
+	err = q.InsertPOICategories(ctx, sqlc_db.InsertPOICategoriesParams{
+		Column1: c.source,
+		Column2: c.poiID,
+		Column3: int32(taxonomy.Current.Version),
+		Column4: c.nodes,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SyncTaxonomy stores a taxonomy version with its nodes and mappings, unless
+// that version is stored already. Stored versions are never changed. The
+// places stored before a new version are categorized in it as part of the same
+// transaction, so the taxonomy filter does not drop them.
+func SyncTaxonomy(ctx context.Context, db *sqlc_db.Database, t *taxonomy.Taxonomy) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	q := db.Queries.WithTx(tx)
+
+	inserted, err := q.InsertTaxonomyVersion(ctx, int32(t.Version))
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return nil
+	}
+
+	paths := make([]string, len(t.Nodes))
+	names := make([]string, len(t.Nodes))
+	for i, n := range t.Nodes {
+		paths[i], names[i] = n.Path, n.Name
+	}
+	err = q.InsertTaxonomyNodes(ctx, sqlc_db.InsertTaxonomyNodesParams{
+		Column1: int32(t.Version),
+		Column2: paths,
+		Column3: names,
+	})
+	if err != nil {
+		return fmt.Errorf("storing taxonomy nodes: %w", err)
+	}
+
+	var sources, languages, categories, nodes []string
+	for _, mp := range t.Mappings {
+		sources = append(sources, mp.Source)
+		languages = append(languages, mp.Language)
+		categories = append(categories, mp.Category)
+		nodes = append(nodes, mp.Node)
+	}
+	err = q.InsertCategoryMappings(ctx, sqlc_db.InsertCategoryMappingsParams{
+		Column1: int32(t.Version),
+		Column2: sources,
+		Column3: languages,
+		Column4: categories,
+		Column5: nodes,
+	})
+	if err != nil {
+		return fmt.Errorf("storing category mappings: %w", err)
+	}
+	if err := backfillGoogleMapsCategories(ctx, q, t); err != nil {
+		return fmt.Errorf("categorizing stored Google Maps places: %w", err)
+	}
+	if err := backfillTripadvisorCategories(ctx, q, t); err != nil {
+		return fmt.Errorf("categorizing stored Tripadvisor places: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// backfillGoogleMapsCategories categorizes the stored Google Maps places in
+// version t from the categories they were scraped with.
+func backfillGoogleMapsCategories(ctx context.Context, q *sqlc_db.Queries, t *taxonomy.Taxonomy) error {
+	params := sqlc_db.ListGoogleMapsCategoriesParams{Column2: categoryBackfillBatchSize}
+	for {
+		rows, err := q.ListGoogleMapsCategories(ctx, params)
+		if err != nil {
+			return err
+		}
+		var ids, nodes []string
+		for _, row := range rows {
+			for _, node := range t.Map(sourceGoogleMaps, append([]string{row.CategoryName}, row.Categories...)...) {
+				ids = append(ids, row.PlaceID)
+				nodes = append(nodes, node)
+			}
+		}
+		if err := insertCategoryRows(ctx, q, sourceGoogleMaps, t.Version, ids, nodes); err != nil {
+			return err
+		}
+		if len(rows) < categoryBackfillBatchSize {
+			return nil
+		}
+		params.Column1 = int64(rows[len(rows)-1].ID)
+	}
+}
+
+// backfillTripadvisorCategories categorizes the stored Tripadvisor places in
+// version t. Their categories are read from the stored source items, since not
+// all of them have a column.
+func backfillTripadvisorCategories(ctx context.Context, q *sqlc_db.Queries, t *taxonomy.Taxonomy) error {
+	parser, ok := models.DefaultRegistry.Lookup(models.ParserTripadvisor)
+	if !ok {
+		return fmt.Errorf("no parser for %s", models.ParserTripadvisor)
+	}
+	params := sqlc_db.ListTripadvisorDetailsParams{Column2: categoryBackfillBatchSize}
+	for {
+		rows, err := q.ListTripadvisorDetails(ctx, params)
+		if err != nil {
+			return err
+		}
+		var ids, nodes []string
+		for _, row := range rows {
+			poi, _, err := parser.Decode(row.Details, models.ParseLenient)
+			if err != nil {
+				log.Printf("Not categorizing %s: %v", row.LocationID, err)
+				continue
+			}
+			if poi == nil {
+				continue
+			}
+			_, _, categories := sourceCategories(poi)
+			for _, node := range t.Map(sourceTripadvisor, categories...) {
+				ids = append(ids, row.LocationID)
+				nodes = append(nodes, node)
+			}
+		}
+		if err := insertCategoryRows(ctx, q, sourceTripadvisor, t.Version, ids, nodes); err != nil {
+			return err
+		}
+		if len(rows) < categoryBackfillBatchSize {
+			return nil
+		}
+		params.Column1 = rows[len(rows)-1].LocationID
+	}
+}
+
+// insertCategoryRows stores the pairs of POI ids and nodes of one source.
+func insertCategoryRows(ctx context.Context, q *sqlc_db.Queries, source string, version int, ids, nodes []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return q.InsertPOICategoryRows(ctx, sqlc_db.InsertPOICategoryRowsParams{
+		Column1: source,
+		Column2: int32(version),
+		Column3: ids,
+		Column4: nodes,
+	})
+}
+
+// listFilter is implemented by the list requests that can be narrowed down
+// after the spatial query.
+type listFilter interface {
+	openFilter
+	GetTaxonomyNode() string
+}
+
+// filterList applies the opening hours and taxonomy filters of a list request.
+// Attribute filters are part of the list queries.
+func (p *PoiService) filterList(ctx context.Context, res []sqlc_db.PoiDataSchemaGoogleMap, in listFilter) ([]sqlc_db.PoiDataSchemaGoogleMap, error) {
+	res, err := p.filterOpen(ctx, res, in)
+	if err != nil {
+		return nil, err
+	}
+	return p.filterTaxonomy(ctx, res, in.GetTaxonomyNode())
+}
+
+// filterTaxonomy keeps the POIs categorized in node or one of its descendants.
+func (p *PoiService) filterTaxonomy(ctx context.Context, res []sqlc_db.PoiDataSchemaGoogleMap, node string) ([]sqlc_db.PoiDataSchemaGoogleMap, error) {
+	if node == "" || len(res) == 0 {
+		return res, nil
+	}
+	if _, ok := taxonomy.Current.Node(node); !ok {
+		return nil, fmt.Errorf("unknown taxonomy node: %q", node)
+	}
+
+	ids := make([]string, 0, len(res))
+	for _, poi := range res {
+		if poi.PlaceID.Valid {
+			ids = append(ids, poi.PlaceID.String)
+		}
+	}
+	matched, err := p.Database.Queries.ListPOIIDsInTaxonomyNode(ctx, sqlc_db.ListPOIIDsInTaxonomyNodeParams{
+		Column1: sourceGoogleMaps,
+		Column2: ids,
+		Column3: int32(taxonomy.Current.Version),
+		Column4: node,
+	})
+	if err != nil {
+		return nil, err
+	}
+	inNode := make(map[string]bool, len(matched))
+	for _, id := range matched {
+		inNode[id] = true
+	}
+
+	filtered := res[:0]
+	for _, poi := range res {
+		if inNode[poi.PlaceID.String] {
+			filtered = append(filtered, poi)
+		}
+	}
+	return filtered, nil
+}
+
+// ListTaxonomy returns the nodes of the current taxonomy, optionally below a root node.
+func (p *PoiService) ListTaxonomy(ctx context.Context, in *poi_v1.ListTaxonomyRequest) (*poi_v1.ListTaxonomyResponse, error) {
+	root := in.GetRoot()
+	if root != "" {
+		if _, ok := taxonomy.Current.Node(root); !ok {
+			return nil, fmt.Errorf("unknown taxonomy node: %q", root)
+		}
+	}
+
+	res := &poi_v1.ListTaxonomyResponse{Version: int32(taxonomy.Current.Version)}
+	for _, n := range taxonomy.Current.Nodes {
+		if root != "" && n.Path != root && !strings.HasPrefix(n.Path, root+".") {
+			continue
+		}
+		res.Nodes = append(res.Nodes, &poi_v1.TaxonomyNode{
+			Path:   n.Path,
+			Name:   n.Name,
+			Parent: n.Parent(),
+		})
+	}
+	return res, nil
+}
@@ -2,20 +2,190 @@ package services
 
 import (
 	"context"
+	"errors"
+	"log"
 
 	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/models"
+	"apify-poi-data/internal/services/converter"
 	"apify-poi-data/pkg/apify"
+	"apify-poi-data/pkg/pipeline"
 	tripsadvisor_v1 "apify-poi-data/proto/apify/tripsadvisor/v1"
 )
 
+// stageMap maps a decoded Tripadvisor place to its rows; the other stages share their names with the maps ingestion.
+const stageMap = "map"
+
+// Statuses of a Tripadvisor search and of the places it stored.
+const (
+	tripadvisorSucceeded = "succeeded"
+	tripadvisorPartial   = "partial"
+	tripadvisorInserted  = "inserted"
+	tripadvisorUpdated   = "updated"
+)
+
 type TripadvisorService struct {
 	tripsadvisor_v1.UnimplementedTripadvisorServiceServer
 	Database    *sqlc_db.Database
 	ApifyClient *apify.Client
+	Maps        *MapsService // Stores raw items, opening hours and categories
+}
+
+// tripadvisorItem carries a decoded Tripadvisor place through the ingestion pipeline.
+type tripadvisorItem struct {
+	index      int // Position in the dataset
+	raw        []byte
+	poi        models.POI
+	rows       tripadvisorRows
+	hours      *openingHours  // Weekly schedule, nil when the item has none
+	categories *poiCategories // Taxonomy nodes, nil when no category is mapped
+	inserted   bool
 }
 
 func (t *TripadvisorService) SearchTripadvisor(ctx context.Context, in *tripsadvisor_v1.SearchRequest) (*tripsadvisor_v1.SearchResponse, error) {
-	return &tripsadvisor_v1.SearchResponse{
-		Status: "OK",
-	}, nil
+	if in.GetQuery() == "" && len(in.GetStartUrls()) == 0 {
+		return nil, errors.New("a query or start URLs are required")
+	}
+
+	payload := converter.SearchRequestToTripAdvisorInputPayload(in)
+	resp := t.ApifyClient.TripAdvisorPOIs(payload, int(in.GetNumberOfResults()), true)
+
+	select {
+	case data := <-resp.Data:
+		return t.ingest(ctx, data, resp.RunID), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case err := <-resp.Err:
+		log.Printf("Error: %v", err)
+		return nil, err
+	}
+}
+
+// ingest writes the decoded places of a run and reports what happened to every item.
+func (t *TripadvisorService) ingest(ctx context.Context, data models.ParseResult, runID string) *tripsadvisor_v1.SearchResponse {
+	items := make([]tripadvisorItem, len(data.POIs))
+	for i, poi := range data.POIs {
+		items[i] = tripadvisorItem{index: data.Indexes[i], raw: data.Raw[i], poi: poi}
+	}
+
+	ingest := t.Maps.Ingest
+	stages := []pipeline.Stage[tripadvisorItem]{
+		{
+			Name:    stageMap,
+			Workers: ingest.NormalizeWorkers,
+			Run: func(ctx context.Context, it *tripadvisorItem) error {
+				rows, err := mapTripadvisorRows(it.poi, it.raw, runID)
+				if err != nil {
+					return err
+				}
+				it.rows = rows
+
+				// Items with hours that cannot be parsed are still written, without a schedule.
+				hours, err := parseOpeningHours(it.poi)
+				if err != nil {
+					log.Printf("Failed to parse opening hours of %s: %v", it.poi.GetID(), err)
+				}
+				it.hours = hours
+				it.categories = categorizePOI(it.poi)
+				return nil
+			},
+		},
+		{
+			Name:    stageH3,
+			Workers: ingest.H3Workers,
+			Run: func(ctx context.Context, it *tripadvisorItem) error {
+				return setTripadvisorLocation(&it.rows.place)
+			},
+		},
+		{
+			Name:    stageWrite,
+			Workers: ingest.WriteWorkers,
+			Run: func(ctx context.Context, it *tripadvisorItem) error {
+				if runID != "" {
+					if err := t.Maps.storeRawItem(ctx, models.ParserTripadvisor, runID, it.poi, it.raw); err != nil {
+						log.Printf("Failed to store raw item: %v", err)
+					}
+				}
+				inserted, err := writeTripadvisorRows(ctx, t.Database, it.rows)
+				if err != nil {
+					return err
+				}
+				it.inserted = inserted
+				t.storeDetails(ctx, it)
+				return nil
+			},
+		},
+	}
+	res := pipeline.New(ingest.Buffer, stages...).Run(ctx, items)
+	logIngestStats(res)
+
+	out := &tripsadvisor_v1.SearchResponse{
+		RunId: runID,
+		Items: int32(len(data.POIs) + len(data.Errors)),
+		Types: map[string]int32{},
+	}
+	for _, w := range data.Warnings {
+		log.Printf("Decoded POI after repair: %v", w)
+	}
+	for _, e := range data.Errors {
+		log.Printf("Skipping POI: %v", e)
+		out.ItemErrors = append(out.ItemErrors, &tripsadvisor_v1.ItemError{
+			Index:   int32(e.Index),
+			Stage:   stageDecode,
+			Message: e.Err.Error(),
+		})
+	}
+	failed := make(map[int]bool, len(res.Errors))
+	for _, e := range res.Errors {
+		log.Printf("Skipping POI: %v", e)
+		failed[e.Index] = true
+		out.ItemErrors = append(out.ItemErrors, &tripsadvisor_v1.ItemError{
+			Index:      int32(items[e.Index].index),
+			LocationId: items[e.Index].poi.GetID(),
+			Stage:      e.Stage,
+			Message:    e.Err.Error(),
+		})
+	}
+	for i, it := range items {
+		// Items the pipeline did not reach before ctx was done have no rows.
+		if failed[i] || it.rows.place.LocationID == "" {
+			continue
+		}
+		status := tripadvisorUpdated
+		if it.inserted {
+			status = tripadvisorInserted
+			out.Inserted++
+		} else {
+			out.Updated++
+		}
+		out.Types[it.rows.place.Type]++
+		out.Places = append(out.Places, &tripsadvisor_v1.StoredPlace{
+			LocationId: it.rows.place.LocationID,
+			Type:       it.rows.place.Type,
+			Name:       it.rows.place.Name,
+			Status:     status,
+		})
+	}
+	out.Failed = out.Items - out.Inserted - out.Updated
+	out.Status = tripadvisorSucceeded
+	if out.Failed > 0 || res.Err != nil {
+		out.Status = tripadvisorPartial
+	}
+	return out
+}
+
+// storeDetails writes the opening hours and taxonomy categories of a written
+// place. Failures are logged; the place itself is already stored.
+func (t *TripadvisorService) storeDetails(ctx context.Context, it *tripadvisorItem) {
+	if it.hours != nil {
+		lat, lng := it.rows.place.LocationLat.Float64, it.rows.place.LocationLng.Float64
+		if err := t.Maps.storeOpeningHours(ctx, it.hours, lat, lng); err != nil {
+			log.Printf("Failed to store opening hours: %v", err)
+		}
+	}
+	if it.categories != nil {
+		if err := t.Maps.storePOICategories(ctx, it.categories); err != nil {
+			log.Printf("Failed to store categories: %v", err)
+		}
+	}
 }
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/uber/h3-go/v4"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/internal/models"
+	"apify-poi-data/pkg/geo"
+)
+
+// tripadvisorRows are the rows of a Tripadvisor place: the place itself, and
+// the lists that are kept in their own tables.
+type tripadvisorRows struct {
+	place     sqlc_db.UpsertTripadvisorPOIParams
+	offers    []sqlc_db.InsertTripadvisorOfferParams
+	ancestors []sqlc_db.InsertTripadvisorAncestorLocationParams
+	stations  []sqlc_db.InsertTripadvisorMetroStationParams
+}
+
+// mapTripadvisorRows maps a decoded Tripadvisor place to its rows. The raw
+// item is kept as details, for the fields that have no column.
+func mapTripadvisorRows(poi models.POI, raw []byte, runID string) (tripadvisorRows, error) {
+	var base *models.BasePOI
+	var place sqlc_db.UpsertTripadvisorPOIParams
+	switch p := poi.(type) {
+	case *models.Hotel:
+		base = &p.BasePOI
+		place = tripadvisorPlaceParams(base)
+		place.HotelClass = textFromPtr(p.HotelClass)
+		place.NumberOfRooms = int4FromPtr(p.NumberOfRooms)
+		place.Amenities = p.Amenities
+		place.PriceLevel = textFromPtr(p.PriceLevel)
+		place.PriceRange = textFromPtr(p.PriceRange)
+	case *models.Restaurant:
+		base = &p.BasePOI
+		place = tripadvisorPlaceParams(base)
+		place.Cuisines = p.Cuisines
+		place.DietaryRestrictions = p.DietaryRestrictions
+		place.MealTypes = p.MealTypes
+		place.Features = p.Features
+		place.PriceLevel = textFromPtr(p.PriceLevel)
+		place.PriceRange = textFromPtr(p.PriceRange)
+	case *models.Attraction:
+		base = &p.BasePOI
+		place = tripadvisorPlaceParams(base)
+	default:
+		return tripadvisorRows{}, fmt.Errorf("unsupported POI type: %s", poi.GetType())
+	}
+	if place.LocationID == "" {
+		return tripadvisorRows{}, errors.New("missing location id")
+	}
+	place.Details = raw
+	place.SourceRunID = textFromString(runID)
+
+	rows := tripadvisorRows{place: place}
+	id := place.LocationID
+	if base.OfferGroup != nil {
+		for _, o := range base.OfferGroup.OfferList {
+			rows.offers = append(rows.offers, sqlc_db.InsertTripadvisorOfferParams{
+				LocationID:      id,
+				Position:        int32(len(rows.offers)),
+				Title:           textFromPtr(o.Title),
+				Url:             textFromPtr(o.URL),
+				Price:           textFromPtr(o.Price),
+				RoundedUpPrice:  textFromPtr(o.RoundedUpPrice),
+				OfferType:       textFromPtr(o.OfferType),
+				ProductCode:     textFromPtr(o.ProductCode),
+				Partner:         textFromPtr(o.Partner),
+				ImageUrl:        textFromPtr(o.ImageURL),
+				Description:     textFromPtr(o.Description),
+				PrimaryCategory: textFromPtr(o.PrimaryCategory),
+			})
+		}
+	}
+	for _, a := range base.AncestorLocations {
+		// Ancestors are looked up by id; one without is of no use.
+		if a.ID == nil || *a.ID == "" {
+			continue
+		}
+		rows.ancestors = append(rows.ancestors, sqlc_db.InsertTripadvisorAncestorLocationParams{
+			LocationID:   id,
+			Position:     int32(len(rows.ancestors)),
+			AncestorID:   *a.ID,
+			Name:         textFromPtr(a.Name),
+			Abbreviation: textFromPtr(a.Abbreviation),
+			Subcategory:  textFromPtr(a.Subcategory),
+		})
+	}
+	for _, s := range base.NearestMetroStations {
+		rows.stations = append(rows.stations, sqlc_db.InsertTripadvisorMetroStationParams{
+			LocationID:   id,
+			Position:     int32(len(rows.stations)),
+			Name:         textFromPtr(s.Name),
+			LocalName:    textFromPtr(s.LocalName),
+			Address:      textFromPtr(s.Address),
+			LocalAddress: textFromPtr(s.LocalAddress),
+			Lines:        s.Lines,
+			LocationLat:  float8FromPtr(s.Latitude),
+			LocationLng:  float8FromPtr(s.Longitude),
+			Distance:     float8FromPtr(s.Distance),
+		})
+	}
+	return rows, nil
+}
+
+// tripadvisorPlaceParams maps the fields every Tripadvisor type has.
+func tripadvisorPlaceParams(p *models.BasePOI) sqlc_db.UpsertTripadvisorPOIParams {
+	params := sqlc_db.UpsertTripadvisorPOIParams{
+		LocationID:      p.ID,
+		Type:            p.Type,
+		Name:            p.Name,
+		Category:        textFromString(p.Category),
+		Subcategories:   p.Subcategories,
+		Description:     textFromPtr(p.Description),
+		Rating:          float8FromPtr(p.Rating),
+		RankingPosition: int4FromPtr(p.RankingPosition),
+		RankingString:   textFromPtr(p.RankingString),
+		Phone:           textFromPtr(p.Phone),
+		Email:           textFromPtr(p.Email),
+		Website:         textFromPtr(p.Website),
+		WebUrl:          textFromPtr(p.WebURL),
+		Address:         textFromPtr(p.Address),
+		LocationLat:     pgtype.Float8{Float64: p.Latitude, Valid: true},
+		LocationLng:     pgtype.Float8{Float64: p.Longitude, Valid: true},
+		ImageUrl:        textFromPtr(p.Image),
+		PhotoCount:      int4FromPtr(p.PhotoCount),
+		IsClosed:        p.IsClosed,
+		IsLongClosed:    p.IsLongClosed,
+	}
+	if a := p.AddressObj; a != nil {
+		params.Street = textFromPtr(a.Street1)
+		params.City = textFromPtr(a.City)
+		params.PostalCode = textFromPtr(a.Postalcode)
+		params.Country = textFromPtr(a.Country)
+	}
+	if h := p.RatingHistogram; h != nil {
+		params.RatingCount1 = pgtype.Int4{Int32: int32(h.Count1), Valid: true}
+		params.RatingCount2 = pgtype.Int4{Int32: int32(h.Count2), Valid: true}
+		params.RatingCount3 = pgtype.Int4{Int32: int32(h.Count3), Valid: true}
+		params.RatingCount4 = pgtype.Int4{Int32: int32(h.Count4), Valid: true}
+		params.RatingCount5 = pgtype.Int4{Int32: int32(h.Count5), Valid: true}
+	}
+	if p.OfferGroup != nil {
+		params.LowestPrice = textFromPtr(p.OfferGroup.LowestPrice)
+	}
+	if p.Booking != nil {
+		params.BookingProvider = textFromPtr(p.Booking.Provider)
+		params.BookingUrl = textFromPtr(p.Booking.URL)
+	}
+	return params
+}
+
+// setTripadvisorLocation fills in the H3 cell of a place. Places are keyed by
+// their location id, so one without usable coordinates is still stored, without a location.
+func setTripadvisorLocation(params *sqlc_db.UpsertTripadvisorPOIParams) error {
+	p := geo.Point{Lat: params.LocationLat.Float64, Lng: params.LocationLng.Float64}
+	if issue := geo.Check(p); issue != geo.IssueNone {
+		log.Printf("Storing %s without a location: %s (lat=%f, lng=%f)", params.LocationID, issue, p.Lat, p.Lng)
+		params.LocationLat, params.LocationLng = pgtype.Float8{}, pgtype.Float8{}
+		return nil
+	}
+	cell, err := h3.LatLngToCell(h3.LatLng{Lat: p.Lat, Lng: p.Lng}, DATABASE_RESOLUTION)
+	if err != nil {
+		return fmt.Errorf("failed to get H3Index: %w", err)
+	}
+	params.H3Index = pgtype.Text{String: cell.String(), Valid: true}
+	return nil
+}
+
+// writeTripadvisorRows upserts a place and replaces its lists in one
+// transaction. It reports whether the place was new.
+func writeTripadvisorRows(ctx context.Context, db *sqlc_db.Database, rows tripadvisorRows) (bool, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+	q := db.Queries.WithTx(tx)
+
+	inserted, err := q.UpsertTripadvisorPOI(ctx, rows.place)
+	if err != nil {
+		return false, err
+	}
+	id := rows.place.LocationID
+	if err := q.DeleteTripadvisorOffers(ctx, id); err != nil {
+		return false, err
+	}
+	for _, o := range rows.offers {
+		if err := q.InsertTripadvisorOffer(ctx, o); err != nil {
+			return false, err
+		}
+	}
+	if err := q.DeleteTripadvisorAncestorLocations(ctx, id); err != nil {
+		return false, err
+	}
+	for _, a := range rows.ancestors {
+		if err := q.InsertTripadvisorAncestorLocation(ctx, a); err != nil {
+			return false, err
+		}
+	}
+	if err := q.DeleteTripadvisorMetroStations(ctx, id); err != nil {
+		return false, err
+	}
+	for _, s := range rows.stations {
+		if err := q.InsertTripadvisorMetroStation(ctx, s); err != nil {
+			return false, err
+		}
+	}
+	return inserted, tx.Commit(ctx)
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/pkg/changes"
+	"apify-poi-data/pkg/geo"
+	poi_v1 "apify-poi-data/proto/apify/poi/v1"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/uber/h3-go/v4"
+)
+
+const (
+	areaExisting = "existing"
+	areaSynced   = "synced"
+	areaClosed   = "closed"
+	areaLeft     = "left"
+)
+
+// watchArea is the area of a WatchArea request.
+type watchArea struct {
+	box     *poi_v1.Box
+	polygon geo.Polygon
+	cells   map[int]map[h3.Cell]bool // By resolution
+}
+
+func newWatchArea(in *poi_v1.WatchAreaRequest) (*watchArea, error) {
+	switch area := in.GetArea().(type) {
+	case *poi_v1.WatchAreaRequest_Box:
+		b := area.Box
+		if b.GetMinX() > b.GetMaxX() || b.GetMinY() > b.GetMaxY() {
+			return nil, fmt.Errorf("invalid box; min must not be larger than max")
+		}
+		return &watchArea{box: b}, nil
+	case *poi_v1.WatchAreaRequest_Polygon:
+		points := area.Polygon.GetPoints()
+		if len(points) < 3 {
+			return nil, fmt.Errorf("a polygon needs at least 3 points")
+		}
+		poly := make(geo.Polygon, len(points))
+		for i, p := range points {
+			poly[i] = geo.Point{Lat: p.GetLat(), Lng: p.GetLng()}
+		}
+		return &watchArea{polygon: poly}, nil
+	case *poi_v1.WatchAreaRequest_H3Cells:
+		if len(area.H3Cells.GetCells()) == 0 {
+			return nil, fmt.Errorf("no h3 cells")
+		}
+		cells := map[int]map[h3.Cell]bool{}
+		for _, index := range area.H3Cells.GetCells() {
+			cell := h3.Cell(h3.IndexFromString(index))
+			if !cell.IsValid() {
+				return nil, fmt.Errorf("invalid h3 index; %s", index)
+			}
+			if cell.Resolution() >= DATABASE_RESOLUTION {
+				return nil, fmt.Errorf("h3 index resolution is too small(higher than 9 is not allowed); %s", index)
+			}
+			if cells[cell.Resolution()] == nil {
+				cells[cell.Resolution()] = map[h3.Cell]bool{}
+			}
+			cells[cell.Resolution()][cell] = true
+		}
+		return &watchArea{cells: cells}, nil
+	default:
+		return nil, fmt.Errorf("one of box, polygon or h3_cells is required")
+	}
+}
+
+func (a *watchArea) contains(p geo.Point) bool {
+	switch {
+	case a.box != nil:
+		return p.Lng >= a.box.GetMinX() && p.Lng <= a.box.GetMaxX() &&
+			p.Lat >= a.box.GetMinY() && p.Lat <= a.box.GetMaxY()
+	case a.polygon != nil:
+		return a.polygon.Contains(p)
+	default:
+		for res, cells := range a.cells {
+			cell, err := h3.LatLngToCell(h3.LatLng{Lat: p.Lat, Lng: p.Lng}, res)
+			if err == nil && cells[cell] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// polygonWKT returns a polygon as well-known text, closing the ring.
+func polygonWKT(poly geo.Polygon) string {
+	ring := append(slices.Clone(poly), poly[0])
+	coords := make([]string, len(ring))
+	for i, p := range ring {
+		coords[i] = fmt.Sprintf("%f %f", p.Lng, p.Lat)
+	}
+	return "POLYGON((" + strings.Join(coords, ",") + "))"
+}
+
+// list returns the places in the area.
+func (a *watchArea) list(ctx context.Context, q *sqlc_db.Queries) ([]sqlc_db.PoiDataSchemaGoogleMap, error) {
+	switch {
+	case a.box != nil:
+		return q.ListPOIInBox(ctx, sqlc_db.ListPOIInBoxParams{
+			Column1: a.box.GetMinX(),
+			Column2: a.box.GetMinY(),
+			Column3: a.box.GetMaxX(),
+			Column4: a.box.GetMaxY(),
+			Column6: [][]byte{}, // No attribute filter
+		})
+	case a.polygon != nil:
+		return q.ListPOIInPolygon(ctx, polygonWKT(a.polygon))
+	default:
+		var indexes []string
+		for _, cells := range a.cells {
+			for cell := range cells {
+				indexes = append(indexes, cell.String())
+			}
+		}
+		return q.ListPOIsByH3Cells(ctx, sqlc_db.ListPOIsByH3CellsParams{
+			Column1: DATABASE_RESOLUTION,
+			Column2: indexes,
+			Column4: [][]byte{}, // No attribute filter
+		})
+	}
+}
+
+// matchesCategory reports whether one of the categories contains the
+// normalized category, or whether no category was requested.
+func matchesCategory(category string, categories ...string) bool {
+	if category == "" {
+		return true
+	}
+	for _, c := range categories {
+		if strings.Contains(normalizeCategory(c), category) {
+			return true
+		}
+	}
+	return false
+}
+
+// WatchArea sends the places in an area, then every insert, update or closure
+// of a place in it, and every place that moves out of it, until the client disconnects. Writes of every replica are
+// seen, as they are read from the outbox.
+func (p *PoiService) WatchArea(in *poi_v1.WatchAreaRequest, stream poi_v1.PoiService_WatchAreaServer) error {
+	ctx := stream.Context()
+	area, err := newWatchArea(in)
+	if err != nil {
+		return err
+	}
+	category := normalizeCategory(in.GetCategory())
+
+	// Writes after the head are followed, so none is missed between the
+	// listing and the stream
+	pos, err := p.outboxHead(ctx)
+	if err != nil {
+		return err
+	}
+	rows, err := area.list(ctx, p.Database.Queries)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if !matchesCategory(category, append([]string{row.CategoryName.String}, row.Categories...)...) {
+			continue
+		}
+		poi, err := p.toPOI(row)
+		if err != nil {
+			log.Printf("Failed to convert place %s: %v", row.PlaceID.String, err)
+			continue
+		}
+		if err := stream.Send(&poi_v1.AreaUpdate{Kind: areaExisting, Poi: poi}); err != nil {
+			return err
+		}
+	}
+	if err := stream.Send(&poi_v1.AreaUpdate{Kind: areaSynced}); err != nil {
+		return err
+	}
+
+	return p.followOutbox(ctx, pos, func(row sqlc_db.ListOutboxMessagesRow, _ outboxPosition) error {
+		var msg poiMessage
+		if err := json.Unmarshal(row.Data, &msg); err != nil {
+			return fmt.Errorf("decoding outbox message %d: %w", row.ID, err)
+		}
+		inside := msg.Lat != nil && msg.Lng != nil && area.contains(geo.Point{Lat: *msg.Lat, Lng: *msg.Lng})
+		wasInside := msg.PrevLat != nil && msg.PrevLng != nil && area.contains(geo.Point{Lat: *msg.PrevLat, Lng: *msg.PrevLng})
+		if !inside && !wasInside {
+			return nil
+		}
+		if !matchesCategory(category, append([]string{msg.Category}, msg.Categories...)...) {
+			return nil
+		}
+		place, err := p.Database.Queries.GetPOIByPlaceID(ctx, pgtype.Text{String: msg.PlaceID, Valid: true})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		poi, err := p.toPOI(place)
+		if err != nil {
+			log.Printf("Failed to convert place %s: %v", msg.PlaceID, err)
+			return nil
+		}
+		kind := row.Subject
+		switch {
+		case !inside:
+			// The client drops a place that moved out of the area
+			kind = areaLeft
+		case slices.Contains(msg.Changes, string(changes.TypeClosed)) || slices.Contains(msg.Changes, string(changes.TypeTemporarilyClosed)):
+			kind = areaClosed
+		}
+		return stream.Send(&poi_v1.AreaUpdate{Kind: kind, Poi: poi})
+	})
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	sqlc_db "apify-poi-data/db/sqlc"
+	poi_v1 "apify-poi-data/proto/apify/poi/v1"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const watchBatchSize = 100
+
+// outboxPosition is a place in the (txid, id) order the outbox is read in.
+type outboxPosition struct {
+	txid int64
+	id   int64
+}
+
+func encodeResumeToken(pos outboxPosition) string {
+	tok := fmt.Sprintf("%d:%d", pos.txid, pos.id)
+	return base64.RawURLEncoding.EncodeToString([]byte(tok))
+}
+
+func decodeResumeToken(tok string) (outboxPosition, error) {
+	b, err := base64.RawURLEncoding.DecodeString(tok)
+	if err != nil {
+		return outboxPosition{}, fmt.Errorf("invalid resume_token")
+	}
+	txid, id, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return outboxPosition{}, fmt.Errorf("invalid resume_token")
+	}
+	var pos outboxPosition
+	if pos.txid, err = strconv.ParseInt(txid, 10, 64); err != nil {
+		return outboxPosition{}, fmt.Errorf("invalid resume_token")
+	}
+	if pos.id, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return outboxPosition{}, fmt.Errorf("invalid resume_token")
+	}
+	return pos, nil
+}
+
+// WatchChanges streams the outbox from the resume token, or from now on,
+// until the client disconnects.
+func (p *PoiService) WatchChanges(in *poi_v1.WatchChangesRequest, stream poi_v1.PoiService_WatchChangesServer) error {
+	ctx := stream.Context()
+	for _, s := range in.GetSubjects() {
+		if s != subjectInserted && s != subjectUpdated {
+			return fmt.Errorf("unknown subject: %q", s)
+		}
+	}
+	subjects := toSet(in.GetSubjects())
+	places := toSet(in.GetPlaceIds())
+
+	var pos outboxPosition
+	var err error
+	if tok := in.GetResumeToken(); tok != "" {
+		pos, err = decodeResumeToken(tok)
+	} else {
+		pos, err = p.outboxHead(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	return p.followOutbox(ctx, pos, func(row sqlc_db.ListOutboxMessagesRow, pos outboxPosition) error {
+		if (len(subjects) > 0 && !subjects[row.Subject]) || (len(places) > 0 && !places[row.Key]) {
+			return nil
+		}
+		change, err := toPOIChange(row, pos)
+		if err != nil {
+			return err
+		}
+		return stream.Send(change)
+	})
+}
+
+// outboxHead returns the position after the messages that are visible now.
+func (p *PoiService) outboxHead(ctx context.Context) (outboxPosition, error) {
+	head, err := p.Database.Queries.GetOutboxHead(ctx)
+	if err != nil {
+		return outboxPosition{}, err
+	}
+	return outboxPosition{txid: head.Txid, id: head.ID}, nil
+}
+
+// followOutbox passes the outbox messages after pos to handle, in order, until
+// ctx is done. New messages are read when the listener reports them, and
+// regularly in case a notification was missed.
+func (p *PoiService) followOutbox(ctx context.Context, pos outboxPosition, handle func(row sqlc_db.ListOutboxMessagesRow, pos outboxPosition) error) error {
+	var wake <-chan struct{}
+	interval := watchPollNoListener
+	if p.Outbox != nil {
+		var unsubscribe func()
+		wake, unsubscribe = p.Outbox.Subscribe()
+		defer unsubscribe()
+		interval = watchFallbackPoll
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		rows, err := p.Database.Queries.ListOutboxMessages(ctx, sqlc_db.ListOutboxMessagesParams{
+			Column1: pos.txid,
+			Column2: pos.id,
+			Column3: watchBatchSize,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		for _, row := range rows {
+			pos = outboxPosition{txid: row.Txid, id: row.ID}
+			if err := handle(row, pos); err != nil {
+				return err
+			}
+		}
+		if len(rows) == watchBatchSize {
+			continue // More are waiting
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func toPOIChange(row sqlc_db.ListOutboxMessagesRow, pos outboxPosition) (*poi_v1.PoiChange, error) {
+	var data map[string]any
+	if err := json.Unmarshal(row.Data, &data); err != nil {
+		return nil, fmt.Errorf("decoding outbox message %d: %w", row.ID, err)
+	}
+	s, err := structpb.NewStruct(data)
+	if err != nil {
+		return nil, err
+	}
+	return &poi_v1.PoiChange{
+		Id:          row.ID,
+		Subject:     row.Subject,
+		PlaceId:     row.Key,
+		Data:        s,
+		CreatedAt:   row.CreatedAt.Format(time.RFC3339),
+		ResumeToken: encodeResumeToken(pos),
+	}, nil
+}
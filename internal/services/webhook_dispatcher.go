@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"apify-poi-data/config"
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/pkg/publish"
+	"apify-poi-data/pkg/webhook"
+)
+
+const (
+	webhookMatchBatchSize  = 100
+	webhookCleanupInterval = time.Hour
+)
+
+// WebhookDispatcher calls the webhook subscribers. Outbox messages are matched
+// against the subscriptions into deliveries, which are posted and retried with
+// backoff until they succeed or fail too often. Several instances may share a
+// database: the outbox cursor and the due deliveries are locked with SKIP LOCKED.
+type WebhookDispatcher struct {
+	Database *sqlc_db.Database
+	Outbox   *OutboxListener // Wakes up the matching, polled without it
+	Sender   *webhook.Sender
+	Config   config.Webhooks
+}
+
+func NewWebhookDispatcher(cfg config.Webhooks, db *sqlc_db.Database, listener *OutboxListener) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		Database: db,
+		Outbox:   listener,
+		Sender:   webhook.NewSender(cfg.Timeout),
+		Config:   cfg,
+	}
+}
+
+// Run matches and delivers until ctx is done, and deletes the delivered
+// deliveries that are older than the retention.
+func (d *WebhookDispatcher) Run(ctx context.Context) error {
+	var wake <-chan struct{}
+	if d.Outbox != nil {
+		var unsubscribe func()
+		wake, unsubscribe = d.Outbox.Subscribe()
+		defer unsubscribe()
+	}
+	ticker := time.NewTicker(d.Config.PollInterval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(webhookCleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		matched, err := d.matchBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to match outbox messages to webhooks: %v", err)
+		}
+		sent, err := d.deliverBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to deliver webhooks: %v", err)
+		}
+		if matched == webhookMatchBatchSize || sent == d.Config.BatchSize {
+			continue // More are waiting
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-cleanup.C:
+			d.cleanup(ctx)
+		case <-wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// matchBatch adds the deliveries of the outbox messages after the cursor and
+// returns how many messages there were.
+func (d *WebhookDispatcher) matchBatch(ctx context.Context) (int, error) {
+	tx, err := d.Database.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	q := d.Database.Queries.WithTx(tx)
+
+	cursor, err := q.GetWebhookCursor(ctx)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil // Another instance is matching
+	}
+	if err != nil {
+		return 0, err
+	}
+	rows, err := q.ListOutboxMessages(ctx, sqlc_db.ListOutboxMessagesParams{
+		Column1: cursor.Txid,
+		Column2: cursor.OutboxID,
+		Column3: webhookMatchBatchSize,
+	})
+	if err != nil || len(rows) == 0 {
+		return 0, err
+	}
+	for _, row := range rows {
+		if err := enqueueWebhookDeliveries(ctx, q, row); err != nil {
+			return 0, err
+		}
+	}
+	last := rows[len(rows)-1]
+	if err := q.UpdateWebhookCursor(ctx, sqlc_db.UpdateWebhookCursorParams{
+		Column1: last.Txid,
+		Column2: last.ID,
+	}); err != nil {
+		return 0, err
+	}
+	return len(rows), tx.Commit(ctx)
+}
+
+// enqueueWebhookDeliveries adds a delivery of an outbox message for every
+// subscription that matches it. The posted body is the envelope the brokers
+// receive.
+func enqueueWebhookDeliveries(ctx context.Context, q *sqlc_db.Queries, row sqlc_db.ListOutboxMessagesRow) error {
+	var msg poiMessage
+	if err := json.Unmarshal(row.Data, &msg); err != nil {
+		log.Printf("Skipping outbox message %d for webhooks: %v", row.ID, err)
+		return nil
+	}
+	payload, err := publish.Message{
+		ID:      row.ID,
+		Subject: row.Subject,
+		Key:     row.Key,
+		Data:    row.Data,
+		Time:    row.CreatedAt,
+	}.Encode()
+	if err != nil {
+		return err
+	}
+	params := sqlc_db.EnqueueWebhookDeliveriesParams{
+		Column1: row.ID,
+		Column2: row.CreatedAt,
+		Column3: row.Subject,
+		Column4: row.Key,
+		Column5: payload,
+		Column9: append([]string{row.Subject}, msg.Changes...),
+	}
+	if msg.Lat != nil && msg.Lng != nil {
+		params.Column6, params.Column7, params.Column8 = true, *msg.Lng, *msg.Lat
+	}
+	_, err = q.EnqueueWebhookDeliveries(ctx, params)
+	return err
+}
+
+// deliverBatch posts the due deliveries at the same time, records the outcome
+// of each and returns how many there were.
+func (d *WebhookDispatcher) deliverBatch(ctx context.Context) (int, error) {
+	tx, err := d.Database.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	q := d.Database.Queries.WithTx(tx)
+
+	rows, err := q.ClaimWebhookDeliveries(ctx, int32(d.Config.BatchSize))
+	if err != nil || len(rows) == 0 {
+		return 0, err
+	}
+
+	codes := make([]int, len(rows))
+	errs := make([]error, len(rows))
+	var wg sync.WaitGroup
+	for i, row := range rows {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			secrets := []string{row.Secret}
+			if row.PreviousSecret != "" {
+				secrets = append(secrets, row.PreviousSecret)
+			}
+			codes[i], errs[i] = d.Sender.Send(ctx, webhook.Request{
+				ID:      strconv.FormatInt(row.ID, 10),
+				URL:     row.Url,
+				Body:    row.Payload,
+				Secrets: secrets,
+			})
+		}()
+	}
+	wg.Wait()
+
+	for i, row := range rows {
+		code := pgtype.Int4{Int32: int32(codes[i]), Valid: codes[i] != 0}
+		if errs[i] == nil {
+			if err := q.MarkWebhookDelivered(ctx, sqlc_db.MarkWebhookDeliveredParams{
+				ID:             row.ID,
+				LastStatusCode: code,
+			}); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		attempts := int(row.Attempts) + 1
+		dead := attempts >= d.Config.MaxAttempts
+		if dead {
+			log.Printf("Webhook delivery %d to %s is dead after %d attempts: %v", row.ID, row.Url, attempts, errs[i])
+		}
+		if err := q.FailWebhookDelivery(ctx, sqlc_db.FailWebhookDeliveryParams{
+			ID:             row.ID,
+			LastStatusCode: code,
+			Column3:        errs[i].Error(),
+			Column4:        time.Now().Add(webhook.Backoff(attempts, d.Config.BackoffBase, d.Config.BackoffMax)),
+			Column5:        dead,
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return len(rows), tx.Commit(ctx)
+}
+
+func (d *WebhookDispatcher) cleanup(ctx context.Context) {
+	n, err := d.Database.Queries.DeleteWebhookDeliveries(ctx, time.Now().Add(-d.Config.Retention))
+	if err != nil {
+		log.Printf("Failed to delete delivered webhooks: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Deleted %d delivered webhooks", n)
+	}
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"apify-poi-data/config"
+	sqlc_db "apify-poi-data/db/sqlc"
+	"apify-poi-data/pkg/changes"
+	"apify-poi-data/pkg/geo"
+	"apify-poi-data/pkg/webhook"
+	webhook_v1 "apify-poi-data/proto/apify/webhook/v1"
+)
+
+const (
+	defaultWebhookLimit = 100
+	maxWebhookLimit     = 1000
+)
+
+// Statuses a webhook delivery goes through.
+const (
+	deliveryPending   = "pending"
+	deliveryDelivered = "delivered"
+	deliveryDead      = "dead"
+)
+
+type WebhookService struct {
+	Database *sqlc_db.Database
+	Config   config.Webhooks
+	webhook_v1.UnimplementedWebhookServiceServer
+}
+
+// webhookEventType reports whether t can be subscribed to: a subject, or a
+// change that is recorded when a place is written.
+func webhookEventType(t string) bool {
+	if t == subjectInserted || t == subjectUpdated {
+		return true
+	}
+	return changes.Type(t).Valid() && changes.Type(t) != changes.TypeDisappeared
+}
+
+// webhookArea returns the area of a subscription as WKT, or "" for every place.
+func webhookArea(in *webhook_v1.CreateWebhookSubscriptionRequest) (string, error) {
+	switch area := in.GetArea().(type) {
+	case nil:
+		return "", nil
+	case *webhook_v1.CreateWebhookSubscriptionRequest_Box:
+		b := area.Box
+		if b.GetMinX() >= b.GetMaxX() || b.GetMinY() >= b.GetMaxY() {
+			return "", errors.New("invalid box; min must be smaller than max")
+		}
+		return polygonWKT(geo.Polygon{
+			{Lat: b.GetMinY(), Lng: b.GetMinX()},
+			{Lat: b.GetMinY(), Lng: b.GetMaxX()},
+			{Lat: b.GetMaxY(), Lng: b.GetMaxX()},
+			{Lat: b.GetMaxY(), Lng: b.GetMinX()},
+		}), nil
+	case *webhook_v1.CreateWebhookSubscriptionRequest_Polygon:
+		points := area.Polygon.GetPoints()
+		if len(points) < 3 {
+			return "", errors.New("a polygon needs at least 3 points")
+		}
+		poly := make(geo.Polygon, len(points))
+		for i, p := range points {
+			poly[i] = geo.Point{Lat: p.GetLat(), Lng: p.GetLng()}
+		}
+		return polygonWKT(poly), nil
+	default:
+		return "", errors.New("unknown area")
+	}
+}
+
+func (w *WebhookService) CreateWebhookSubscription(ctx context.Context, in *webhook_v1.CreateWebhookSubscriptionRequest) (*webhook_v1.CreateWebhookSubscriptionResponse, error) {
+	u, err := url.Parse(in.GetUrl())
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url: %q", in.GetUrl())
+	}
+	for _, t := range in.GetEventTypes() {
+		if !webhookEventType(t) {
+			return nil, fmt.Errorf("unknown event type: %q", t)
+		}
+	}
+	area, err := webhookArea(in)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := w.Database.Queries.CreateWebhookSubscription(ctx, sqlc_db.CreateWebhookSubscriptionParams{
+		Url:         in.GetUrl(),
+		Description: in.GetDescription(),
+		Secret:      secret,
+		Column4:     area,
+		Column5:     append([]string{}, in.GetEventTypes()...),
+	})
+	if err != nil {
+		return nil, err
+	}
+	row, err := w.Database.Queries.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook_v1.CreateWebhookSubscriptionResponse{
+		Subscription: toWebhookSubscription(sqlc_db.ListWebhookSubscriptionsRow(row)),
+		Secret:       secret,
+	}, nil
+}
+
+func (w *WebhookService) ListWebhookSubscriptions(ctx context.Context, in *webhook_v1.ListWebhookSubscriptionsRequest) (*webhook_v1.ListWebhookSubscriptionsResponse, error) {
+	limit := in.GetLimit()
+	if limit <= 0 {
+		limit = defaultWebhookLimit
+	}
+	if limit > maxWebhookLimit {
+		limit = maxWebhookLimit
+	}
+
+	rows, err := w.Database.Queries.ListWebhookSubscriptions(ctx, sqlc_db.ListWebhookSubscriptionsParams{
+		ID:    in.GetAfterId(),
+		Limit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &webhook_v1.ListWebhookSubscriptionsResponse{}
+	for _, row := range rows {
+		resp.Subscriptions = append(resp.Subscriptions, toWebhookSubscription(row))
+	}
+	if len(rows) == int(limit) {
+		resp.NextAfterId = rows[len(rows)-1].ID
+	}
+	return resp, nil
+}
+
+func (w *WebhookService) DeleteWebhookSubscription(ctx context.Context, in *webhook_v1.DeleteWebhookSubscriptionRequest) (*webhook_v1.DeleteWebhookSubscriptionResponse, error) {
+	deleted, err := w.Database.Queries.DeleteWebhookSubscription(ctx, in.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if deleted == 0 {
+		return nil, fmt.Errorf("webhook subscription %d not found", in.GetId())
+	}
+	return &webhook_v1.DeleteWebhookSubscriptionResponse{Status: "deleted"}, nil
+}
+
+func (w *WebhookService) RotateWebhookSecret(ctx context.Context, in *webhook_v1.RotateWebhookSecretRequest) (*webhook_v1.RotateWebhookSecretResponse, error) {
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	expires := time.Now().Add(w.Config.SecretGrace)
+	rotated, err := w.Database.Queries.RotateWebhookSecret(ctx, sqlc_db.RotateWebhookSecretParams{
+		ID:      in.GetId(),
+		Column2: secret,
+		Column3: expires,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rotated == 0 {
+		return nil, fmt.Errorf("webhook subscription %d not found", in.GetId())
+	}
+	return &webhook_v1.RotateWebhookSecretResponse{
+		Secret:                  secret,
+		PreviousSecretExpiresAt: expires.Format(time.RFC3339),
+	}, nil
+}
+
+func (w *WebhookService) ListWebhookDeliveries(ctx context.Context, in *webhook_v1.ListWebhookDeliveriesRequest) (*webhook_v1.ListWebhookDeliveriesResponse, error) {
+	if in.Status != nil && !slices.Contains([]string{deliveryPending, deliveryDelivered, deliveryDead}, in.GetStatus()) {
+		return nil, fmt.Errorf("unknown status: %q", in.GetStatus())
+	}
+	if _, err := w.Database.Queries.GetWebhookSubscription(ctx, in.GetSubscriptionId()); errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("webhook subscription %d not found", in.GetSubscriptionId())
+	} else if err != nil {
+		return nil, err
+	}
+	limit := in.GetLimit()
+	if limit <= 0 {
+		limit = defaultWebhookLimit
+	}
+	if limit > maxWebhookLimit {
+		limit = maxWebhookLimit
+	}
+
+	rows, err := w.Database.Queries.ListWebhookDeliveries(ctx, sqlc_db.ListWebhookDeliveriesParams{
+		SubscriptionID: in.GetSubscriptionId(),
+		Column2:        in.GetStatus(),
+		Column3:        in.GetBeforeId(),
+		Limit:          limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &webhook_v1.ListWebhookDeliveriesResponse{}
+	for _, row := range rows {
+		delivery, err := toWebhookDelivery(row)
+		if err != nil {
+			return nil, err
+		}
+		resp.Deliveries = append(resp.Deliveries, delivery)
+	}
+	if len(rows) == int(limit) {
+		resp.NextBeforeId = rows[len(rows)-1].ID
+	}
+	return resp, nil
+}
+
+func (w *WebhookService) RedeliverWebhook(ctx context.Context, in *webhook_v1.RedeliverWebhookRequest) (*webhook_v1.RedeliverWebhookResponse, error) {
+	queued, err := w.Database.Queries.RedeliverWebhookDelivery(ctx, in.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if queued == 0 {
+		return nil, fmt.Errorf("webhook delivery %d not found or already pending", in.GetId())
+	}
+	return &webhook_v1.RedeliverWebhookResponse{Status: deliveryPending}, nil
+}
+
+func toWebhookSubscription(row sqlc_db.ListWebhookSubscriptionsRow) *webhook_v1.WebhookSubscription {
+	sub := &webhook_v1.WebhookSubscription{
+		Id:          row.ID,
+		Url:         row.Url,
+		Description: row.Description,
+		Area:        row.Area,
+		EventTypes:  row.EventTypes,
+		CreatedAt:   row.CreatedAt.Format(time.RFC3339),
+	}
+	if row.PreviousSecretExpiresAt.Valid && row.PreviousSecretExpiresAt.Time.After(time.Now()) {
+		expires := row.PreviousSecretExpiresAt.Time.Format(time.RFC3339)
+		sub.PreviousSecretExpiresAt = &expires
+	}
+	return sub
+}
+
+func toWebhookDelivery(row sqlc_db.PoiDataSchemaWebhookDelivery) (*webhook_v1.WebhookDelivery, error) {
+	var payload map[string]any
+	if err := json.Unmarshal(row.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("decoding webhook delivery %d: %w", row.ID, err)
+	}
+	s, err := structpb.NewStruct(payload)
+	if err != nil {
+		return nil, err
+	}
+	delivery := &webhook_v1.WebhookDelivery{
+		Id:             row.ID,
+		SubscriptionId: row.SubscriptionID,
+		MessageId:      row.OutboxID,
+		Subject:        row.Subject,
+		PlaceId:        row.PlaceID,
+		Status:         row.Status,
+		Attempts:       row.Attempts,
+		LastStatusCode: int4Ptr(row.LastStatusCode),
+		LastError:      textPtr(row.LastError),
+		NextAttemptAt:  row.NextAttemptAt.Format(time.RFC3339),
+		CreatedAt:      row.CreatedAt.Format(time.RFC3339),
+		Payload:        s,
+	}
+	if row.DeliveredAt.Valid {
+		delivered := row.DeliveredAt.Time.Format(time.RFC3339)
+		delivery.DeliveredAt = &delivered
+	}
+	return delivery, nil
+}
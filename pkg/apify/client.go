@@ -3,6 +3,7 @@ package apify
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -19,33 +20,48 @@ const (
 	GetDatasetItemsURL = "https://api.apify.com/v2/actor-runs/%s/dataset/items"     // GetDatasetItemsURL is the URL for getting the dataset items from the Apify API
 )
 
+// ErrNoTripadvisorActor is returned when no Tripadvisor task is configured.
+var ErrNoTripadvisorActor = errors.New("apify: no Tripadvisor actor configured")
+
 type Poll struct {
 	Data chan []byte
 	Err  chan error
 }
 
+// POIResponse delivers the leniently parsed dataset of a run, or the error that stopped it.
+// RunID identifies the started run.
 type POIResponse struct {
-	Data chan []models.POI
-	Err  chan error
+	RunID string
+	Data  chan models.ParseResult
+	Err   chan error
 }
 
 type Client struct {
-	client           *http.Client
-	actorExtractorID string
-	actorScraperID   string
-	key              string
+	client             *http.Client
+	actorExtractorID   string
+	actorScraperID     string
+	actorTripadvisorID string
+	key                string
 }
 
 // NewClient creates a new Apify client.
-func NewClient(key, actorExtractorID, actorScraperID string) *Client {
+func NewClient(key, actorExtractorID, actorScraperID, actorTripadvisorID string) *Client {
 	return &Client{
-		client:           &http.Client{},
-		actorExtractorID: actorExtractorID,
-		actorScraperID:   actorScraperID,
-		key:              key,
+		client:             &http.Client{},
+		actorExtractorID:   actorExtractorID,
+		actorScraperID:     actorScraperID,
+		actorTripadvisorID: actorTripadvisorID,
+		key:                key,
 	}
 }
 
+// WithHTTPClient makes c send its requests with hc, e.g. one whose transport
+// routes them to a test server. It returns c.
+func (c *Client) WithHTTPClient(hc *http.Client) *Client {
+	c.client = hc
+	return c
+}
+
 // GetDataset gets the dataset from the Apify API.
 // returns an array of items.
 func (c *Client) GetDataset(id string) ([]byte, error) {
@@ -155,11 +171,15 @@ func (c *Client) newRequest(method, url string, body []byte) (*http.Request, err
 // TripAdvisorPOIs extracts POIs from the Apify API.
 // Required that a task is created in the Apify API, or via the console. The task ID is required to run the task.
 func (c *Client) TripAdvisorPOIs(payload models.TripAdvisorInput, maxResults int, backoff bool) POIResponse {
-	completeURL := fmt.Sprintf(RunTaskURL, c.actorExtractorID, maxResults)
+	completeURL := fmt.Sprintf(RunTaskURL, c.actorTripadvisorID, maxResults)
 	resp := POIResponse{
-		Data: make(chan []models.POI, 1),
+		Data: make(chan models.ParseResult, 1),
 		Err:  make(chan error, 1),
 	}
+	if c.actorTripadvisorID == "" {
+		resp.Err <- ErrNoTripadvisorActor
+		return resp
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -202,18 +222,19 @@ func (c *Client) TripAdvisorPOIs(payload models.TripAdvisorInput, maxResults int
 		Err:  make(chan error, 1),
 	}
 
+	resp.RunID = unmarshaledResponse.Data.ID
 	go c.pollingWithBackoff(unmarshaledResponse.Data.ID, p, backoff)
 
 	go func() {
 		select {
 		case data := <-p.Data:
 			fmt.Println("Data received")
-			poilist, err := models.ParsePOIsFromJSON(data)
+			result, err := models.DefaultRegistry.Parse(data, models.ParserTripadvisor, models.ParseLenient)
 			if err != nil {
 				resp.Err <- err
 				return
 			}
-			resp.Data <- poilist
+			resp.Data <- result
 		case err := <-p.Err:
 			resp.Err <- err
 			return
@@ -229,7 +250,7 @@ func (c *Client) ExtractPOIs(payload models.InputPayloadMaps, maxResults int, ba
 
 	completeURL := fmt.Sprintf(RunTaskURL, c.actorExtractorID, maxResults)
 	resp := POIResponse{
-		Data: make(chan []models.POI, 1),
+		Data: make(chan models.ParseResult, 1),
 		Err:  make(chan error, 1),
 	}
 
@@ -276,18 +297,19 @@ func (c *Client) ExtractPOIs(payload models.InputPayloadMaps, maxResults int, ba
 		Err:  make(chan error, 1),
 	}
 
+	resp.RunID = unmarshaledResponse.Data.ID
 	go c.pollingWithBackoff(unmarshaledResponse.Data.ID, p, backoff)
 
 	go func() {
 		select {
 		case data := <-p.Data:
 			fmt.Println("Data received")
-			poilist, err := models.ParsePOIsFromJSON(data)
+			result, err := models.DefaultRegistry.Parse(data, models.ParserGoogleMapsExtractor, models.ParseLenient)
 			if err != nil {
 				resp.Err <- err
 				return
 			}
-			resp.Data <- poilist
+			resp.Data <- result
 		case err := <-p.Err:
 			resp.Err <- err
 			return
@@ -303,7 +325,7 @@ func (c *Client) ScrapePOIs(payload models.ScraperInputPayloadMaps, backoff bool
 
 	completeURL := fmt.Sprintf(BaseRunTaskURL, c.actorScraperID)
 	resp := POIResponse{
-		Data: make(chan []models.POI, 1),
+		Data: make(chan models.ParseResult, 1),
 		Err:  make(chan error, 1),
 	}
 
@@ -350,18 +372,19 @@ func (c *Client) ScrapePOIs(payload models.ScraperInputPayloadMaps, backoff bool
 		Err:  make(chan error, 1),
 	}
 
+	resp.RunID = unmarshaledResponse.Data.ID
 	go c.pollingWithBackoff(unmarshaledResponse.Data.ID, p, backoff)
 
 	go func() {
 		select {
 		case data := <-p.Data:
 			fmt.Println("Data received")
-			poilist, err := models.ParsePOIsFromJSON(data)
+			result, err := models.DefaultRegistry.Parse(data, models.ParserGoogleMapsScraper, models.ParseLenient)
 			if err != nil {
 				resp.Err <- err
 				return
 			}
-			resp.Data <- poilist
+			resp.Data <- result
 		case err := <-p.Err:
 			resp.Err <- err
 			return
@@ -19,7 +19,7 @@ func init() {
 	if err := godotenv.Load("../../.env"); err != nil {
 		panic(err)
 	}
-	client = NewClient(os.Getenv("APIFY_KEY"), os.Getenv("APIFY_ACTOR_EXTRACTOR_ID"), os.Getenv("APIFY_ACTOR_SCRAPER_ID"))
+	client = NewClient(os.Getenv("APIFY_KEY"), os.Getenv("APIFY_ACTOR_EXTRACTOR_ID"), os.Getenv("APIFY_ACTOR_SCRAPER_ID"), os.Getenv("APIFY_ACTOR_TRIPADVISOR_ID"))
 }
 
 func TestClient(t *testing.T) {
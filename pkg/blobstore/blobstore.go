@@ -0,0 +1,37 @@
+// Package blobstore stores binary objects, such as archived images, by key.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"path"
+)
+
+// ErrNotFound is returned when no object is stored under a key.
+var ErrNotFound = errors.New("blob not found")
+
+// Info describes a stored object.
+type Info struct {
+	ContentType string
+	Size        int64
+}
+
+// Store keeps objects under slash-separated keys such as "images/abc.jpg".
+type Store interface {
+	// Put stores the content read from r under key, replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get opens the object stored under key. The caller closes the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, Info, error)
+	// Delete removes the object stored under key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// contentTypeOf guesses the content type of a key from its extension.
+func contentTypeOf(key string) string {
+	if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,122 @@
+// Package changes classifies what changed about a place between two scrapes:
+// it opened, closed, reopened, got a new name, a different rating or moved.
+package changes
+
+import (
+	"math"
+	"strings"
+
+	"apify-poi-data/pkg/geo"
+)
+
+// Type names a kind of change.
+type Type string
+
+const (
+	TypeNew               Type = "new"
+	TypeClosed            Type = "closed"
+	TypeTemporarilyClosed Type = "temporarily_closed"
+	TypeReopened          Type = "reopened"
+	TypeRenamed           Type = "renamed"
+	TypeRatingJump        Type = "rating_jump"
+	TypeRatingLost        Type = "rating_lost"
+	TypeMoved             Type = "moved"
+	// TypeDisappeared is not detected from snapshots: a place disappears when
+	// consecutive scrapes of the same search no longer return it.
+	TypeDisappeared Type = "disappeared"
+)
+
+// Types lists every change type.
+var Types = []Type{
+	TypeNew,
+	TypeClosed,
+	TypeTemporarilyClosed,
+	TypeReopened,
+	TypeRenamed,
+	TypeRatingJump,
+	TypeRatingLost,
+	TypeMoved,
+	TypeDisappeared,
+}
+
+// Valid reports whether t is a known change type.
+func (t Type) Valid() bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot is the state of a place that changes are detected on.
+type Snapshot struct {
+	Name              string
+	PermanentlyClosed bool
+	TemporarilyClosed bool
+	Rating            *float64   // nil when the place has no rating
+	Location          *geo.Point // nil when the place has no location
+}
+
+func (s Snapshot) open() bool {
+	return !s.PermanentlyClosed && !s.TemporarilyClosed
+}
+
+// Change is a detected change with the values before and after it.
+type Change struct {
+	Type Type
+	From any
+	To   any
+}
+
+// Thresholds are the smallest differences reported as a change.
+type Thresholds struct {
+	RatingJump float64 // Stars, e.g. 0.5
+	MoveKm     float64
+}
+
+// Detect compares the previous snapshot of a place with the current one. A
+// nil previous snapshot means the place was not stored before. Values missing
+// from the current snapshot, other than the rating, are not reported as
+// changes since scrapers leave out what they could not read.
+func Detect(prev *Snapshot, cur Snapshot, th Thresholds) []Change {
+	if prev == nil {
+		return []Change{{Type: TypeNew, To: cur.Name}}
+	}
+
+	var changes []Change
+	switch {
+	case cur.PermanentlyClosed && !prev.PermanentlyClosed:
+		changes = append(changes, Change{Type: TypeClosed})
+	case cur.TemporarilyClosed && !cur.PermanentlyClosed && prev.open():
+		changes = append(changes, Change{Type: TypeTemporarilyClosed})
+	case cur.open() && !prev.open():
+		changes = append(changes, Change{Type: TypeReopened})
+	}
+
+	if name := strings.TrimSpace(cur.Name); name != "" && !strings.EqualFold(name, strings.TrimSpace(prev.Name)) {
+		changes = append(changes, Change{Type: TypeRenamed, From: prev.Name, To: cur.Name})
+	}
+
+	switch {
+	case prev.Rating != nil && cur.Rating == nil:
+		changes = append(changes, Change{Type: TypeRatingLost, From: *prev.Rating})
+	case prev.Rating != nil && cur.Rating != nil && th.RatingJump > 0 &&
+		math.Abs(*cur.Rating-*prev.Rating) >= th.RatingJump:
+		changes = append(changes, Change{Type: TypeRatingJump, From: *prev.Rating, To: *cur.Rating})
+	}
+
+	if prev.Location != nil && cur.Location != nil && th.MoveKm > 0 &&
+		prev.Location.DistanceKm(*cur.Location) >= th.MoveKm {
+		changes = append(changes, Change{
+			Type: TypeMoved,
+			From: location(*prev.Location),
+			To:   location(*cur.Location),
+		})
+	}
+	return changes
+}
+
+func location(p geo.Point) map[string]float64 {
+	return map[string]float64{"lat": p.Lat, "lng": p.Lng}
+}
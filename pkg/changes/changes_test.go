@@ -0,0 +1,91 @@
+package changes
+
+import (
+	"reflect"
+	"testing"
+
+	"apify-poi-data/pkg/geo"
+)
+
+var thresholds = Thresholds{RatingJump: 0.5, MoveKm: 0.1}
+
+func rating(r float64) *float64 {
+	return &r
+}
+
+func types(changes []Change) []Type {
+	var out []Type
+	for _, c := range changes {
+		out = append(out, c.Type)
+	}
+	return out
+}
+
+func TestDetect(t *testing.T) {
+	base := Snapshot{
+		Name:     "Pizzeria Roma",
+		Rating:   rating(4.2),
+		Location: &geo.Point{Lat: 57.7089, Lng: 11.9746},
+	}
+	tests := []struct {
+		name   string
+		change func(s *Snapshot)
+		want   []Type
+	}{
+		{"Unchanged", func(s *Snapshot) {}, nil},
+		{"Closed", func(s *Snapshot) { s.PermanentlyClosed = true }, []Type{TypeClosed}},
+		{"TemporarilyClosed", func(s *Snapshot) { s.TemporarilyClosed = true }, []Type{TypeTemporarilyClosed}},
+		{"Renamed", func(s *Snapshot) { s.Name = "Roma Pizzeria" }, []Type{TypeRenamed}},
+		{"RenamedCase", func(s *Snapshot) { s.Name = "PIZZERIA ROMA " }, nil},
+		{"NameMissing", func(s *Snapshot) { s.Name = "" }, nil},
+		{"RatingJump", func(s *Snapshot) { s.Rating = rating(3.6) }, []Type{TypeRatingJump}},
+		{"RatingDrift", func(s *Snapshot) { s.Rating = rating(4.4) }, nil},
+		{"RatingLost", func(s *Snapshot) { s.Rating = nil }, []Type{TypeRatingLost}},
+		{"Moved", func(s *Snapshot) { s.Location = &geo.Point{Lat: 57.7179, Lng: 11.9746} }, []Type{TypeMoved}},
+		{"Jitter", func(s *Snapshot) { s.Location = &geo.Point{Lat: 57.7090, Lng: 11.9747} }, nil},
+		{"LocationMissing", func(s *Snapshot) { s.Location = nil }, nil},
+		{"ClosedAndRenamed", func(s *Snapshot) { s.PermanentlyClosed, s.Name = true, "Roma" }, []Type{TypeClosed, TypeRenamed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := base
+			cur := base
+			tt.change(&cur)
+			if got := types(Detect(&prev, cur, thresholds)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Detect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectReopened(t *testing.T) {
+	for _, prev := range []Snapshot{
+		{Name: "Roma", PermanentlyClosed: true},
+		{Name: "Roma", TemporarilyClosed: true},
+	} {
+		got := types(Detect(&prev, Snapshot{Name: "Roma"}, thresholds))
+		if !reflect.DeepEqual(got, []Type{TypeReopened}) {
+			t.Errorf("Detect(%+v) = %v, want reopened", prev, got)
+		}
+	}
+
+	// Moving from temporarily to permanently closed is a closure, not a reopening
+	prev := Snapshot{Name: "Roma", TemporarilyClosed: true}
+	got := types(Detect(&prev, Snapshot{Name: "Roma", PermanentlyClosed: true}, thresholds))
+	if !reflect.DeepEqual(got, []Type{TypeClosed}) {
+		t.Errorf("Detect() = %v, want closed", got)
+	}
+}
+
+func TestDetectNew(t *testing.T) {
+	got := Detect(nil, Snapshot{Name: "Roma"}, thresholds)
+	if len(got) != 1 || got[0].Type != TypeNew || got[0].To != "Roma" {
+		t.Errorf("Detect(nil) = %+v, want a new place", got)
+	}
+}
+
+func TestTypeValid(t *testing.T) {
+	if !TypeDisappeared.Valid() || Type("opened").Valid() {
+		t.Error("unexpected validity of change types")
+	}
+}
@@ -0,0 +1,158 @@
+// Package geo checks the coordinates reported by scrapers for values that
+// cannot be right: out of range, the (0,0) placeholder, latitude and
+// longitude swapped, or far away from the area that was searched.
+package geo
+
+import (
+	"math"
+)
+
+// Issue names what is wrong with a coordinate. The zero value means nothing is.
+type Issue string
+
+const (
+	IssueNone        Issue = ""
+	IssueInvalid     Issue = "invalid_coordinates"
+	IssueNullIsland  Issue = "null_island"
+	IssueSwapped     Issue = "swapped_coordinates"
+	IssueOutsideArea Issue = "outside_search_area"
+)
+
+const (
+	earthRadiusKm = 6371.0
+	// Scrapers report a missing location as 0,0
+	nullIslandEpsilon = 1e-4
+)
+
+// Point is a WGS84 coordinate.
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Swapped returns the point with latitude and longitude exchanged.
+func (p Point) Swapped() Point {
+	return Point{Lat: p.Lng, Lng: p.Lat}
+}
+
+func (p Point) inRange() bool {
+	return !math.IsNaN(p.Lat) && !math.IsNaN(p.Lng) &&
+		!math.IsInf(p.Lat, 0) && !math.IsInf(p.Lng, 0) &&
+		p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// Check reports whether a point can be a real location.
+func Check(p Point) Issue {
+	if !p.inRange() {
+		// A latitude beyond the poles that is a valid longitude is most likely swapped
+		if p.Swapped().inRange() {
+			return IssueSwapped
+		}
+		return IssueInvalid
+	}
+	if math.Abs(p.Lat) < nullIslandEpsilon && math.Abs(p.Lng) < nullIslandEpsilon {
+		return IssueNullIsland
+	}
+	return IssueNone
+}
+
+// Polygon is a ring of points; the last point connects back to the first.
+type Polygon []Point
+
+// Contains reports whether p lies inside the polygon, by ray casting.
+func (poly Polygon) Contains(p Point) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// DistanceKm is the distance from p to the polygon, 0 when p is inside.
+func (poly Polygon) DistanceKm(p Point) float64 {
+	if len(poly) == 0 {
+		return math.Inf(1)
+	}
+	if poly.Contains(p) {
+		return 0
+	}
+	best := math.Inf(1)
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		if d := segmentDistanceKm(p, poly[j], poly[i]); d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// Area is the union of the polygons a search was limited to.
+type Area []Polygon
+
+// DistanceKm is the distance from p to the closest polygon of the area.
+func (a Area) DistanceKm(p Point) float64 {
+	best := math.Inf(1)
+	for _, poly := range a {
+		if d := poly.DistanceKm(p); d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// Center returns the middle of the area's bounding box.
+func (a Area) Center() (Point, bool) {
+	minLat, minLng := math.Inf(1), math.Inf(1)
+	maxLat, maxLng := math.Inf(-1), math.Inf(-1)
+	for _, poly := range a {
+		for _, p := range poly {
+			minLat, maxLat = math.Min(minLat, p.Lat), math.Max(maxLat, p.Lat)
+			minLng, maxLng = math.Min(minLng, p.Lng), math.Max(maxLng, p.Lng)
+		}
+	}
+	if math.IsInf(minLat, 1) {
+		return Point{}, false
+	}
+	return Point{Lat: (minLat + maxLat) / 2, Lng: (minLng + maxLng) / 2}, true
+}
+
+// Check reports points that lie more than toleranceKm outside the area. A
+// point that only fits the area with latitude and longitude exchanged is
+// reported as swapped. An empty area accepts every point.
+func (a Area) Check(p Point, toleranceKm float64) Issue {
+	if len(a) == 0 || a.DistanceKm(p) <= toleranceKm {
+		return IssueNone
+	}
+	if s := p.Swapped(); s.inRange() && a.DistanceKm(s) <= toleranceKm {
+		return IssueSwapped
+	}
+	return IssueOutsideArea
+}
+
+// segmentDistanceKm projects the segment onto a plane tangent at p, which is
+// accurate enough for the few kilometres that matter here.
+func segmentDistanceKm(p, a, b Point) float64 {
+	cosLat := math.Cos(p.Lat * math.Pi / 180)
+	project := func(q Point) (float64, float64) {
+		x := (q.Lng - p.Lng) * cosLat * math.Pi / 180 * earthRadiusKm
+		y := (q.Lat - p.Lat) * math.Pi / 180 * earthRadiusKm
+		return x, y
+	}
+	ax, ay := project(a)
+	bx, by := project(b)
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/l))
+	}
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
+
+// DistanceKm returns the distance between two points, accurate for the short
+// distances between a place and its area or its previous location.
+func (p Point) DistanceKm(q Point) float64 {
+	return segmentDistanceKm(p, q, q)
+}
@@ -0,0 +1,79 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+// gothenburg is a rough box around central Gothenburg.
+var gothenburg = Area{{
+	{Lat: 57.68, Lng: 11.90},
+	{Lat: 57.68, Lng: 12.05},
+	{Lat: 57.73, Lng: 12.05},
+	{Lat: 57.73, Lng: 11.90},
+}}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Point
+		want Issue
+	}{
+		{"Valid", Point{Lat: 57.70, Lng: 11.97}, IssueNone},
+		{"NullIsland", Point{Lat: 0, Lng: 0}, IssueNullIsland},
+		{"Swapped", Point{Lat: 151.2, Lng: -33.8}, IssueSwapped},
+		{"OutOfRange", Point{Lat: 120, Lng: 200}, IssueInvalid},
+		{"NaN", Point{Lat: math.NaN(), Lng: 10}, IssueInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Check(tt.p); got != tt.want {
+				t.Errorf("Check(%v) = %q, want %q", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAreaCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Point
+		want Issue
+	}{
+		{"Inside", Point{Lat: 57.70, Lng: 11.97}, IssueNone},
+		{"WithinTolerance", Point{Lat: 57.70, Lng: 12.10}, IssueNone},
+		{"Swapped", Point{Lat: 11.97, Lng: 57.70}, IssueSwapped},
+		{"Outside", Point{Lat: 59.33, Lng: 18.07}, IssueOutsideArea},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gothenburg.Check(tt.p, 5); got != tt.want {
+				t.Errorf("Check(%v) = %q, want %q", tt.p, got, tt.want)
+			}
+		})
+	}
+
+	if got := (Area{}).Check(Point{Lat: 59.33, Lng: 18.07}, 5); got != IssueNone {
+		t.Errorf("expected an empty area to accept every point, got %q", got)
+	}
+}
+
+func TestPointDistanceKm(t *testing.T) {
+	center := Point{Lat: 57.7089, Lng: 11.9746}
+	tests := []struct {
+		name string
+		q    Point
+		want float64
+	}{
+		{"Same", center, 0},
+		{"North", Point{Lat: 57.7179, Lng: 11.9746}, 1.0},
+		{"East", Point{Lat: 57.7089, Lng: 11.9918}, 1.02},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := center.DistanceKm(tt.q); math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("DistanceKm(%v) = %.3f, want %.2f", tt.q, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package hours
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Day is one entry of the openingHours array of the Google Maps actors,
+// e.g. {"day": "Monday", "hours": "9 AM to 5:30 PM"}.
+type Day struct {
+	Day   string `json:"day"`
+	Hours string `json:"hours"`
+}
+
+var weekdays = map[string]int{
+	"monday":    0,
+	"tuesday":   1,
+	"wednesday": 2,
+	"thursday":  3,
+	"friday":    4,
+	"saturday":  5,
+	"sunday":    6,
+}
+
+var (
+	// rangeSeparator splits "9 AM–5 PM", "9 AM - 5 PM" and "9 AM to 5 PM".
+	rangeSeparator = regexp.MustCompile(`\s*(?:–|—|-|\bto\b)\s*`)
+	timeOfDay      = regexp.MustCompile(`(?i)^(\d{1,2})(?:[:.](\d{2}))?\s*([ap])?\.?\s*(?:m\.?)?$`)
+	// spaces are the non-breaking and thin spaces Google puts around times.
+	spaces = strings.NewReplacer("\u00a0", " ", "\u2009", " ", "\u202f", " ")
+)
+
+// ParseGoogle converts the opening hours of a Google Maps place. Days may
+// hold several ranges separated by commas, and ranges that end after
+// midnight continue into the next day.
+func ParseGoogle(days []Day) (Week, error) {
+	var w Week
+	for _, d := range days {
+		name, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(d.Day)), " ") // "Monday (Labor Day)"
+		day, ok := weekdays[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown day: %q", d.Day)
+		}
+		base := day * MinutesPerDay
+
+		hours := strings.TrimSpace(spaces.Replace(d.Hours))
+		switch strings.ToLower(hours) {
+		case "closed", "":
+			continue
+		case "open 24 hours":
+			w = w.add(base, base+MinutesPerDay)
+			continue
+		}
+		for _, r := range strings.Split(hours, ",") {
+			start, end, err := parseGoogleRange(strings.TrimSpace(r))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", d.Day, err)
+			}
+			w = w.add(base+start, base+end)
+		}
+	}
+	return w.Normalize(), nil
+}
+
+// parseGoogleRange parses a range such as "9:30 AM–2 PM", "1–5 PM" or
+// "18:00–02:00" into minutes since midnight. The end is after the start and
+// lies in the next day for ranges past midnight.
+func parseGoogleRange(r string) (start, end int, err error) {
+	parts := rangeSeparator.Split(r, -1)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid range: %q", r)
+	}
+	sh, sm, sMeridiem, err := parseTimeOfDay(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	eh, em, eMeridiem, err := parseTimeOfDay(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end = to24(eh, eMeridiem)*60 + em
+	switch {
+	case sMeridiem != 0:
+		start = to24(sh, sMeridiem)*60 + sm
+	case eMeridiem != 0:
+		// "1–5 PM" shares the meridiem of the end, unless that puts the start
+		// after the end, as in "11–2 PM".
+		start = to24(sh, eMeridiem)*60 + sm
+		if start > end {
+			other := byte('a')
+			if eMeridiem == 'a' {
+				other = 'p'
+			}
+			start = to24(sh, other)*60 + sm
+		}
+	default:
+		start = sh*60 + sm
+	}
+	if start >= MinutesPerDay || end > MinutesPerDay {
+		return 0, 0, fmt.Errorf("invalid range: %q", r)
+	}
+	if end <= start {
+		end += MinutesPerDay // Past midnight
+	}
+	return start, end, nil
+}
+
+// parseTimeOfDay parses "9", "9:30", "9 AM" or "21.30". The meridiem is 'a',
+// 'p' or 0 when absent.
+func parseTimeOfDay(s string) (hour, minute int, meridiem byte, err error) {
+	m := timeOfDay.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, 0, 0, fmt.Errorf("invalid time: %q", s)
+	}
+	hour, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	if m[3] != "" {
+		meridiem = strings.ToLower(m[3])[0]
+		if hour < 1 || hour > 12 {
+			return 0, 0, 0, fmt.Errorf("invalid time: %q", s)
+		}
+	}
+	if hour > 24 || minute > 59 {
+		return 0, 0, 0, fmt.Errorf("invalid time: %q", s)
+	}
+	return hour, minute, meridiem, nil
+}
+
+// to24 converts a 12-hour clock hour, where 12 AM is midnight.
+func to24(hour int, meridiem byte) int {
+	switch meridiem {
+	case 'a':
+		return hour % 12
+	case 'p':
+		return hour%12 + 12
+	}
+	return hour
+}
@@ -0,0 +1,74 @@
+// Package hours parses opening hours from the scraped sources into a common
+// weekly model of intervals in the local time of a place.
+package hours
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	MinutesPerDay  = 24 * 60
+	MinutesPerWeek = 7 * MinutesPerDay
+)
+
+// Interval is a span of opening time in minutes since Monday 00:00 local time.
+// Start is inclusive and End exclusive; 0 <= Start < End <= MinutesPerWeek.
+type Interval struct {
+	Start int
+	End   int
+}
+
+// Week holds the opening intervals of a place over one week.
+type Week []Interval
+
+// add appends the interval from start to end, which may run past the end of
+// the week, as when a place opens Sunday evening and closes Monday morning.
+func (w Week) add(start, end int) Week {
+	length := end - start
+	start = ((start % MinutesPerWeek) + MinutesPerWeek) % MinutesPerWeek
+	end = start + length
+	if end > MinutesPerWeek {
+		return append(w, Interval{Start: start, End: MinutesPerWeek}, Interval{Start: 0, End: end - MinutesPerWeek})
+	}
+	return append(w, Interval{Start: start, End: end})
+}
+
+// Normalize sorts the intervals and merges the ones that overlap or touch.
+func (w Week) Normalize() Week {
+	if len(w) == 0 {
+		return nil
+	}
+	sorted := append(Week(nil), w...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+	out := sorted[:1]
+	for _, iv := range sorted[1:] {
+		last := &out[len(out)-1]
+		if iv.Start <= last.End {
+			last.End = max(last.End, iv.End)
+			continue
+		}
+		out = append(out, iv)
+	}
+	return out
+}
+
+// OpenAt reports whether the place is open at the given time, which must be
+// in the local time of the place.
+func (w Week) OpenAt(t time.Time) bool {
+	m := MinuteOfWeek(t)
+	for _, iv := range w {
+		if m >= iv.Start && m < iv.End {
+			return true
+		}
+	}
+	return false
+}
+
+// MinuteOfWeek returns the minutes since Monday 00:00 of the week of t.
+func MinuteOfWeek(t time.Time) int {
+	day := (int(t.Weekday()) + 6) % 7 // Monday is 0
+	return day*MinutesPerDay + t.Hour()*60 + t.Minute()
+}
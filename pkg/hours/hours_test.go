@@ -0,0 +1,110 @@
+package hours
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseGoogle(t *testing.T) {
+	tests := []struct {
+		name string
+		days []Day
+		want Week
+	}{
+		{
+			name: "Simple",
+			days: []Day{{Day: "Monday", Hours: "9 AM to 5 PM"}},
+			want: Week{{Start: 9 * 60, End: 17 * 60}},
+		},
+		{
+			name: "Narrow spaces and shared meridiem",
+			days: []Day{{Day: "Tuesday", Hours: "9:30\u202fAM\u2009–\u20092\u202fPM, 3–6:15\u202fPM"}},
+			want: Week{
+				{Start: MinutesPerDay + 9*60 + 30, End: MinutesPerDay + 14*60},
+				{Start: MinutesPerDay + 15*60, End: MinutesPerDay + 18*60 + 15},
+			},
+		},
+		{
+			name: "Start before noon with PM end",
+			days: []Day{{Day: "Wednesday", Hours: "11–2 PM"}},
+			want: Week{{Start: 2*MinutesPerDay + 11*60, End: 2*MinutesPerDay + 14*60}},
+		},
+		{
+			name: "Overnight",
+			days: []Day{{Day: "Friday", Hours: "6 PM–2 AM"}},
+			want: Week{{Start: 4*MinutesPerDay + 18*60, End: 5*MinutesPerDay + 2*60}},
+		},
+		{
+			name: "Midnight close",
+			days: []Day{{Day: "Saturday", Hours: "10 AM–12 AM"}},
+			want: Week{{Start: 5*MinutesPerDay + 10*60, End: 6 * MinutesPerDay}},
+		},
+		{
+			name: "Overnight into Monday",
+			days: []Day{{Day: "Sunday", Hours: "22:00–03:00"}, {Day: "Monday", Hours: "Closed"}},
+			want: Week{{Start: 0, End: 3 * 60}, {Start: 6*MinutesPerDay + 22*60, End: MinutesPerWeek}},
+		},
+		{
+			name: "Open 24 hours merges",
+			days: []Day{{Day: "Monday", Hours: "Open 24 hours"}, {Day: "Tuesday (Holiday)", Hours: "Open 24 hours"}},
+			want: Week{{Start: 0, End: 2 * MinutesPerDay}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseGoogle(tt.days)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, hours := range []string{"9 AM", "13 PM–2 PM", "soon"} {
+		if _, err := ParseGoogle([]Day{{Day: "Monday", Hours: hours}}); err == nil {
+			t.Errorf("expected an error for %q", hours)
+		}
+	}
+	if _, err := ParseGoogle([]Day{{Day: "Someday", Hours: "Closed"}}); err == nil {
+		t.Error("expected an error for an unknown day")
+	}
+}
+
+func TestFromWeekRanges(t *testing.T) {
+	days := make([][]Range, 7)
+	days[0] = []Range{{Open: 20 * 60, Close: 60}} // Sunday, past midnight
+	days[1] = []Range{{Open: 9 * 60, Close: 17 * 60}}
+
+	got := FromWeekRanges(days)
+	want := Week{
+		{Start: 0, End: 60},
+		{Start: 9 * 60, End: 17 * 60},
+		{Start: 6*MinutesPerDay + 20*60, End: MinutesPerWeek},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestOpenAt(t *testing.T) {
+	w, err := ParseGoogle([]Day{{Day: "Sunday", Hours: "10 PM–3 AM"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	monday := time.Date(2024, time.March, 4, 2, 59, 0, 0, time.UTC)
+	if !w.OpenAt(monday) {
+		t.Error("expected open early Monday")
+	}
+	if w.OpenAt(monday.Add(time.Minute)) {
+		t.Error("expected closed at 3 AM Monday")
+	}
+}
+
+func TestTimezoneAt(t *testing.T) {
+	if tz := TimezoneAt(57.7089, 11.9746); tz != "Europe/Stockholm" {
+		t.Errorf("expected Europe/Stockholm for Gothenburg, got %q", tz)
+	}
+}
@@ -0,0 +1,28 @@
+package hours
+
+// Range is an opening range in minutes since midnight. Close may be less than
+// Open, or greater than a day, for ranges that end after midnight.
+type Range struct {
+	Open  int
+	Close int
+}
+
+// FromWeekRanges converts per-day minute ranges as used by Tripadvisor, where
+// index 0 is Sunday.
+func FromWeekRanges(days [][]Range) Week {
+	var w Week
+	for i, ranges := range days {
+		if i >= 7 {
+			break
+		}
+		base := ((i + 6) % 7) * MinutesPerDay
+		for _, r := range ranges {
+			end := r.Close
+			if end <= r.Open {
+				end += MinutesPerDay
+			}
+			w = w.add(base+r.Open, base+end)
+		}
+	}
+	return w.Normalize()
+}
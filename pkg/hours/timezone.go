@@ -0,0 +1,22 @@
+package hours
+
+import (
+	"sync"
+
+	"github.com/ringsaturn/tzf"
+)
+
+// The finder loads its boundary data once, on first use.
+var finder = sync.OnceValues(func() (tzf.F, error) {
+	return tzf.NewDefaultFinder()
+})
+
+// TimezoneAt returns the IANA time zone at the coordinates, or "" when it is
+// unknown.
+func TimezoneAt(lat, lng float64) string {
+	f, err := finder()
+	if err != nil {
+		return ""
+	}
+	return f.GetTimezoneName(lng, lat)
+}
@@ -0,0 +1,76 @@
+// Package imaging decodes scraped images, scales them down to thumbnails and
+// computes perceptual hashes to find near-duplicates.
+package imaging
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif" // Decoders for the formats the scraped CDNs serve
+	"image/jpeg"
+	_ "image/png"
+	"math/bits"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ThumbnailQuality is the JPEG quality of encoded thumbnails.
+const ThumbnailQuality = 80
+
+// Decode decodes an image and returns its format, e.g. "jpeg" or "webp".
+func Decode(data []byte) (image.Image, string, error) {
+	return image.Decode(bytes.NewReader(data))
+}
+
+// Thumbnail scales img down so that its longer side is at most size pixels,
+// keeping the aspect ratio. Smaller images are returned unchanged.
+func Thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	if w >= h {
+		h = max(1, h*size/w)
+		w = size
+	} else {
+		w = max(1, w*size/h)
+		h = size
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// EncodeJPEG encodes a thumbnail.
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: ThumbnailQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DHash computes the difference hash of an image: the image is reduced to
+// 9x8 gray pixels and each bit tells whether a pixel is brighter than its
+// right neighbour. Similar images have hashes a small Hamming distance apart.
+func DHash(img image.Image) uint64 {
+	small := image.NewGray(image.Rect(0, 0, 9, 8))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if small.GrayAt(x, y).Y > small.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// Distance returns the number of bits in which two hashes differ.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,69 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// gradient draws a horizontal gradient, brightening to the right when rising is set.
+func gradient(w, h int, rising bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(x * 255 / (w - 1))
+			if !rising {
+				v = 255 - v
+			}
+			img.Set(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	return img
+}
+
+func TestThumbnail(t *testing.T) {
+	thumb := Thumbnail(gradient(800, 400, true), 200)
+	if b := thumb.Bounds(); b.Dx() != 200 || b.Dy() != 100 {
+		t.Fatalf("expected 200x100, got %dx%d", b.Dx(), b.Dy())
+	}
+	thumb = Thumbnail(gradient(300, 600, true), 200)
+	if b := thumb.Bounds(); b.Dx() != 100 || b.Dy() != 200 {
+		t.Fatalf("expected 100x200, got %dx%d", b.Dx(), b.Dy())
+	}
+	small := gradient(50, 50, true)
+	if Thumbnail(small, 200) != small {
+		t.Fatal("expected small images to be returned unchanged")
+	}
+}
+
+func TestDHash(t *testing.T) {
+	rising := DHash(gradient(640, 480, true))
+	if rising != 0 {
+		t.Fatalf("expected no brighter left neighbours, got %064b", rising)
+	}
+	falling := DHash(gradient(640, 480, false))
+	if falling != ^uint64(0) {
+		t.Fatalf("expected every left neighbour to be brighter, got %064b", falling)
+	}
+
+	// A scaled copy hashes the same
+	if d := Distance(DHash(Thumbnail(gradient(640, 480, false), 120)), falling); d > 4 {
+		t.Fatalf("expected a scaled copy to be close, distance %d", d)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, gradient(10, 10, true)); err != nil {
+		t.Fatal(err)
+	}
+	_, format, err := Decode(buf.Bytes())
+	if err != nil || format != "png" {
+		t.Fatalf("expected png, got %q, %v", format, err)
+	}
+	if _, _, err := Decode([]byte("not an image")); err == nil {
+		t.Fatal("expected an error for garbage")
+	}
+}
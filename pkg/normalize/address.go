@@ -0,0 +1,70 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Address holds the normalized components of a postal address.
+type Address struct {
+	Street      string // Street name without the house number
+	HouseNumber string
+	PostalCode  string
+	City        string
+	CountryCode string // ISO 3166-1 alpha-2, uppercase
+}
+
+var (
+	// "Kungstorget 9", "Main Street 12B", "Rue de Rivoli 99-101"
+	numberLast = regexp.MustCompile(`^(.*?\D)\s+(\d+[\p{L}]?(?:\s*[-/]\s*\d+[\p{L}]?)?)$`)
+	// "123 Main St", "12-14 Queen's Road"
+	numberFirst = regexp.MustCompile(`^(\d+[\p{L}]?(?:\s*[-/]\s*\d+[\p{L}]?)?),?\s+(.*\D.*)$`)
+)
+
+// NewAddress normalizes the address pieces reported by a scraper.
+func NewAddress(street, postalCode, city, countryCode string) Address {
+	a := Address{
+		PostalCode:  PostalCode(postalCode),
+		City:        collapseSpaces(city),
+		CountryCode: strings.ToUpper(strings.TrimSpace(countryCode)),
+	}
+	a.Street, a.HouseNumber = SplitStreet(street)
+	return a
+}
+
+// SplitStreet separates the house number from a street line.
+func SplitStreet(street string) (name, number string) {
+	street = collapseSpaces(street)
+	if m := numberLast.FindStringSubmatch(street); m != nil {
+		return strings.TrimRight(m[1], " ,"), strings.ToUpper(strings.ReplaceAll(m[2], " ", ""))
+	}
+	if m := numberFirst.FindStringSubmatch(street); m != nil {
+		return m[2], strings.ToUpper(strings.ReplaceAll(m[1], " ", ""))
+	}
+	return street, ""
+}
+
+// PostalCode uppercases a postal code and removes its inner spaces, so that
+// "411 17" and "41117" compare equal.
+func PostalCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, code)
+}
+
+// Key is a lowercase match key for the address, empty when too little is known.
+func (a Address) Key() string {
+	if a.Street == "" || a.CountryCode == "" || (a.PostalCode == "" && a.City == "") {
+		return ""
+	}
+	parts := []string{a.CountryCode, a.PostalCode, a.City, a.Street, a.HouseNumber}
+	return strings.ToLower(strings.Join(parts, "|"))
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,81 @@
+package normalize
+
+import "testing"
+
+func TestPhone(t *testing.T) {
+	tests := []struct {
+		raw, country string
+		want         string
+		ok           bool
+	}{
+		{"031-12 34 56", "SE", "+4631123456", true},
+		{"+46 31 12 34 56", "", "+4631123456", true},
+		{"0046 31 12 34 56", "", "+4631123456", true},
+		{"(415) 555-0132", "us", "+14155550132", true},
+		{"1 415 555 0132", "US", "+14155550132", true},
+		{"06 1234 5678", "IT", "+390612345678", true},
+		{"+44 20 7946 0958 ext. 12", "GB", "+442079460958", true},
+		{"+44 (0)20 7946 0000", "GB", "+442079460000", true},
+		{"0044 (0)20 7946 0000", "", "+442079460000", true},
+		{"12345", "SE", "", false},
+		{"031 12 34 56", "", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Phone(tt.raw, tt.country)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Phone(%q, %q) = %q, %v, want %q, %v", tt.raw, tt.country, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestWebsite(t *testing.T) {
+	tests := []struct {
+		raw       string
+		canonical string
+		host      string
+		ok        bool
+	}{
+		{"http://www.Example.com/", "https://example.com", "example.com", true},
+		{"example.com/menu/?utm_source=google&utm_medium=maps&lang=sv#top", "https://example.com/menu?lang=sv", "example.com", true},
+		{"https://shop.example.co.uk/?fbclid=abc", "https://shop.example.co.uk", "shop.example.co.uk", true},
+		{"mailto:info@example.com", "", "", false},
+		{"not a website", "", "", false},
+	}
+	for _, tt := range tests {
+		canonical, host, ok := Website(tt.raw)
+		if canonical != tt.canonical || host != tt.host || ok != tt.ok {
+			t.Errorf("Website(%q) = %q, %q, %v, want %q, %q, %v", tt.raw, canonical, host, ok, tt.canonical, tt.host, tt.ok)
+		}
+	}
+}
+
+func TestSplitStreet(t *testing.T) {
+	tests := []struct {
+		street, name, number string
+	}{
+		{"Kungstorget 9", "Kungstorget", "9"},
+		{"Main Street 12b", "Main Street", "12B"},
+		{"123 Main St", "Main St", "123"},
+		{"12-14 Queen's Road", "Queen's Road", "12-14"},
+		{"Rue de Rivoli 99 - 101", "Rue de Rivoli", "99-101"},
+		{"Avenida 5 de Mayo", "Avenida 5 de Mayo", ""},
+		{"Drottninggatan", "Drottninggatan", ""},
+	}
+	for _, tt := range tests {
+		name, number := SplitStreet(tt.street)
+		if name != tt.name || number != tt.number {
+			t.Errorf("SplitStreet(%q) = %q, %q, want %q, %q", tt.street, name, number, tt.name, tt.number)
+		}
+	}
+}
+
+func TestAddressKey(t *testing.T) {
+	a := NewAddress("Kungstorget  9", "411 17", "Göteborg", "se")
+	b := NewAddress("Kungstorget 9", "41117", "Göteborg", "SE")
+	if a.Key() == "" || a.Key() != b.Key() {
+		t.Errorf("expected equal keys, got %q and %q", a.Key(), b.Key())
+	}
+	if NewAddress("", "41117", "Göteborg", "SE").Key() != "" {
+		t.Error("expected no key without a street")
+	}
+}
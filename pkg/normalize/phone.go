@@ -0,0 +1,98 @@
+// Package normalize brings scraped contact and address values into a single
+// canonical form, so that the same business matches across runs and sources.
+package normalize
+
+import (
+	"strings"
+)
+
+// callingCodes maps ISO 3166-1 alpha-2 country codes to their calling code.
+var callingCodes = map[string]string{
+	"AD": "376", "AE": "971", "AL": "355", "AM": "374", "AR": "54", "AT": "43",
+	"AU": "61", "AZ": "994", "BA": "387", "BD": "880", "BE": "32", "BG": "359",
+	"BH": "973", "BO": "591", "BR": "55", "BY": "375", "CA": "1", "CH": "41",
+	"CL": "56", "CN": "86", "CO": "57", "CR": "506", "CY": "357", "CZ": "420",
+	"DE": "49", "DK": "45", "DO": "1", "DZ": "213", "EC": "593", "EE": "372",
+	"EG": "20", "ES": "34", "FI": "358", "FR": "33", "GB": "44", "GE": "995",
+	"GR": "30", "GT": "502", "HK": "852", "HR": "385", "HU": "36", "ID": "62",
+	"IE": "353", "IL": "972", "IN": "91", "IQ": "964", "IR": "98", "IS": "354",
+	"IT": "39", "JM": "1", "JO": "962", "JP": "81", "KE": "254", "KR": "82",
+	"KW": "965", "KZ": "7", "LB": "961", "LI": "423", "LK": "94", "LT": "370",
+	"LU": "352", "LV": "371", "MA": "212", "MC": "377", "MD": "373", "ME": "382",
+	"MK": "389", "MT": "356", "MX": "52", "MY": "60", "NG": "234", "NL": "31",
+	"NO": "47", "NZ": "64", "OM": "968", "PA": "507", "PE": "51", "PH": "63",
+	"PK": "92", "PL": "48", "PR": "1", "PT": "351", "PY": "595", "QA": "974",
+	"RO": "40", "RS": "381", "RU": "7", "SA": "966", "SE": "46", "SG": "65",
+	"SI": "386", "SK": "421", "SM": "378", "TH": "66", "TN": "216", "TR": "90",
+	"TT": "1", "TW": "886", "TZ": "255", "UA": "380", "UG": "256", "US": "1",
+	"UY": "598", "VA": "39", "VE": "58", "VN": "84", "ZA": "27",
+}
+
+// keepsTrunkZero lists the countries whose leading 0 is part of the number
+// itself rather than a trunk prefix.
+var keepsTrunkZero = map[string]bool{
+	"IT": true, "SM": true, "VA": true,
+}
+
+const (
+	minE164Digits = 8
+	maxE164Digits = 15
+)
+
+// Phone returns a phone number in E.164 form, e.g. "+46311234567". Numbers
+// without an international prefix are read as national numbers of
+// countryCode. It reports false when the number cannot be normalized.
+func Phone(raw, countryCode string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	// Drop extensions such as "ext. 12" or "x12"
+	for _, sep := range []string{"ext", "x", "#"} {
+		if i := strings.Index(strings.ToLower(raw), sep); i > 0 {
+			raw = raw[:i]
+		}
+	}
+
+	// Drop a trunk 0 written after the country code, as in "+44 (0)20 7946 0000"
+	if i := strings.Index(raw, "(0)"); i > 0 {
+		if cc := raw[:i]; strings.HasPrefix(cc, "+") || strings.HasPrefix(onlyDigits(cc), "00") {
+			raw = cc + raw[i+len("(0)"):]
+		}
+	}
+
+	international := strings.HasPrefix(raw, "+")
+	digits := onlyDigits(raw)
+	if !international && strings.HasPrefix(digits, "00") {
+		international = true
+		digits = digits[2:]
+	}
+
+	if !international {
+		country := strings.ToUpper(strings.TrimSpace(countryCode))
+		cc, ok := callingCodes[country]
+		if !ok {
+			return "", false
+		}
+		switch {
+		case cc == "1" && len(digits) == 11 && digits[0] == '1':
+			// North American numbers are often written with the leading 1
+			digits = digits[1:]
+		case !keepsTrunkZero[country]:
+			digits = strings.TrimLeft(digits, "0")
+		}
+		digits = cc + digits
+	}
+
+	if len(digits) < minE164Digits || len(digits) > maxE164Digits || digits[0] == '0' {
+		return "", false
+	}
+	return "+" + digits, true
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
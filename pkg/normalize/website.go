@@ -0,0 +1,65 @@
+package normalize
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// otherScheme matches non-web URLs such as "mailto:" or "tel:", but not "example.com:8080".
+var otherScheme = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:[^0-9/]`)
+
+// trackingParams are query parameters that only identify the referrer.
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true, "yclid": true,
+	"igshid": true, "mc_cid": true, "mc_eid": true, "_ga": true, "_gl": true,
+	"ref": true, "ref_src": true,
+}
+
+// Website returns the canonical form of a website URL and its hostname. The
+// scheme is forced to https, the hostname is lowercased without "www.", and
+// fragments and tracking parameters such as utm_source are removed. It reports
+// false when raw is not a usable URL.
+func Website(raw string) (canonical, host string, ok bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", "", false
+	}
+	if !strings.Contains(raw, "://") {
+		if otherScheme.MatchString(raw) {
+			return "", "", false
+		}
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return "", "", false
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", "", false
+	}
+
+	host = strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	host = strings.TrimPrefix(host, "www.")
+	if !strings.Contains(host, ".") {
+		return "", "", false
+	}
+
+	q := u.Query()
+	for name := range q {
+		if trackingParams[strings.ToLower(name)] || strings.HasPrefix(strings.ToLower(name), "utm_") {
+			q.Del(name)
+		}
+	}
+
+	out := url.URL{
+		Scheme:   "https",
+		Host:     host,
+		Path:     strings.TrimSuffix(u.EscapedPath(), "/"),
+		RawQuery: q.Encode(),
+	}
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		out.Host = host + ":" + port
+	}
+	return out.String(), host, true
+}
@@ -0,0 +1,311 @@
+// Package olc encodes and decodes Open Location Codes, also known as plus
+// codes, e.g. "9FFW84J9+XG". See https://github.com/google/open-location-code
+// for the specification.
+package olc
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+const (
+	Separator = '+'
+	Padding   = '0'
+
+	// PairCodeLength is the length of a code made of lat/lng pairs only, about 14x14 m.
+	PairCodeLength = 10
+	// MaxCodeLength is the longest code that still adds precision.
+	MaxCodeLength = 15
+
+	alphabet       = "23456789CFGHJMPQRVWX"
+	separatorPos   = 8
+	encBase        = int64(len(alphabet))
+	gridRows       = 5
+	gridCols       = 4
+	latMax         = 90
+	lngMax         = 180
+	pairPrecision  = 8000       // encBase^3
+	pairFirstValue = 160000     // encBase^(PairCodeLength/2-1)
+	gridLatFirst   = 625        // gridRows^(MaxCodeLength-PairCodeLength-1)
+	gridLngFirst   = 256        // gridCols^(MaxCodeLength-PairCodeLength-1)
+	finalLatPrec   = 25_000_000 // pairPrecision * gridRows^(MaxCodeLength-PairCodeLength)
+	finalLngPrec   = 8_192_000  // pairPrecision * gridCols^(MaxCodeLength-PairCodeLength)
+	gridLatDivisor = 3125       // gridRows^(MaxCodeLength-PairCodeLength)
+	gridLngDivisor = 1024       // gridCols^(MaxCodeLength-PairCodeLength)
+)
+
+var (
+	ErrInvalid  = errors.New("olc: invalid code")
+	ErrNotFull  = errors.New("olc: not a full code")
+	ErrNotShort = errors.New("olc: not a short code")
+)
+
+// CodeArea is the rectangle a code stands for.
+type CodeArea struct {
+	LatLo, LngLo float64
+	LatHi, LngHi float64
+	Len          int // Number of significant digits
+}
+
+// Center returns the middle of the area.
+func (a CodeArea) Center() (lat, lng float64) {
+	lat = math.Min(a.LatLo+(a.LatHi-a.LatLo)/2, latMax)
+	lng = math.Min(a.LngLo+(a.LngHi-a.LngLo)/2, lngMax)
+	return lat, lng
+}
+
+// Contains reports whether the point lies inside the area.
+func (a CodeArea) Contains(lat, lng float64) bool {
+	return a.LatLo <= lat && lat < a.LatHi && a.LngLo <= lng && lng < a.LngHi
+}
+
+// Encode returns the code of the given length for a point. Lengths below
+// PairCodeLength are rounded up to an even number; lengths beyond
+// MaxCodeLength are capped.
+func Encode(lat, lng float64, codeLen int) string {
+	switch {
+	case codeLen < 2:
+		codeLen = 2
+	case codeLen < PairCodeLength && codeLen%2 == 1:
+		codeLen++
+	case codeLen > MaxCodeLength:
+		codeLen = MaxCodeLength
+	}
+
+	lat = clipLatitude(lat)
+	lng = normalizeLongitude(lng)
+	// The north pole would fall outside the last cell
+	if lat == latMax {
+		lat -= latitudePrecision(codeLen)
+	}
+
+	latVal := int64(math.Floor(math.Round((lat+latMax)*finalLatPrec*1e6) / 1e6))
+	lngVal := int64(math.Floor(math.Round((lng+lngMax)*finalLngPrec*1e6) / 1e6))
+
+	// Digits are produced from the least significant one
+	rev := make([]byte, 0, MaxCodeLength)
+	if codeLen > PairCodeLength {
+		for i := 0; i < MaxCodeLength-PairCodeLength; i++ {
+			rev = append(rev, alphabet[(latVal%gridRows)*gridCols+lngVal%gridCols])
+			latVal /= gridRows
+			lngVal /= gridCols
+		}
+	} else {
+		latVal /= gridLatDivisor
+		lngVal /= gridLngDivisor
+	}
+	for i := 0; i < PairCodeLength/2; i++ {
+		rev = append(rev, alphabet[lngVal%encBase], alphabet[latVal%encBase])
+		latVal /= encBase
+		lngVal /= encBase
+	}
+
+	digits := make([]byte, len(rev))
+	for i, c := range rev {
+		digits[len(rev)-1-i] = c
+	}
+	digits = digits[:codeLen]
+
+	var b strings.Builder
+	if codeLen < separatorPos {
+		b.Write(digits)
+		b.WriteString(strings.Repeat(string(Padding), separatorPos-codeLen))
+		b.WriteByte(Separator)
+	} else {
+		b.Write(digits[:separatorPos])
+		b.WriteByte(Separator)
+		b.Write(digits[separatorPos:])
+	}
+	return b.String()
+}
+
+// Decode returns the area of a full code.
+func Decode(code string) (CodeArea, error) {
+	if !IsFull(code) {
+		return CodeArea{}, ErrNotFull
+	}
+	digits := significantDigits(code)
+	if len(digits) > MaxCodeLength {
+		digits = digits[:MaxCodeLength]
+	}
+
+	normalLat := int64(-latMax * pairPrecision)
+	normalLng := int64(-lngMax * pairPrecision)
+	var gridLat, gridLng int64
+
+	pairDigits := min(len(digits), PairCodeLength)
+	pv := int64(pairFirstValue)
+	digit := 0
+	for digit < pairDigits {
+		normalLat += int64(strings.IndexByte(alphabet, digits[digit])) * pv
+		normalLng += int64(strings.IndexByte(alphabet, digits[digit+1])) * pv
+		digit += 2
+		if digit < pairDigits {
+			pv /= encBase
+		}
+	}
+	latPrecision := float64(pv) / pairPrecision
+	lngPrecision := float64(pv) / pairPrecision
+
+	if len(digits) > PairCodeLength {
+		rowpv := int64(gridLatFirst)
+		colpv := int64(gridLngFirst)
+		for digit < len(digits) {
+			v := int64(strings.IndexByte(alphabet, digits[digit]))
+			gridLat += v / gridCols * rowpv
+			gridLng += v % gridCols * colpv
+			digit++
+			if digit < len(digits) {
+				rowpv /= gridRows
+				colpv /= gridCols
+			}
+		}
+		latPrecision = float64(rowpv) / finalLatPrec
+		lngPrecision = float64(colpv) / finalLngPrec
+	}
+
+	lat := float64(normalLat)/pairPrecision + float64(gridLat)/finalLatPrec
+	lng := float64(normalLng)/pairPrecision + float64(gridLng)/finalLngPrec
+	return CodeArea{
+		LatLo: lat,
+		LngLo: lng,
+		LatHi: lat + latPrecision,
+		LngHi: lng + lngPrecision,
+		Len:   len(digits),
+	}, nil
+}
+
+// DecodePrefix returns the area of a full code or of the leading digits of
+// one, such as "9FFW" or "9FFW84", which need not be padded.
+func DecodePrefix(prefix string) (CodeArea, error) {
+	prefix = strings.ToUpper(strings.TrimSpace(prefix))
+	if !strings.ContainsRune(prefix, Separator) && len(prefix) < separatorPos {
+		if len(prefix) < 2 || len(prefix)%2 == 1 {
+			return CodeArea{}, ErrInvalid
+		}
+		prefix += strings.Repeat(string(Padding), separatorPos-len(prefix)) + string(Separator)
+	}
+	return Decode(prefix)
+}
+
+// RecoverNearest turns a short code, e.g. "84J9+XG", into the full code
+// closest to the reference point. Full codes are returned unchanged.
+func RecoverNearest(code string, refLat, refLng float64) (string, error) {
+	if IsFull(code) {
+		return strings.ToUpper(code), nil
+	}
+	if !IsShort(code) {
+		return "", ErrNotShort
+	}
+	code = strings.ToUpper(code)
+	refLat = clipLatitude(refLat)
+	refLng = normalizeLongitude(refLng)
+
+	paddingLen := separatorPos - strings.IndexByte(code, Separator)
+	resolution := math.Pow(float64(encBase), 2-float64(paddingLen/2))
+	halfRes := resolution / 2
+
+	area, err := Decode(Encode(refLat, refLng, PairCodeLength)[:paddingLen] + code)
+	if err != nil {
+		return "", err
+	}
+	lat, lng := area.Center()
+
+	// The prefix of the reference may belong to a neighbouring cell
+	switch {
+	case refLat+halfRes < lat && lat-resolution >= -latMax:
+		lat -= resolution
+	case refLat-halfRes > lat && lat+resolution <= latMax:
+		lat += resolution
+	}
+	switch {
+	case refLng+halfRes < lng:
+		lng -= resolution
+	case refLng-halfRes > lng:
+		lng += resolution
+	}
+	return Encode(lat, lng, area.Len), nil
+}
+
+// IsValid reports whether code is a well-formed full or short code.
+func IsValid(code string) bool {
+	if len(code) < 2 {
+		return false
+	}
+	code = strings.ToUpper(code)
+	sep := strings.IndexByte(code, Separator)
+	if sep < 0 || sep != strings.LastIndexByte(code, Separator) || sep > separatorPos || sep%2 == 1 {
+		return false
+	}
+	if pad := strings.IndexByte(code, Padding); pad >= 0 {
+		// Padding fills up to the separator, in pairs, and nothing may follow it
+		if sep < separatorPos || pad == 0 || len(code) > sep+1 {
+			return false
+		}
+		run := code[pad:sep]
+		if len(run)%2 == 1 || strings.Trim(run, string(Padding)) != "" {
+			return false
+		}
+	}
+	if len(code)-sep-1 == 1 {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		if c := code[i]; c != Separator && c != Padding && strings.IndexByte(alphabet, c) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IsShort reports whether code is a valid code with leading digits removed.
+func IsShort(code string) bool {
+	return IsValid(code) && strings.IndexByte(code, Separator) < separatorPos
+}
+
+// IsFull reports whether code is a valid code that can be decoded on its own.
+func IsFull(code string) bool {
+	if !IsValid(code) || IsShort(code) {
+		return false
+	}
+	code = strings.ToUpper(code)
+	if int64(strings.IndexByte(alphabet, code[0]))*encBase >= 2*latMax {
+		return false
+	}
+	if len(code) > 1 && int64(strings.IndexByte(alphabet, code[1]))*encBase >= 2*lngMax {
+		return false
+	}
+	return true
+}
+
+// significantDigits strips the separator and padding from a code.
+func significantDigits(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.Replace(code, string(Separator), "", 1)
+	if i := strings.IndexByte(code, Padding); i >= 0 {
+		code = code[:i]
+	}
+	return code
+}
+
+func latitudePrecision(codeLen int) float64 {
+	if codeLen <= PairCodeLength {
+		return math.Pow(float64(encBase), math.Floor(float64(codeLen)/-2+2))
+	}
+	return math.Pow(float64(encBase), -3) / math.Pow(gridRows, float64(codeLen-PairCodeLength))
+}
+
+func clipLatitude(lat float64) float64 {
+	return math.Min(latMax, math.Max(-latMax, lat))
+}
+
+func normalizeLongitude(lng float64) float64 {
+	for lng < -lngMax {
+		lng += 2 * lngMax
+	}
+	for lng >= lngMax {
+		lng -= 2 * lngMax
+	}
+	return lng
+}
@@ -0,0 +1,125 @@
+package olc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		lat, lng float64
+		codeLen  int
+		want     string
+	}{
+		{20.375, 2.775, 6, "7FG49Q00+"},
+		{20.3700625, 2.7821875, 10, "7FG49QCJ+2V"},
+		{20.3701125, 2.782234375, 11, "7FG49QCJ+2VX"},
+		{47.0000625, 8.0000625, 10, "8FVC2222+22"},
+		{-41.2730625, 174.7859375, 10, "4VCPPQGP+Q9"},
+		{0.5, -179.5, 4, "62G20000+"},
+		{-89.5, -179.5, 4, "22220000+"},
+		{0.5, 179.5, 4, "6VGX0000+"},
+		{90, 1, 4, "CFX30000+"},
+		{1, 1, 11, "6FH32222+222"},
+	}
+	for _, tt := range tests {
+		if got := Encode(tt.lat, tt.lng, tt.codeLen); got != tt.want {
+			t.Errorf("Encode(%v, %v, %d) = %s, want %s", tt.lat, tt.lng, tt.codeLen, got, tt.want)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	area, err := Decode("7FG49QCJ+2V")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := CodeArea{LatLo: 20.37, LngLo: 2.782125, LatHi: 20.370125, LngHi: 2.78225, Len: 10}
+	if !closeTo(area, want) {
+		t.Errorf("Decode = %+v, want %+v", area, want)
+	}
+
+	// Decoding the center of an area encodes back to the same code
+	for _, code := range []string{"7FG49Q00+", "8FVC2222+22", "6FH32222+222", "9C3W9QCJ+2VXGJ", "CFX30000+"} {
+		area, err := Decode(code)
+		if err != nil {
+			t.Fatalf("Decode(%s): %v", code, err)
+		}
+		lat, lng := area.Center()
+		if got := Encode(lat, lng, area.Len); got != code {
+			t.Errorf("round trip of %s gave %s", code, got)
+		}
+	}
+
+	for _, code := range []string{"9QCJ+2VX", "8FVC2222", "ZZ000000+", "8FVC2222+2"} {
+		if _, err := Decode(code); err == nil {
+			t.Errorf("expected Decode(%s) to fail", code)
+		}
+	}
+}
+
+func TestDecodePrefix(t *testing.T) {
+	area, err := DecodePrefix("8fvc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := CodeArea{LatLo: 47, LngLo: 8, LatHi: 48, LngHi: 9, Len: 4}
+	if !closeTo(area, want) {
+		t.Errorf("DecodePrefix = %+v, want %+v", area, want)
+	}
+	if _, err := DecodePrefix("8FV"); err == nil {
+		t.Error("expected an odd prefix to fail")
+	}
+}
+
+func TestRecoverNearest(t *testing.T) {
+	tests := []struct {
+		code     string
+		lat, lng float64
+		want     string
+	}{
+		{"9QCJ+2VX", 51.3708675, -1.217765625, "9C3W9QCJ+2VX"},
+		{"CJ+2VX", 51.3708675, -1.217765625, "9C3W9QCJ+2VX"},
+		// The closest match lies in the next cell to the north of the reference
+		{"2222+22", 47.99, 8.0, "8FWC2222+22"},
+		{"2222+22", 47.4, 8.0, "8FVC2222+22"},
+		{"8FVC2222+22", 0, 0, "8FVC2222+22"},
+	}
+	for _, tt := range tests {
+		got, err := RecoverNearest(tt.code, tt.lat, tt.lng)
+		if err != nil {
+			t.Fatalf("RecoverNearest(%s): %v", tt.code, err)
+		}
+		if got != tt.want {
+			t.Errorf("RecoverNearest(%s, %v, %v) = %s, want %s", tt.code, tt.lat, tt.lng, got, tt.want)
+		}
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	valid := []string{"8FWC2345+G6", "8FWC2345+G6G", "8fwc2345+", "8FWCX400+", "WC2345+G6g", "2345+G6", "45+G6", "+G6"}
+	invalid := []string{"G+", "+", "8FWC2345+G", "8FWC2_45+G6", "8FWC2η45+G6", "8FWC2345+G6+", "8FWC2345G6+", "8FWC2300+G6", "WC2300+G6g", "WC2345+G"}
+	for _, code := range valid {
+		if !IsValid(code) {
+			t.Errorf("expected %s to be valid", code)
+		}
+	}
+	for _, code := range invalid {
+		if IsValid(code) {
+			t.Errorf("expected %s to be invalid", code)
+		}
+	}
+	if !IsFull("8FWC2345+G6") || IsShort("8FWC2345+G6") {
+		t.Error("expected 8FWC2345+G6 to be full")
+	}
+	if !IsShort("WC2345+G6g") || IsFull("WC2345+G6g") {
+		t.Error("expected WC2345+G6g to be short")
+	}
+}
+
+func closeTo(a, b CodeArea) bool {
+	const eps = 1e-9
+	return a.Len == b.Len &&
+		math.Abs(a.LatLo-b.LatLo) < eps && math.Abs(a.LngLo-b.LngLo) < eps &&
+		math.Abs(a.LatHi-b.LatHi) < eps && math.Abs(a.LngHi-b.LngHi) < eps
+}
@@ -0,0 +1,190 @@
+// Package pipeline runs items through a fixed sequence of stages, each with
+// its own pool of workers. Stages are connected by bounded channels, so a slow
+// stage holds back the ones before it instead of letting work pile up.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrSkip is returned by a stage to drop an item without reporting an error.
+var ErrSkip = errors.New("pipeline: skip item")
+
+// Stage is one step of a pipeline. Run may modify the item in place; an item
+// for which Run returns an error does not reach the following stages.
+type Stage[T any] struct {
+	Name    string
+	Workers int
+	Run     func(ctx context.Context, item *T) error
+}
+
+// ItemError records the stage at which an item failed.
+type ItemError struct {
+	Index int // Position of the item in the input
+	Stage string
+	Err   error
+}
+
+func (e ItemError) Error() string {
+	return fmt.Sprintf("item %d: %s: %v", e.Index, e.Stage, e.Err)
+}
+
+func (e ItemError) Unwrap() error {
+	return e.Err
+}
+
+// StageStats are the timing metrics of a single stage.
+type StageStats struct {
+	Name    string
+	Workers int
+	Items   int           // Items the stage ran on
+	Failed  int           // Items the stage returned an error for
+	Skipped int           // Items the stage dropped with ErrSkip
+	Busy    time.Duration // Total time spent in Run, across workers
+	Max     time.Duration // Slowest single Run
+}
+
+// Mean is the average time the stage spent on an item.
+func (s StageStats) Mean() time.Duration {
+	if s.Items == 0 {
+		return 0
+	}
+	return s.Busy / time.Duration(s.Items)
+}
+
+// Result summarizes a run.
+type Result struct {
+	Completed int         // Items that passed every stage
+	Errors    []ItemError // Ordered by item index
+	Stats     []StageStats
+	Elapsed   time.Duration
+	Err       error // Set when the context ended before every item was processed
+}
+
+// Pipeline is a reusable sequence of stages.
+type Pipeline[T any] struct {
+	buffer int
+	stages []Stage[T]
+}
+
+// New creates a pipeline whose stages are connected by channels holding up to buffer items.
+func New[T any](buffer int, stages ...Stage[T]) *Pipeline[T] {
+	if buffer < 0 {
+		buffer = 0
+	}
+	return &Pipeline[T]{
+		buffer: buffer,
+		stages: stages,
+	}
+}
+
+type envelope[T any] struct {
+	index int
+	item  *T
+}
+
+// collector gathers errors and stats from the workers of every stage.
+type collector struct {
+	mu     sync.Mutex
+	stats  []StageStats
+	errors []ItemError
+}
+
+func (c *collector) record(stage int, index int, d time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := &c.stats[stage]
+	s.Items++
+	s.Busy += d
+	if d > s.Max {
+		s.Max = d
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrSkip):
+		s.Skipped++
+	default:
+		s.Failed++
+		c.errors = append(c.errors, ItemError{Index: index, Stage: s.Name, Err: err})
+	}
+}
+
+// Run passes every item through the stages and waits for all of them to finish.
+// Items are modified in place. Once ctx is done no new item enters a stage.
+func (p *Pipeline[T]) Run(ctx context.Context, items []T) Result {
+	start := time.Now()
+	c := &collector{stats: make([]StageStats, len(p.stages))}
+
+	src := make(chan envelope[T], p.buffer)
+	go func() {
+		defer close(src)
+		for i := range items {
+			select {
+			case src <- envelope[T]{index: i, item: &items[i]}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	in := (<-chan envelope[T])(src)
+	for i := range p.stages {
+		in = p.runStage(ctx, i, in, c)
+	}
+
+	var completed int
+	for range in {
+		completed++
+	}
+
+	sort.Slice(c.errors, func(i, j int) bool { return c.errors[i].Index < c.errors[j].Index })
+	return Result{
+		Completed: completed,
+		Errors:    c.errors,
+		Stats:     c.stats,
+		Elapsed:   time.Since(start),
+		Err:       ctx.Err(),
+	}
+}
+
+func (p *Pipeline[T]) runStage(ctx context.Context, i int, in <-chan envelope[T], c *collector) <-chan envelope[T] {
+	stage := p.stages[i]
+	workers := stage.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	c.stats[i].Name = stage.Name
+	c.stats[i].Workers = workers
+
+	out := make(chan envelope[T], p.buffer)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			// Keep draining after cancellation so that upstream workers never block
+			for env := range in {
+				if ctx.Err() != nil {
+					continue
+				}
+				t := time.Now()
+				err := stage.Run(ctx, env.item)
+				c.record(i, env.index, time.Since(t), err)
+				if err != nil {
+					continue
+				}
+				out <- env
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
@@ -0,0 +1,109 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i
+	}
+
+	errOdd := errors.New("odd")
+	p := New(4,
+		Stage[int]{Name: "skip", Workers: 3, Run: func(ctx context.Context, n *int) error {
+			if *n%10 == 0 {
+				return ErrSkip
+			}
+			return nil
+		}},
+		Stage[int]{Name: "odd", Workers: 5, Run: func(ctx context.Context, n *int) error {
+			if *n%2 == 1 {
+				return errOdd
+			}
+			*n *= 2
+			return nil
+		}},
+	)
+
+	res := p.Run(context.Background(), items)
+	if res.Err != nil {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+	if res.Completed != 40 {
+		t.Fatalf("expected 40 completed items, got %d", res.Completed)
+	}
+	if len(res.Errors) != 50 {
+		t.Fatalf("expected 50 errors, got %d", len(res.Errors))
+	}
+	for i, e := range res.Errors {
+		if e.Index != 2*i+1 || e.Stage != "odd" || !errors.Is(e, errOdd) {
+			t.Fatalf("unexpected error at %d: %v", i, e)
+		}
+	}
+	if items[4] != 8 || items[10] != 10 {
+		t.Errorf("expected items to be modified in place, got %d and %d", items[4], items[10])
+	}
+
+	skip, odd := res.Stats[0], res.Stats[1]
+	if skip.Items != 100 || skip.Skipped != 10 || skip.Workers != 3 {
+		t.Errorf("unexpected skip stats: %+v", skip)
+	}
+	if odd.Items != 90 || odd.Failed != 50 || odd.Workers != 5 {
+		t.Errorf("unexpected odd stats: %+v", odd)
+	}
+}
+
+func TestRunBackpressure(t *testing.T) {
+	const buffer = 2
+	var started, finished atomic.Int64
+	release := make(chan struct{})
+
+	p := New(buffer,
+		Stage[int]{Name: "count", Workers: 1, Run: func(ctx context.Context, n *int) error {
+			started.Add(1)
+			return nil
+		}},
+		Stage[int]{Name: "slow", Workers: 1, Run: func(ctx context.Context, n *int) error {
+			<-release
+			finished.Add(1)
+			return nil
+		}},
+	)
+
+	done := make(chan Result)
+	go func() { done <- p.Run(context.Background(), make([]int, 50)) }()
+
+	time.Sleep(50 * time.Millisecond)
+	// One item in the slow stage, one blocked in the first stage and a full channel in between
+	if n := started.Load(); n > buffer+2 {
+		t.Fatalf("expected at most %d items to start while the last stage is blocked, got %d", buffer+2, n)
+	}
+	close(release)
+
+	res := <-done
+	if res.Completed != 50 || finished.Load() != 50 {
+		t.Fatalf("expected every item to finish, got %d", res.Completed)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(0, Stage[int]{Name: "cancel", Workers: 2, Run: func(ctx context.Context, n *int) error {
+		cancel()
+		return nil
+	}})
+
+	res := p.Run(ctx, make([]int, 1000))
+	if !errors.Is(res.Err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", res.Err)
+	}
+	if res.Completed == 1000 {
+		t.Fatal("expected the run to stop early")
+	}
+}
@@ -0,0 +1,128 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	kafkaContentType = "application/vnd.kafka.json.v2+json"
+	kafkaAccept      = "application/vnd.kafka.v2+json"
+)
+
+// Kafka publishes each message to topic "<prefix>.<subject>" through the
+// Kafka REST Proxy (API v2), keyed by place so a place's messages share a
+// partition.
+type Kafka struct {
+	baseURL string
+	prefix  string
+	client  *http.Client
+}
+
+// NewKafka publishes through the REST Proxy at baseURL, e.g. "http://kafka-rest:8082".
+func NewKafka(baseURL, prefix string, client *http.Client) *Kafka {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Kafka{baseURL: strings.TrimRight(baseURL, "/"), prefix: prefix, client: client}
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition int     `json:"partition"`
+		Offset    int64   `json:"offset"`
+		ErrorCode *int    `json:"error_code"`
+		Error     *string `json:"error"`
+	} `json:"offsets"`
+}
+
+type kafkaError struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func (p *Kafka) Publish(ctx context.Context, msgs []Message) error {
+	// Runs of messages with the same subject go to their topic in one request
+	for start := 0; start < len(msgs); {
+		end := start + 1
+		for end < len(msgs) && msgs[end].Subject == msgs[start].Subject {
+			end++
+		}
+		if err := p.produce(ctx, p.prefix+"."+msgs[start].Subject, msgs[start:end]); err != nil {
+			return err
+		}
+		start = end
+	}
+	return nil
+}
+
+func (p *Kafka) produce(ctx context.Context, topic string, msgs []Message) error {
+	body := struct {
+		Records []kafkaRecord `json:"records"`
+	}{Records: make([]kafkaRecord, len(msgs))}
+	for i, m := range msgs {
+		value, err := m.Encode()
+		if err != nil {
+			return err
+		}
+		body.Records[i] = kafkaRecord{Key: m.Key, Value: value}
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/topics/"+url.PathEscape(topic), bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaContentType)
+	req.Header.Set("Accept", kafkaAccept)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e kafkaError
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(raw, &e) == nil && e.Message != "" {
+			return fmt.Errorf("producing to %s: %s (error code %d)", topic, e.Message, e.ErrorCode)
+		}
+		return fmt.Errorf("producing to %s: unexpected status %s", topic, resp.Status)
+	}
+	var produced kafkaProduceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&produced); err != nil {
+		return fmt.Errorf("decoding produce response of %s: %w", topic, err)
+	}
+	if len(produced.Offsets) != len(msgs) {
+		return fmt.Errorf("producing to %s: %d of %d messages acknowledged", topic, len(produced.Offsets), len(msgs))
+	}
+	for i, o := range produced.Offsets {
+		if o.ErrorCode != nil || o.Error != nil {
+			msg := ""
+			if o.Error != nil {
+				msg = *o.Error
+			}
+			return fmt.Errorf("producing message %d to %s: %s", msgs[i].ID, topic, msg)
+		}
+	}
+	return nil
+}
+
+func (p *Kafka) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,93 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+)
+
+// restProxy stands in for the Kafka REST Proxy and keeps the produced records per topic.
+type restProxy struct {
+	mu     sync.Mutex
+	topics map[string][]kafkaRecord
+	fail   string // Error returned for every record when set
+}
+
+func (p *restProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != kafkaContentType {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		w.Write([]byte(`{"error_code": 415, "message": "unsupported media type"}`))
+		return
+	}
+	var body struct {
+		Records []kafkaRecord `json:"records"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	topic := r.URL.Path[len("/topics/"):]
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	resp := map[string]any{}
+	var offsets []map[string]any
+	for _, rec := range body.Records {
+		if p.fail != "" {
+			offsets = append(offsets, map[string]any{"partition": nil, "offset": nil, "error_code": 50002, "error": p.fail})
+			continue
+		}
+		p.topics[topic] = append(p.topics[topic], rec)
+		offsets = append(offsets, map[string]any{"partition": 0, "offset": len(p.topics[topic]) - 1, "error_code": nil, "error": nil})
+	}
+	resp["offsets"] = offsets
+	json.NewEncoder(w).Encode(resp)
+}
+
+func TestKafkaPublish(t *testing.T) {
+	proxy := &restProxy{topics: map[string][]kafkaRecord{}}
+	srv := httptest.NewServer(proxy)
+	defer srv.Close()
+
+	p := NewKafka(srv.URL+"/", "poi", srv.Client())
+	if err := p.Publish(context.Background(), testMessages); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(proxy.topics["poi.inserted"]); n != 1 {
+		t.Errorf("expected 1 inserted record, got %d", n)
+	}
+	updated := proxy.topics["poi.updated"]
+	if len(updated) != 2 || updated[0].Key != "ChIJ1" || updated[1].Key != "ChIJ2" {
+		t.Fatalf("unexpected updated records %+v", updated)
+	}
+	var env envelope
+	if err := json.Unmarshal(updated[1].Value, &env); err != nil {
+		t.Fatal(err)
+	}
+	if env.ID != 3 || env.Subject != "updated" {
+		t.Errorf("unexpected envelope %+v", env)
+	}
+
+	proxy.fail = "leader not available"
+	if err := p.Publish(context.Background(), testMessages); err == nil {
+		t.Error("expected record errors to fail the publish")
+	}
+}
+
+// TestKafkaRESTProxy publishes through the REST Proxy at KAFKA_REST_URL, e.g.
+// http://localhost:8082, which must allow topics to be created on first use.
+func TestKafkaRESTProxy(t *testing.T) {
+	url := os.Getenv("KAFKA_REST_URL")
+	if url == "" {
+		t.Skip("KAFKA_REST_URL is not set")
+	}
+	p := NewKafka(url, "poitest", nil)
+	defer p.Close()
+	if err := p.Publish(context.Background(), testMessages); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,58 @@
+package publish
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSConn is the part of *nats.Conn the publisher uses.
+type NATSConn interface {
+	PublishMsg(m *nats.Msg) error
+	FlushWithContext(ctx context.Context) error
+	Close()
+}
+
+// NATS publishes each message to "<prefix>.<subject>". The Nats-Msg-Id header
+// carries the message ID, which JetStream streams deduplicate on.
+type NATS struct {
+	conn   NATSConn
+	prefix string
+}
+
+func NewNATS(conn NATSConn, prefix string) *NATS {
+	return &NATS{conn: conn, prefix: prefix}
+}
+
+// DialNATS connects to the NATS server at url.
+func DialNATS(url, prefix string) (*NATS, error) {
+	conn, err := nats.Connect(url, nats.Name("apify-poi-data"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	return NewNATS(conn, prefix), nil
+}
+
+func (p *NATS) Publish(ctx context.Context, msgs []Message) error {
+	for _, m := range msgs {
+		data, err := m.Encode()
+		if err != nil {
+			return err
+		}
+		msg := nats.NewMsg(p.prefix + "." + m.Subject)
+		msg.Header.Set(nats.MsgIdHdr, strconv.FormatInt(m.ID, 10))
+		msg.Header.Set("Poi-Key", m.Key)
+		msg.Data = data
+		if err := p.conn.PublishMsg(msg); err != nil {
+			return err
+		}
+	}
+	// The server has received every message once the flush returns
+	return p.conn.FlushWithContext(ctx)
+}
+
+func (p *NATS) Close() error {
+	p.conn.Close()
+	return nil
+}
@@ -0,0 +1,104 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// fakeNATS stands in for a NATS connection.
+type fakeNATS struct {
+	msgs     []*nats.Msg
+	flushed  int
+	flushErr error
+}
+
+func (c *fakeNATS) PublishMsg(m *nats.Msg) error {
+	c.msgs = append(c.msgs, m)
+	return nil
+}
+
+func (c *fakeNATS) FlushWithContext(ctx context.Context) error {
+	c.flushed++
+	return c.flushErr
+}
+
+func (c *fakeNATS) Close() {}
+
+func TestNATSPublish(t *testing.T) {
+	conn := &fakeNATS{}
+	p := NewNATS(conn, "poi")
+	if err := p.Publish(context.Background(), testMessages); err != nil {
+		t.Fatal(err)
+	}
+	if len(conn.msgs) != 3 || conn.flushed != 1 {
+		t.Fatalf("expected 3 messages and one flush, got %d and %d", len(conn.msgs), conn.flushed)
+	}
+
+	m := conn.msgs[1]
+	if m.Subject != "poi.updated" {
+		t.Errorf("unexpected subject %q", m.Subject)
+	}
+	if m.Header.Get(nats.MsgIdHdr) != "2" || m.Header.Get("Poi-Key") != "ChIJ1" {
+		t.Errorf("unexpected headers %v", m.Header)
+	}
+	var env envelope
+	if err := json.Unmarshal(m.Data, &env); err != nil {
+		t.Fatal(err)
+	}
+	if env.ID != 2 || string(env.Data) != `{"title":"Roma Pizzeria"}` {
+		t.Errorf("unexpected envelope %+v", env)
+	}
+
+	conn.flushErr = errors.New("connection closed")
+	if err := p.Publish(context.Background(), testMessages); err == nil {
+		t.Error("expected a failed flush to fail the publish")
+	}
+}
+
+// TestNATSServer publishes to the server at NATS_URL, e.g. nats://localhost:4222.
+func TestNATSServer(t *testing.T) {
+	url := os.Getenv("NATS_URL")
+	if url == "" {
+		t.Skip("NATS_URL is not set")
+	}
+	sub, err := nats.Connect(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	received := make(chan *nats.Msg, len(testMessages))
+	s, err := sub.ChanSubscribe("poitest.>", received)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Unsubscribe()
+	if err := sub.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := DialNATS(url, "poitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if err := p.Publish(context.Background(), testMessages); err != nil {
+		t.Fatal(err)
+	}
+	for i := range testMessages {
+		select {
+		case m := <-received:
+			if got, want := m.Header.Get(nats.MsgIdHdr), strconv.FormatInt(testMessages[i].ID, 10); got != want {
+				t.Errorf("message %d has ID %q, want %q", i, got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of %d messages", i, len(testMessages))
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Package publish delivers the messages of the transactional outbox to
+// downstream systems. Delivery is at least once: a message is published again
+// when the relay stops before recording it as published, so consumers
+// deduplicate on its ID.
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// Message is an outbox message.
+type Message struct {
+	ID      int64           // Unique per message
+	Subject string          // e.g. "inserted" or "updated"
+	Key     string          // Place ID; messages with the same key are published in order
+	Data    json.RawMessage // JSON document
+	Time    time.Time       // When the message was written
+}
+
+// envelope is how a message is encoded on the wire.
+type envelope struct {
+	ID      int64           `json:"id"`
+	Subject string          `json:"subject"`
+	Key     string          `json:"key"`
+	Time    string          `json:"time"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Encode returns the JSON envelope of a message.
+func (m Message) Encode() ([]byte, error) {
+	return json.Marshal(envelope{
+		ID:      m.ID,
+		Subject: m.Subject,
+		Key:     m.Key,
+		Time:    m.Time.UTC().Format(time.RFC3339Nano),
+		Data:    m.Data,
+	})
+}
+
+// Publisher delivers messages to a broker.
+type Publisher interface {
+	// Publish delivers msgs in order and returns once the broker accepted
+	// every one of them. On error, any of them may have been delivered.
+	Publish(ctx context.Context, msgs []Message) error
+	Close() error
+}
+
+// Log writes messages to the standard logger.
+type Log struct{}
+
+func (Log) Publish(ctx context.Context, msgs []Message) error {
+	for _, m := range msgs {
+		b, err := m.Encode()
+		if err != nil {
+			return err
+		}
+		log.Printf("Published %s", b)
+	}
+	return nil
+}
+
+func (Log) Close() error {
+	return nil
+}
+
+// Memory keeps published messages in memory, standing in for a broker in tests.
+type Memory struct {
+	mu   sync.Mutex
+	msgs []Message
+	Err  error // Returned by Publish when set
+}
+
+func (p *Memory) Publish(ctx context.Context, msgs []Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Err != nil {
+		return p.Err
+	}
+	p.msgs = append(p.msgs, msgs...)
+	return nil
+}
+
+func (p *Memory) Close() error {
+	return nil
+}
+
+// Messages returns the messages published so far.
+func (p *Memory) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Message(nil), p.msgs...)
+}
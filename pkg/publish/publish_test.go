@@ -0,0 +1,47 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+var testMessages = []Message{
+	{ID: 1, Subject: "inserted", Key: "ChIJ1", Data: json.RawMessage(`{"title":"Roma"}`), Time: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)},
+	{ID: 2, Subject: "updated", Key: "ChIJ1", Data: json.RawMessage(`{"title":"Roma Pizzeria"}`), Time: time.Date(2025, 3, 1, 12, 5, 0, 0, time.UTC)},
+	{ID: 3, Subject: "updated", Key: "ChIJ2", Data: json.RawMessage(`{"title":"Napoli"}`), Time: time.Date(2025, 3, 1, 12, 6, 0, 0, time.UTC)},
+}
+
+func TestEncode(t *testing.T) {
+	b, err := testMessages[0].Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":1,"subject":"inserted","key":"ChIJ1","time":"2025-03-01T12:00:00Z","data":{"title":"Roma"}}`
+	if string(b) != want {
+		t.Errorf("Encode() = %s, want %s", b, want)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	p := &Memory{}
+	if err := p.Publish(context.Background(), testMessages[:2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Publish(context.Background(), testMessages[2:]); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Messages(); len(got) != 3 || got[2].ID != 3 {
+		t.Errorf("unexpected messages %+v", got)
+	}
+
+	p.Err = errors.New("broker down")
+	if err := p.Publish(context.Background(), testMessages); err == nil {
+		t.Error("expected the configured error")
+	}
+	if got := p.Messages(); len(got) != 3 {
+		t.Errorf("expected a failed publish to keep nothing, got %d messages", len(got))
+	}
+}
@@ -0,0 +1,192 @@
+// Package taxonomy maps the free-text categories of the scraped sources onto
+// a versioned hierarchy of canonical categories, such as food.restaurant.pizza.
+//
+// Node paths are dot-separated labels, compatible with the PostgreSQL ltree
+// type, so that a node matches its descendants with the <@ operator.
+package taxonomy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//go:embed taxonomy_v1.json
+var currentJSON []byte
+
+// Current is the taxonomy POIs are categorized with at ingest.
+var Current = MustParse(currentJSON)
+
+// Node is a category in the hierarchy.
+type Node struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+}
+
+// Parent returns the path of the parent node, or "" for a root node.
+func (n Node) Parent() string {
+	i := strings.LastIndex(n.Path, ".")
+	if i < 0 {
+		return ""
+	}
+	return n.Path[:i]
+}
+
+// Mapping assigns a source category in one language to a node.
+type Mapping struct {
+	Source   string
+	Language string
+	Category string
+	Node     string
+}
+
+// Taxonomy is one version of the hierarchy with its source mappings.
+type Taxonomy struct {
+	Version  int
+	Nodes    []Node
+	Mappings []Mapping
+
+	nodes  map[string]Node
+	lookup map[string]map[string][]string // source -> normalized category -> node paths
+}
+
+// document is the JSON form of a taxonomy, with mappings keyed by source,
+// then language, then source category.
+type document struct {
+	Version  int                                     `json:"version"`
+	Nodes    []Node                                  `json:"nodes"`
+	Mappings map[string]map[string]map[string]string `json:"mappings"`
+}
+
+var label = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Parse decodes and validates a taxonomy. Every node's parent must be listed
+// before it and every mapping must point at a node.
+func Parse(data []byte) (*Taxonomy, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Version < 1 {
+		return nil, fmt.Errorf("taxonomy version must be at least 1")
+	}
+
+	t := &Taxonomy{
+		Version: doc.Version,
+		Nodes:   doc.Nodes,
+		nodes:   map[string]Node{},
+		lookup:  map[string]map[string][]string{},
+	}
+	for _, n := range doc.Nodes {
+		for _, l := range strings.Split(n.Path, ".") {
+			if !label.MatchString(l) {
+				return nil, fmt.Errorf("invalid node path: %q", n.Path)
+			}
+		}
+		if _, ok := t.nodes[n.Path]; ok {
+			return nil, fmt.Errorf("duplicate node: %s", n.Path)
+		}
+		if p := n.Parent(); p != "" {
+			if _, ok := t.nodes[p]; !ok {
+				return nil, fmt.Errorf("parent of %s is not defined before it", n.Path)
+			}
+		}
+		t.nodes[n.Path] = n
+	}
+
+	for source, languages := range doc.Mappings {
+		if t.lookup[source] == nil {
+			t.lookup[source] = map[string][]string{}
+		}
+		for language, categories := range languages {
+			for category, node := range categories {
+				if _, ok := t.nodes[node]; !ok {
+					return nil, fmt.Errorf("%s/%s %q maps to unknown node %s", source, language, category, node)
+				}
+				t.Mappings = append(t.Mappings, Mapping{
+					Source:   source,
+					Language: language,
+					Category: category,
+					Node:     node,
+				})
+				key := Normalize(category)
+				if !contains(t.lookup[source][key], node) {
+					t.lookup[source][key] = append(t.lookup[source][key], node)
+				}
+			}
+		}
+	}
+	sort.Slice(t.Mappings, func(i, j int) bool {
+		a, b := t.Mappings[i], t.Mappings[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Language != b.Language {
+			return a.Language < b.Language
+		}
+		return a.Category < b.Category
+	})
+	return t, nil
+}
+
+// MustParse is Parse for the embedded taxonomies, which are known to be valid.
+func MustParse(data []byte) *Taxonomy {
+	t, err := Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Normalize folds a source category for lookups: lowercase, with runs of
+// white space collapsed.
+func Normalize(category string) string {
+	return strings.ToLower(strings.Join(strings.Fields(category), " "))
+}
+
+// Node returns the node at path.
+func (t *Taxonomy) Node(path string) (Node, bool) {
+	n, ok := t.nodes[path]
+	return n, ok
+}
+
+// Map returns the most specific nodes the source categories map to. A node is
+// left out when one of its descendants is also matched, since filters on a
+// node include its descendants anyway. Categories without a mapping are ignored.
+func (t *Taxonomy) Map(source string, categories ...string) []string {
+	var matched []string
+	for _, c := range categories {
+		for _, node := range t.lookup[source][Normalize(c)] {
+			if !contains(matched, node) {
+				matched = append(matched, node)
+			}
+		}
+	}
+	var out []string
+	for _, node := range matched {
+		specific := true
+		for _, other := range matched {
+			if strings.HasPrefix(other, node+".") {
+				specific = false
+				break
+			}
+		}
+		if specific {
+			out = append(out, node)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
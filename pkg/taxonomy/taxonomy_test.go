@@ -0,0 +1,48 @@
+package taxonomy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCurrent(t *testing.T) {
+	if Current.Version != 1 {
+		t.Fatalf("expected version 1, got %d", Current.Version)
+	}
+	if n, ok := Current.Node("food.restaurant.pizza"); !ok || n.Parent() != "food.restaurant" {
+		t.Fatalf("unexpected pizza node %+v", n)
+	}
+}
+
+func TestMap(t *testing.T) {
+	tests := []struct {
+		source     string
+		categories []string
+		want       []string
+	}{
+		{"google_maps", []string{"Pizza restaurant", "Restaurant", "Italian restaurant"}, []string{"food.restaurant.italian", "food.restaurant.pizza"}},
+		{"google_maps", []string{"pizzeria"}, []string{"food.restaurant.pizza"}},
+		{"google_maps", []string{"Sushirestaurang", "Japansk  restaurang"}, []string{"food.restaurant.asian.japanese.sushi"}},
+		{"google_maps", []string{"Unknown thing"}, nil},
+		{"tripadvisor", []string{"restaurant", "Pizza", "Italian"}, []string{"food.restaurant.italian", "food.restaurant.pizza"}},
+	}
+	for _, tt := range tests {
+		if got := Current.Map(tt.source, tt.categories...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Map(%s, %v) = %v, want %v", tt.source, tt.categories, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	invalid := []string{
+		`{"version": 1, "nodes": [{"path": "food.pizza"}]}`,
+		`{"version": 1, "nodes": [{"path": "Food"}]}`,
+		`{"version": 1, "nodes": [{"path": "food"}], "mappings": {"google_maps": {"en": {"Pizza": "food.pizza"}}}}`,
+		`{"version": 0, "nodes": []}`,
+	}
+	for _, doc := range invalid {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("expected an error for %s", doc)
+		}
+	}
+}
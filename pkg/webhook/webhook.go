@@ -0,0 +1,151 @@
+// Package webhook signs and sends HTTP callbacks. A payload is signed with
+// HMAC-SHA256 over "<timestamp>.<body>", so receivers can check it came from
+// us and reject replays of old requests.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderID        = "Webhook-Id"        // Unique per delivery, the same on every attempt
+	HeaderTimestamp = "Webhook-Timestamp" // Unix seconds the request was signed at
+	HeaderSignature = "Webhook-Signature" // Space separated "v1=<hex>" signatures
+
+	signatureVersion = "v1"
+	secretPrefix     = "whsec_"
+	maxErrorBody     = 512
+)
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature of body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature headers of a request against secret. Requests
+// signed more than tolerance away from now are rejected.
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return errors.New("webhook: invalid timestamp")
+	}
+	if d := now.Sub(time.Unix(timestamp, 0)); d > tolerance || d < -tolerance {
+		return errors.New("webhook: timestamp outside the tolerance")
+	}
+	want := Sign(secret, timestamp, body)
+	for _, sig := range strings.Fields(header.Get(HeaderSignature)) {
+		if hmac.Equal([]byte(sig), []byte(want)) {
+			return nil
+		}
+	}
+	return errors.New("webhook: no matching signature")
+}
+
+// Backoff returns how long to wait before the next attempt after the given
+// number of failed ones: base, doubling with every attempt, up to max.
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= max {
+			return max
+		}
+	}
+	return min(d, max)
+}
+
+// Request is one delivery attempt.
+type Request struct {
+	ID      string
+	URL     string
+	Body    []byte   // JSON payload
+	Secrets []string // Each signs the request; more than one while a secret is rotated
+}
+
+// StatusError is returned when the receiver answers with a status other than 2xx.
+type StatusError struct {
+	StatusCode int
+	Body       string // Start of the response body
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("webhook: receiver answered %d", e.StatusCode)
+	}
+	return fmt.Sprintf("webhook: receiver answered %d: %s", e.StatusCode, e.Body)
+}
+
+// Sender posts signed requests.
+type Sender struct {
+	Client *http.Client
+	Now    func() time.Time // time.Now when nil
+}
+
+// NewSender returns a sender whose requests time out after timeout.
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{Client: &http.Client{Timeout: timeout}}
+}
+
+// Send posts r and returns the status code of the response, or 0 when there
+// was none. A status other than 2xx is returned as a *StatusError.
+func (s *Sender) Send(ctx context.Context, r Request) (int, error) {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	timestamp := now().Unix()
+	sigs := make([]string, len(r.Secrets))
+	for i, secret := range r.Secrets {
+		sigs[i] = Sign(secret, timestamp, r.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, r.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, strings.Join(sigs, " "))
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+	}
+	io.Copy(io.Discard, resp.Body) // Lets the connection be reused
+	return resp.StatusCode, nil
+}
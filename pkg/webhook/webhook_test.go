@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// receiver stands in for a subscriber: it checks the signature with its
+// secret and answers with status.
+type receiver struct {
+	secret string
+	status int
+	got    []*http.Request
+	bodies []string
+	errs   []error
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.got = append(r.got, req)
+	r.bodies = append(r.bodies, string(body))
+	r.errs = append(r.errs, Verify(r.secret, req.Header, body, 5*time.Minute, time.Now()))
+	w.WriteHeader(r.status)
+	w.Write([]byte("try later\n"))
+}
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	now := time.Unix(1700000000, 0)
+	header := http.Header{}
+	header.Set(HeaderTimestamp, "1700000000")
+	header.Set(HeaderSignature, Sign("old", now.Unix(), body)+" "+Sign("new", now.Unix(), body))
+
+	for _, secret := range []string{"old", "new"} {
+		if err := Verify(secret, header, body, time.Minute, now); err != nil {
+			t.Errorf("secret %q: %v", secret, err)
+		}
+	}
+	if err := Verify("other", header, body, time.Minute, now); err == nil {
+		t.Error("expected another secret to fail")
+	}
+	if err := Verify("new", header, []byte(`{"id":2}`), time.Minute, now); err == nil {
+		t.Error("expected a changed body to fail")
+	}
+	if err := Verify("new", header, body, time.Minute, now.Add(2*time.Minute)); err == nil {
+		t.Error("expected an old timestamp to fail")
+	}
+}
+
+func TestSend(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(secret, secretPrefix) || len(secret) != len(secretPrefix)+64 {
+		t.Errorf("unexpected secret %q", secret)
+	}
+	recv := &receiver{secret: secret, status: http.StatusNoContent}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	s := &Sender{Client: srv.Client()}
+	req := Request{ID: "42", URL: srv.URL, Body: []byte(`{"subject":"inserted"}`), Secrets: []string{secret}}
+	code, err := s.Send(context.Background(), req)
+	if err != nil || code != http.StatusNoContent {
+		t.Fatalf("Send() = %d, %v", code, err)
+	}
+	if recv.errs[0] != nil {
+		t.Errorf("receiver rejected the signature: %v", recv.errs[0])
+	}
+	if h := recv.got[0].Header; h.Get(HeaderID) != "42" || h.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected headers %v", h)
+	}
+	if recv.bodies[0] != `{"subject":"inserted"}` {
+		t.Errorf("unexpected body %q", recv.bodies[0])
+	}
+
+	recv.status = http.StatusServiceUnavailable
+	code, err = s.Send(context.Background(), req)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || code != http.StatusServiceUnavailable || statusErr.Body != "try later" {
+		t.Errorf("Send() = %d, %v; want a 503 StatusError", code, err)
+	}
+
+	srv.Close()
+	if code, err := s.Send(context.Background(), req); err == nil || code != 0 {
+		t.Errorf("Send() to a closed server = %d, %v", code, err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{8, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts, 30*time.Second, time.Hour); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+-- Benchmark of the category filters against a few million synthetic POIs.
+--
+-- Builds a scratch copy of the category layout in the category_bench schema,
+-- then prints EXPLAIN ANALYZE plans of the old unnest/ILIKE filter and of the
+-- indexed exact, prefix, substring and fuzzy filters. Run with
+--
+--   psql "$DATABASE_URL" -v rows=3000000 -f scripts/bench_category_search.sql
+--
+-- The indexed plans should show Bitmap Index Scans on
+-- idx_bench_categories_trgm or idx_bench_categories_norm; the old filter
+-- shows a Function Scan on unnest for every candidate row.
+
+\set ON_ERROR_STOP on
+\if :{?rows}
+\else
+  \set rows 3000000
+\endif
+\timing on
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS h3;
+
+DROP SCHEMA IF EXISTS category_bench CASCADE;
+CREATE SCHEMA category_bench;
+
+-- Places spread over Europe, with two to four categories each
+CREATE TABLE category_bench.places AS
+SELECT g AS id,
+       h3_lat_lng_to_cell(point(lng, lat), 9)::text AS h3_index,
+       ARRAY(
+         SELECT (ARRAY[
+           'Restaurant', 'Pizza restaurant', 'Italian restaurant', 'Sushi restaurant',
+           'Cafe', 'Coffee shop', 'Bakery', 'Bar', 'Pub', 'Hotel', 'Hostel',
+           'Museum', 'Art gallery', 'Park', 'Supermarket', 'Pharmacy',
+           'Gas station', 'Clothing store', 'Book store', 'Dentist'
+         ])[1 + ((g * 7 + k * 13) % 20)]
+         FROM generate_series(1, 2 + g % 3) AS k
+       ) AS categories
+FROM generate_series(1, :rows) AS g
+CROSS JOIN LATERAL (
+  SELECT 36 + random() * 34 AS lat, -10 + random() * 40 AS lng
+) AS p;
+
+ALTER TABLE category_bench.places ADD PRIMARY KEY (id);
+CREATE INDEX idx_bench_places_h3 ON category_bench.places (h3_index);
+
+CREATE TABLE category_bench.categories AS
+SELECT p.id AS poi_id, min(cat) AS category, lower(regexp_replace(btrim(cat), '\s+', ' ', 'g')) AS category_norm
+FROM category_bench.places p
+CROSS JOIN LATERAL unnest(p.categories) AS cat
+GROUP BY p.id, 3;
+
+ALTER TABLE category_bench.categories ADD PRIMARY KEY (poi_id, category_norm);
+CREATE INDEX idx_bench_categories_norm
+  ON category_bench.categories (category_norm text_pattern_ops);
+CREATE INDEX idx_bench_categories_trgm
+  ON category_bench.categories USING GIN (category_norm gin_trgm_ops);
+
+ANALYZE category_bench.places;
+ANALYZE category_bench.categories;
+
+-- Roughly Gothenburg, the same box for every filter
+\set box 'SELECT unnest(h3_polyfill(ST_MakeEnvelope(11.8, 57.6, 12.1, 57.8, 4326), 9))::text AS h3_cell'
+
+\echo 'Old filter: unnest + ILIKE'
+EXPLAIN (ANALYZE, BUFFERS)
+WITH cells AS (:box)
+SELECT p.id FROM category_bench.places p JOIN cells c ON p.h3_index = c.h3_cell
+WHERE EXISTS (SELECT 1 FROM unnest(p.categories) cat WHERE cat ILIKE '%' || 'pizza' || '%');
+
+\echo 'Old filter without a box: unnest + ILIKE over every place'
+EXPLAIN (ANALYZE, BUFFERS)
+SELECT p.id FROM category_bench.places p
+WHERE EXISTS (SELECT 1 FROM unnest(p.categories) cat WHERE cat ILIKE '%' || 'sushi' || '%');
+
+\echo 'Exact'
+EXPLAIN (ANALYZE, BUFFERS)
+WITH cells AS (:box)
+SELECT p.id FROM category_bench.places p JOIN cells c ON p.h3_index = c.h3_cell
+WHERE p.id IN (SELECT poi_id FROM category_bench.categories WHERE category_norm LIKE 'sushi restaurant');
+
+\echo 'Prefix'
+EXPLAIN (ANALYZE, BUFFERS)
+WITH cells AS (:box)
+SELECT p.id FROM category_bench.places p JOIN cells c ON p.h3_index = c.h3_cell
+WHERE p.id IN (SELECT poi_id FROM category_bench.categories WHERE category_norm LIKE 'sushi%');
+
+\echo 'Substring'
+EXPLAIN (ANALYZE, BUFFERS)
+WITH cells AS (:box)
+SELECT p.id FROM category_bench.places p JOIN cells c ON p.h3_index = c.h3_cell
+WHERE p.id IN (SELECT poi_id FROM category_bench.categories WHERE category_norm LIKE '%pizza%');
+
+\echo 'Substring without a box'
+EXPLAIN (ANALYZE, BUFFERS)
+SELECT poi_id FROM category_bench.categories WHERE category_norm LIKE '%sushi%';
+
+\echo 'Fuzzy'
+EXPLAIN (ANALYZE, BUFFERS)
+WITH cells AS (:box)
+SELECT p.id FROM category_bench.places p JOIN cells c ON p.h3_index = c.h3_cell
+WHERE p.id IN (SELECT poi_id FROM category_bench.categories WHERE 'piza' <% category_norm);
+
+\echo 'Drop the scratch data with: DROP SCHEMA category_bench CASCADE;'